@@ -0,0 +1,548 @@
+package admin
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/lib/pq"
+	logger "github.com/sirupsen/logrus"
+
+	postgresdriver "github.com/pokt-foundation/portal-api-go/postgres-driver"
+	"github.com/pokt-foundation/portal-api-go/repository"
+)
+
+const (
+	maxRequestBodySize = 1 << 20
+
+	// actorHeader identifies who makes the changes, recorded in the audit log
+	actorHeader  = "X-Actor"
+	defaultActor = "admin"
+)
+
+// Driver contains the write operations served by the admin API, e.g. postgresdriver.PostgresDriver
+type Driver interface {
+	WriteApplicationContext(ctx context.Context, app *repository.Application) (*repository.Application, error)
+	UpdateApplicationContext(ctx context.Context, id string, fieldsToUpdate *repository.UpdateApplication) error
+	RemoveApplicationContext(ctx context.Context, id string) error
+	WriteLoadBalancerContext(ctx context.Context, loadBalancer *repository.LoadBalancer) (*repository.LoadBalancer, error)
+	UpdateLoadBalancerContext(ctx context.Context, id string, fieldsToUpdate *repository.UpdateLoadBalancer) error
+	RemoveLoadBalancerContext(ctx context.Context, id string) error
+	AddLbAppsContext(ctx context.Context, id string, appIDs []string) error
+	RemoveLbAppsContext(ctx context.Context, id string, appIDs []string) error
+	WriteBlockchainContext(ctx context.Context, blockchain *repository.Blockchain) (*repository.Blockchain, error)
+	UpdateBlockchainContext(ctx context.Context, id string, fieldsToUpdate *repository.UpdateBlockchain) error
+	ActivateBlockchainContext(ctx context.Context, id string, active bool) error
+	WriteRedirectContext(ctx context.Context, redirect *repository.Redirect) (*repository.Redirect, error)
+	RemoveRedirectContext(ctx context.Context, blockchainID, domain string) error
+	ReadPayPlansContext(ctx context.Context) ([]*repository.PayPlan, error)
+	ReadAuditLogContext(ctx context.Context, entityID string) ([]*postgresdriver.AuditEntry, error)
+	ReadApplicationContext(ctx context.Context, id string) (*repository.Application, error)
+	ReadApplicationsPageContext(ctx context.Context, filter postgresdriver.ApplicationFilter, page postgresdriver.Page) ([]*repository.Application, string, error)
+	ReadLoadBalancerContext(ctx context.Context, id string) (*repository.LoadBalancer, error)
+	ReadLoadBalancersPageContext(ctx context.Context, filter postgresdriver.LoadBalancerFilter, page postgresdriver.Page) ([]*repository.LoadBalancer, string, error)
+	ReadBlockchainsPageContext(ctx context.Context, filter postgresdriver.BlockchainFilter, page postgresdriver.Page) ([]*repository.Blockchain, string, error)
+}
+
+var (
+	ErrUnauthorized     = errors.New("missing or invalid authorization token")
+	ErrNotFound         = errors.New("resource not found")
+	ErrMethodNotAllowed = errors.New("method not allowed")
+	ErrInvalidBody      = errors.New("invalid request body")
+	ErrInvalidQuery     = errors.New("invalid query parameter")
+
+	// validationErrors are returned to the client with a 400 status code
+	validationErrors = []error{
+		repository.ErrNoFieldsToUpdate,
+		repository.ErrInvalidAppStatus,
+		repository.ErrInvalidPayPlanType,
+		repository.ErrNotEnterprisePlan,
+		repository.ErrEnterprisePlanNeedsCustomLimit,
+		repository.ErrMissingID,
+		repository.ErrMissingRedirectFields,
+		repository.ErrInvalidBlockchainAlias,
+		repository.ErrNegativeBlockchainSetting,
+		postgresdriver.ErrMissingID,
+		postgresdriver.ErrNoFieldsToUpdate,
+		postgresdriver.ErrMissingApplicationIDs,
+		postgresdriver.ErrMissingDomain,
+		ErrInvalidBody,
+		ErrInvalidQuery,
+	}
+)
+
+// ErrorResponse is the body of every non successful response
+type ErrorResponse struct {
+	Error string `json:"error"`
+}
+
+// LbApplications is the request body to add or remove applications of a load balancer
+type LbApplications struct {
+	ApplicationIDs []string `json:"applicationIDs"`
+}
+
+// ActivateBlockchain is the request body to activate or deactivate a blockchain
+type ActivateBlockchain struct {
+	Active bool `json:"active"`
+}
+
+type adminServer struct {
+	driver Driver
+	token  string
+	log    *logger.Logger
+}
+
+// NewAdminServer returns the handler of the admin API, every request must be authenticated with
+// the given token as: "Authorization: Bearer <token>". Changes are recorded in the audit log
+// as made by the actor in the X-Actor header, "admin" if not set. Lists are paginated, see PageResponse. Serves:
+//
+//	GET    /v1/applications?userID=&status=&payPlan=&loadBalancerID=&updatedSince=&cursor=&limit=
+//	POST   /v1/applications
+//	GET    /v1/applications/{id}
+//	PUT    /v1/applications/{id}
+//	DELETE /v1/applications/{id}
+//	GET    /v1/loadbalancers?userID=&applicationID=&updatedSince=&cursor=&limit=
+//	POST   /v1/loadbalancers
+//	GET    /v1/loadbalancers/{id}
+//	PUT    /v1/loadbalancers/{id}
+//	DELETE /v1/loadbalancers/{id}
+//	POST   /v1/loadbalancers/{id}/applications
+//	DELETE /v1/loadbalancers/{id}/applications
+//	GET    /v1/blockchains?updatedSince=&cursor=&limit=
+//	POST   /v1/blockchains
+//	PUT    /v1/blockchains/{id}
+//	PUT    /v1/blockchains/{id}/activate
+//	POST   /v1/redirects
+//	DELETE /v1/redirects/{blockchainID}/{domain}
+//	GET    /v1/payplans
+//	GET    /v1/audit/{entityID}
+func NewAdminServer(driver Driver, token string, log *logger.Logger) http.Handler {
+	s := &adminServer{
+		driver: driver,
+		token:  token,
+		log:    log,
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/applications", s.handleApplications)
+	mux.HandleFunc("/v1/applications/", s.handleApplication)
+	mux.HandleFunc("/v1/loadbalancers", s.handleLoadBalancers)
+	mux.HandleFunc("/v1/loadbalancers/", s.handleLoadBalancer)
+	mux.HandleFunc("/v1/blockchains", s.handleBlockchains)
+	mux.HandleFunc("/v1/blockchains/", s.handleBlockchain)
+	mux.HandleFunc("/v1/redirects", s.handleRedirects)
+	mux.HandleFunc("/v1/redirects/", s.handleRedirect)
+	mux.HandleFunc("/v1/payplans", s.handlePayPlans)
+	mux.HandleFunc("/v1/audit/", s.handleAuditLog)
+	mux.HandleFunc("/", func(w http.ResponseWriter, req *http.Request) {
+		s.writeError(w, req, http.StatusNotFound, ErrNotFound)
+	})
+
+	return s.authenticate(mux)
+}
+
+func (s *adminServer) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		token := strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer ")
+		if s.token == "" || subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			s.writeError(w, req, http.StatusUnauthorized, ErrUnauthorized)
+			return
+		}
+
+		actor := req.Header.Get(actorHeader)
+		if actor == "" {
+			actor = defaultActor
+		}
+
+		next.ServeHTTP(w, req.WithContext(postgresdriver.WithActor(req.Context(), actor)))
+	})
+}
+
+func (s *adminServer) handleApplications(w http.ResponseWriter, req *http.Request) {
+	if req.Method == http.MethodGet {
+		s.listApplications(w, req)
+		return
+	}
+	if req.Method != http.MethodPost {
+		s.methodNotAllowed(w, req, http.MethodGet, http.MethodPost)
+		return
+	}
+
+	var app repository.Application
+	if !s.decode(w, req, &app) {
+		return
+	}
+	if err := app.Validate(); err != nil {
+		s.writeDriverError(w, req, err)
+		return
+	}
+
+	created, err := s.driver.WriteApplicationContext(req.Context(), &app)
+	if err != nil {
+		s.writeDriverError(w, req, err)
+		return
+	}
+
+	s.writeCreated(w, req, created)
+}
+
+func (s *adminServer) handleApplication(w http.ResponseWriter, req *http.Request) {
+	id, ok := s.pathID(w, req, "/v1/applications/")
+	if !ok {
+		return
+	}
+
+	switch req.Method {
+	case http.MethodGet:
+		app, err := s.driver.ReadApplicationContext(req.Context(), id)
+		if err != nil {
+			s.writeDriverError(w, req, err)
+			return
+		}
+		s.writeJSON(w, http.StatusOK, app)
+	case http.MethodPut:
+		var update repository.UpdateApplication
+		if !s.decode(w, req, &update) {
+			return
+		}
+		if err := update.Validate(); err != nil {
+			s.writeDriverError(w, req, err)
+			return
+		}
+		s.writeResult(w, req, s.driver.UpdateApplicationContext(req.Context(), id, &update))
+	case http.MethodDelete:
+		s.writeResult(w, req, s.driver.RemoveApplicationContext(req.Context(), id))
+	default:
+		s.methodNotAllowed(w, req, http.MethodGet, http.MethodPut, http.MethodDelete)
+	}
+}
+
+func (s *adminServer) handleLoadBalancers(w http.ResponseWriter, req *http.Request) {
+	if req.Method == http.MethodGet {
+		s.listLoadBalancers(w, req)
+		return
+	}
+	if req.Method != http.MethodPost {
+		s.methodNotAllowed(w, req, http.MethodGet, http.MethodPost)
+		return
+	}
+
+	var lb repository.LoadBalancer
+	if !s.decode(w, req, &lb) {
+		return
+	}
+
+	created, err := s.driver.WriteLoadBalancerContext(req.Context(), &lb)
+	if err != nil {
+		s.writeDriverError(w, req, err)
+		return
+	}
+
+	s.writeCreated(w, req, created)
+}
+
+func (s *adminServer) handleLoadBalancer(w http.ResponseWriter, req *http.Request) {
+	if strings.HasSuffix(req.URL.Path, "/applications") {
+		s.handleLbApplications(w, req)
+		return
+	}
+
+	id, ok := s.pathID(w, req, "/v1/loadbalancers/")
+	if !ok {
+		return
+	}
+
+	switch req.Method {
+	case http.MethodGet:
+		lb, err := s.driver.ReadLoadBalancerContext(req.Context(), id)
+		if err != nil {
+			s.writeDriverError(w, req, err)
+			return
+		}
+		s.writeJSON(w, http.StatusOK, lb)
+	case http.MethodPut:
+		var update repository.UpdateLoadBalancer
+		if !s.decode(w, req, &update) {
+			return
+		}
+		if err := update.Validate(); err != nil {
+			s.writeDriverError(w, req, err)
+			return
+		}
+		s.writeResult(w, req, s.driver.UpdateLoadBalancerContext(req.Context(), id, &update))
+	case http.MethodDelete:
+		s.writeResult(w, req, s.driver.RemoveLoadBalancerContext(req.Context(), id))
+	default:
+		s.methodNotAllowed(w, req, http.MethodGet, http.MethodPut, http.MethodDelete)
+	}
+}
+
+// handleLbApplications serves /v1/loadbalancers/{id}/applications
+func (s *adminServer) handleLbApplications(w http.ResponseWriter, req *http.Request) {
+	id := strings.TrimSuffix(strings.TrimPrefix(req.URL.Path, "/v1/loadbalancers/"), "/applications")
+	if id == "" || strings.Contains(id, "/") {
+		s.writeError(w, req, http.StatusNotFound, ErrNotFound)
+		return
+	}
+
+	if req.Method != http.MethodPost && req.Method != http.MethodDelete {
+		s.methodNotAllowed(w, req, http.MethodPost, http.MethodDelete)
+		return
+	}
+
+	var apps LbApplications
+	if !s.decode(w, req, &apps) {
+		return
+	}
+
+	if req.Method == http.MethodPost {
+		s.writeResult(w, req, s.driver.AddLbAppsContext(req.Context(), id, apps.ApplicationIDs))
+		return
+	}
+	s.writeResult(w, req, s.driver.RemoveLbAppsContext(req.Context(), id, apps.ApplicationIDs))
+}
+
+func (s *adminServer) handleBlockchains(w http.ResponseWriter, req *http.Request) {
+	if req.Method == http.MethodGet {
+		s.listBlockchains(w, req)
+		return
+	}
+	if req.Method != http.MethodPost {
+		s.methodNotAllowed(w, req, http.MethodGet, http.MethodPost)
+		return
+	}
+
+	var blockchain repository.Blockchain
+	if !s.decode(w, req, &blockchain) {
+		return
+	}
+	if err := blockchain.Validate(); err != nil {
+		s.writeDriverError(w, req, err)
+		return
+	}
+
+	created, err := s.driver.WriteBlockchainContext(req.Context(), &blockchain)
+	if err != nil {
+		s.writeDriverError(w, req, err)
+		return
+	}
+
+	s.writeCreated(w, req, created)
+}
+
+func (s *adminServer) handleBlockchain(w http.ResponseWriter, req *http.Request) {
+	if strings.HasSuffix(req.URL.Path, "/activate") {
+		s.handleBlockchainActivation(w, req)
+		return
+	}
+
+	id, ok := s.pathID(w, req, "/v1/blockchains/")
+	if !ok {
+		return
+	}
+
+	if req.Method != http.MethodPut {
+		s.methodNotAllowed(w, req, http.MethodPut)
+		return
+	}
+
+	var update repository.UpdateBlockchain
+	if !s.decode(w, req, &update) {
+		return
+	}
+	if err := update.Validate(); err != nil {
+		s.writeDriverError(w, req, err)
+		return
+	}
+
+	s.writeResult(w, req, s.driver.UpdateBlockchainContext(req.Context(), id, &update))
+}
+
+// handleBlockchainActivation serves /v1/blockchains/{id}/activate
+func (s *adminServer) handleBlockchainActivation(w http.ResponseWriter, req *http.Request) {
+	id := strings.TrimSuffix(strings.TrimPrefix(req.URL.Path, "/v1/blockchains/"), "/activate")
+	if id == "" || strings.Contains(id, "/") {
+		s.writeError(w, req, http.StatusNotFound, ErrNotFound)
+		return
+	}
+
+	if req.Method != http.MethodPut {
+		s.methodNotAllowed(w, req, http.MethodPut)
+		return
+	}
+
+	var activate ActivateBlockchain
+	if !s.decode(w, req, &activate) {
+		return
+	}
+
+	s.writeResult(w, req, s.driver.ActivateBlockchainContext(req.Context(), id, activate.Active))
+}
+
+func (s *adminServer) handleRedirects(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		s.methodNotAllowed(w, req, http.MethodPost)
+		return
+	}
+
+	var redirect repository.Redirect
+	if !s.decode(w, req, &redirect) {
+		return
+	}
+	if err := redirect.Validate(); err != nil {
+		s.writeDriverError(w, req, err)
+		return
+	}
+
+	created, err := s.driver.WriteRedirectContext(req.Context(), &redirect)
+	if err != nil {
+		s.writeDriverError(w, req, err)
+		return
+	}
+
+	s.writeCreated(w, req, created)
+}
+
+// handleRedirect serves /v1/redirects/{blockchainID}/{domain}
+func (s *adminServer) handleRedirect(w http.ResponseWriter, req *http.Request) {
+	parts := strings.Split(strings.TrimPrefix(req.URL.Path, "/v1/redirects/"), "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		s.writeError(w, req, http.StatusNotFound, ErrNotFound)
+		return
+	}
+
+	if req.Method != http.MethodDelete {
+		s.methodNotAllowed(w, req, http.MethodDelete)
+		return
+	}
+
+	s.writeResult(w, req, s.driver.RemoveRedirectContext(req.Context(), parts[0], parts[1]))
+}
+
+func (s *adminServer) handlePayPlans(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet {
+		s.methodNotAllowed(w, req, http.MethodGet)
+		return
+	}
+
+	payPlans, err := s.driver.ReadPayPlansContext(req.Context())
+	if err != nil {
+		s.writeDriverError(w, req, err)
+		return
+	}
+
+	s.writeJSON(w, http.StatusOK, payPlans)
+}
+
+// handleAuditLog serves /v1/audit/{entityID}, the changes recorded for the entity, latest first
+func (s *adminServer) handleAuditLog(w http.ResponseWriter, req *http.Request) {
+	id, ok := s.pathID(w, req, "/v1/audit/")
+	if !ok {
+		return
+	}
+
+	if req.Method != http.MethodGet {
+		s.methodNotAllowed(w, req, http.MethodGet)
+		return
+	}
+
+	entries, err := s.driver.ReadAuditLogContext(req.Context(), id)
+	if err != nil {
+		s.writeDriverError(w, req, err)
+		return
+	}
+
+	s.writeJSON(w, http.StatusOK, entries)
+}
+
+// pathID returns the single path segment following prefix, writing a 404 if there is none
+func (s *adminServer) pathID(w http.ResponseWriter, req *http.Request, prefix string) (string, bool) {
+	id := strings.TrimPrefix(req.URL.Path, prefix)
+	if id == "" || strings.Contains(id, "/") {
+		s.writeError(w, req, http.StatusNotFound, ErrNotFound)
+		return "", false
+	}
+
+	return id, true
+}
+
+func (s *adminServer) decode(w http.ResponseWriter, req *http.Request, v any) bool {
+	decoder := json.NewDecoder(http.MaxBytesReader(w, req.Body, maxRequestBodySize))
+	decoder.DisallowUnknownFields()
+
+	if err := decoder.Decode(v); err != nil {
+		s.writeError(w, req, http.StatusBadRequest, fmt.Errorf("%w: %s", ErrInvalidBody, err))
+		return false
+	}
+
+	return true
+}
+
+func (s *adminServer) methodNotAllowed(w http.ResponseWriter, req *http.Request, allowed ...string) {
+	w.Header().Set("Allow", strings.Join(allowed, ", "))
+	s.writeError(w, req, http.StatusMethodNotAllowed, ErrMethodNotAllowed)
+}
+
+func (s *adminServer) writeResult(w http.ResponseWriter, req *http.Request, err error) {
+	if err != nil {
+		s.writeDriverError(w, req, err)
+		return
+	}
+
+	s.log.WithFields(logger.Fields{"method": req.Method, "path": req.URL.Path}).Info("Admin request processed")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *adminServer) writeCreated(w http.ResponseWriter, req *http.Request, created any) {
+	s.log.WithFields(logger.Fields{"method": req.Method, "path": req.URL.Path}).Info("Admin request processed")
+	s.writeJSON(w, http.StatusCreated, created)
+}
+
+// writeDriverError maps validation and database errors to the corresponding status code
+func (s *adminServer) writeDriverError(w http.ResponseWriter, req *http.Request, err error) {
+	if errors.Is(err, postgresdriver.ErrNotFound) {
+		s.writeError(w, req, http.StatusNotFound, ErrNotFound)
+		return
+	}
+
+	for _, validationErr := range validationErrors {
+		if errors.Is(err, validationErr) {
+			s.writeError(w, req, http.StatusBadRequest, err)
+			return
+		}
+	}
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch pqErr.Code.Name() {
+		case "unique_violation":
+			s.writeError(w, req, http.StatusConflict, errors.New(pqErr.Message))
+			return
+		case "foreign_key_violation", "not_null_violation", "check_violation":
+			s.writeError(w, req, http.StatusUnprocessableEntity, errors.New(pqErr.Message))
+			return
+		}
+	}
+
+	s.log.WithFields(logger.Fields{"method": req.Method, "path": req.URL.Path, "error": err}).Error("Error processing admin request")
+	s.writeJSON(w, http.StatusInternalServerError, ErrorResponse{Error: http.StatusText(http.StatusInternalServerError)})
+}
+
+func (s *adminServer) writeError(w http.ResponseWriter, req *http.Request, status int, err error) {
+	s.log.WithFields(logger.Fields{"method": req.Method, "path": req.URL.Path, "status": status, "error": err}).Warn("Invalid admin request")
+	s.writeJSON(w, status, ErrorResponse{Error: err.Error()})
+}
+
+func (s *adminServer) writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		s.log.WithFields(logger.Fields{"error": err}).Warn("Error writing admin response")
+	}
+}
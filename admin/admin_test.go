@@ -0,0 +1,540 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/lib/pq"
+	logger "github.com/sirupsen/logrus"
+
+	postgresdriver "github.com/pokt-foundation/portal-api-go/postgres-driver"
+	"github.com/pokt-foundation/portal-api-go/repository"
+)
+
+const testToken = "admin-token"
+
+func TestAdminServer(t *testing.T) {
+	testCases := []struct {
+		name           string
+		method         string
+		path           string
+		body           string
+		token          string
+		actor          string
+		driverErr      error
+		expectedStatus int
+		expectedCalls  []string
+		expectedBody   string
+	}{
+		{
+			name:           "Missing token is rejected",
+			method:         http.MethodGet,
+			path:           "/v1/payplans",
+			expectedStatus: http.StatusUnauthorized,
+			expectedBody:   `{"error":"missing or invalid authorization token"}`,
+		},
+		{
+			name:           "Invalid token is rejected",
+			method:         http.MethodGet,
+			path:           "/v1/payplans",
+			token:          "wrong-token",
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "Pay plans are returned",
+			method:         http.MethodGet,
+			path:           "/v1/payplans",
+			token:          testToken,
+			expectedStatus: http.StatusOK,
+			expectedCalls:  []string{"ReadPayPlans"},
+			expectedBody:   `[{"planType":"FREETIER_V0","dailyLimit":250000}]`,
+		},
+		{
+			name:           "Application is created",
+			method:         http.MethodPost,
+			path:           "/v1/applications",
+			body:           `{"name":"app-1","status":"IN_SERVICE","gatewayAAT":{"privateKey":"private-key"}}`,
+			token:          testToken,
+			expectedStatus: http.StatusCreated,
+			expectedCalls:  []string{"WriteApplication"},
+		},
+		{
+			name:           "Invalid application is rejected",
+			method:         http.MethodPost,
+			path:           "/v1/applications",
+			body:           `{"name":"app-1","status":"NOT_A_STATUS"}`,
+			token:          testToken,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"error":"invalid app status"}`,
+		},
+		{
+			name:           "Unknown fields are rejected",
+			method:         http.MethodPost,
+			path:           "/v1/applications",
+			body:           `{"nmae":"app-1"}`,
+			token:          testToken,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Application is updated",
+			method:         http.MethodPut,
+			path:           "/v1/applications/app-1",
+			body:           `{"name":"app-2"}`,
+			token:          testToken,
+			expectedStatus: http.StatusNoContent,
+			expectedCalls:  []string{"UpdateApplication app-1"},
+		},
+		{
+			name:           "Application is removed",
+			method:         http.MethodDelete,
+			path:           "/v1/applications/app-1",
+			token:          testToken,
+			expectedStatus: http.StatusNoContent,
+			expectedCalls:  []string{"RemoveApplication app-1"},
+		},
+		{
+			name:           "Missing application is not updated",
+			method:         http.MethodPut,
+			path:           "/v1/applications/app-1",
+			body:           `{"name":"app-2"}`,
+			token:          testToken,
+			driverErr:      postgresdriver.ErrNotFound,
+			expectedStatus: http.StatusNotFound,
+			expectedCalls:  []string{"UpdateApplication app-1"},
+		},
+		{
+			name:           "Missing application is not removed",
+			method:         http.MethodDelete,
+			path:           "/v1/applications/app-1",
+			token:          testToken,
+			driverErr:      postgresdriver.ErrNotFound,
+			expectedStatus: http.StatusNotFound,
+			expectedCalls:  []string{"RemoveApplication app-1"},
+		},
+		{
+			name:           "Unsupported method is rejected",
+			method:         http.MethodPatch,
+			path:           "/v1/applications/app-1",
+			token:          testToken,
+			expectedStatus: http.StatusMethodNotAllowed,
+		},
+		{
+			name:           "Load balancer is created",
+			method:         http.MethodPost,
+			path:           "/v1/loadbalancers",
+			body:           `{"name":"lb-1","applicationIDs":["app-1"]}`,
+			token:          testToken,
+			expectedStatus: http.StatusCreated,
+			expectedCalls:  []string{"WriteLoadBalancer"},
+		},
+		{
+			name:           "Empty load balancer update is rejected",
+			method:         http.MethodPut,
+			path:           "/v1/loadbalancers/lb-1",
+			body:           `{}`,
+			token:          testToken,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Load balancer is removed",
+			method:         http.MethodDelete,
+			path:           "/v1/loadbalancers/lb-1",
+			token:          testToken,
+			expectedStatus: http.StatusNoContent,
+			expectedCalls:  []string{"RemoveLoadBalancer lb-1"},
+		},
+		{
+			name:           "Missing load balancer is not removed",
+			method:         http.MethodDelete,
+			path:           "/v1/loadbalancers/lb-1",
+			token:          testToken,
+			driverErr:      postgresdriver.ErrNotFound,
+			expectedStatus: http.StatusNotFound,
+			expectedCalls:  []string{"RemoveLoadBalancer lb-1"},
+		},
+		{
+			name:           "Applications are added to load balancer",
+			method:         http.MethodPost,
+			path:           "/v1/loadbalancers/lb-1/applications",
+			body:           `{"applicationIDs":["app-1","app-2"]}`,
+			token:          testToken,
+			expectedStatus: http.StatusNoContent,
+			expectedCalls:  []string{"AddLbApps lb-1 app-1 app-2"},
+		},
+		{
+			name:           "Applications are removed from load balancer",
+			method:         http.MethodDelete,
+			path:           "/v1/loadbalancers/lb-1/applications",
+			body:           `{"applicationIDs":["app-1"]}`,
+			token:          testToken,
+			expectedStatus: http.StatusNoContent,
+			expectedCalls:  []string{"RemoveLbApps lb-1 app-1"},
+		},
+		{
+			name:           "Missing application IDs are rejected",
+			method:         http.MethodPost,
+			path:           "/v1/loadbalancers/lb-1/applications",
+			body:           `{"applicationIDs":[]}`,
+			token:          testToken,
+			driverErr:      postgresdriver.ErrMissingApplicationIDs,
+			expectedStatus: http.StatusBadRequest,
+			expectedCalls:  []string{"AddLbApps lb-1"},
+		},
+		{
+			name:           "Blockchain without ID is rejected",
+			method:         http.MethodPost,
+			path:           "/v1/blockchains",
+			body:           `{"ticker":"POKT"}`,
+			token:          testToken,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Duplicate blockchain returns conflict",
+			method:         http.MethodPost,
+			path:           "/v1/blockchains",
+			body:           `{"id":"0001"}`,
+			token:          testToken,
+			driverErr:      &pq.Error{Code: "23505", Message: "duplicate key value"},
+			expectedStatus: http.StatusConflict,
+			expectedCalls:  []string{"WriteBlockchain"},
+		},
+		{
+			name:           "Blockchain is activated",
+			method:         http.MethodPut,
+			path:           "/v1/blockchains/0001/activate",
+			body:           `{"active":true}`,
+			token:          testToken,
+			expectedStatus: http.StatusNoContent,
+			expectedCalls:  []string{"ActivateBlockchain 0001 true"},
+		},
+		{
+			name:           "Blockchain is updated",
+			method:         http.MethodPut,
+			path:           "/v1/blockchains/0001",
+			body:           `{"blockchainAliases":["pokt-mainnet"],"requestTimeout":10}`,
+			token:          testToken,
+			expectedStatus: http.StatusNoContent,
+			expectedCalls:  []string{"UpdateBlockchain 0001"},
+		},
+		{
+			name:           "Invalid blockchain update is rejected",
+			method:         http.MethodPut,
+			path:           "/v1/blockchains/0001",
+			body:           `{"blockchainAliases":["pokt-mainnet","POKT-MAINNET"]}`,
+			token:          testToken,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"error":"blockchain aliases must be unique and not empty"}`,
+		},
+		{
+			name:           "Redirect is removed",
+			method:         http.MethodDelete,
+			path:           "/v1/redirects/0001/pokt.network",
+			token:          testToken,
+			expectedStatus: http.StatusNoContent,
+			expectedCalls:  []string{"RemoveRedirect 0001 pokt.network"},
+		},
+		{
+			name:           "Redirect is created",
+			method:         http.MethodPost,
+			path:           "/v1/redirects",
+			body:           `{"blockchainID":"0001","alias":"pokt-mainnet","domain":"pokt.network","loadBalancerID":"lb-1"}`,
+			token:          testToken,
+			expectedStatus: http.StatusCreated,
+			expectedCalls:  []string{"WriteRedirect"},
+		},
+		{
+			name:           "Redirect referencing unknown blockchain is unprocessable",
+			method:         http.MethodPost,
+			path:           "/v1/redirects",
+			body:           `{"blockchainID":"9999","alias":"pokt-mainnet","domain":"pokt.network"}`,
+			token:          testToken,
+			driverErr:      &pq.Error{Code: "23503", Message: "foreign key violation"},
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedCalls:  []string{"WriteRedirect"},
+		},
+		{
+			name:           "Unexpected driver errors are not returned to the client",
+			method:         http.MethodDelete,
+			path:           "/v1/applications/app-1",
+			token:          testToken,
+			driverErr:      errors.New("connection refused"),
+			expectedStatus: http.StatusInternalServerError,
+			expectedCalls:  []string{"RemoveApplication app-1"},
+			expectedBody:   `{"error":"Internal Server Error"}`,
+		},
+		{
+			name:           "Applications are listed",
+			method:         http.MethodGet,
+			path:           "/v1/applications?userID=user-1&status=IN_SERVICE&payPlan=FREETIER_V0&loadBalancerID=lb-1&updatedSince=2022-01-01T00:00:00Z&cursor=app-0&limit=1",
+			token:          testToken,
+			expectedStatus: http.StatusOK,
+			expectedCalls:  []string{"ReadPayPlans", "ReadApplicationsPage user-1 IN_SERVICE FREETIER_V0 lb-1 2022-01-01T00:00:00Z app-0 1"},
+			expectedBody:   `{"data":[{"id":"app-1","userID":"user-1","name":"","contactEmail":"","description":"","owner":"","url":"","dummy":false,"status":"IN_SERVICE","firstDateSurpassed":"0001-01-01T00:00:00Z","createdAt":"0001-01-01T00:00:00Z","updatedAt":"0001-01-01T00:00:00Z","gatewayAAT":{"address":"","applicationPublicKey":"","applicationSignature":"","clientPublicKey":"","privateKey":"[REDACTED]","version":""},"gatewaySettings":{"secretKey":"","secretKeyRequired":false},"limit":{"payPlan":{"planType":"","dailyLimit":0},"customLimit":0},"notificationSettings":{"signedUp":false,"quarter":false,"half":false,"threeQuarters":false,"full":false}}],"nextCursor":"app-1"}`,
+		},
+		{
+			name:           "Invalid application list filter is rejected",
+			method:         http.MethodGet,
+			path:           "/v1/applications?status=NOT_A_STATUS",
+			token:          testToken,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Application list filter on a pay plan missing from the catalogue is rejected",
+			method:         http.MethodGet,
+			path:           "/v1/applications?payPlan=ENTERPRISE",
+			token:          testToken,
+			expectedStatus: http.StatusBadRequest,
+			expectedCalls:  []string{"ReadPayPlans"},
+			expectedBody:   `{"error":"invalid pay plan type: ENTERPRISE"}`,
+		},
+		{
+			name:           "Invalid page limit is rejected",
+			method:         http.MethodGet,
+			path:           "/v1/applications?limit=-1",
+			token:          testToken,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Application is returned",
+			method:         http.MethodGet,
+			path:           "/v1/applications/app-1",
+			token:          testToken,
+			expectedStatus: http.StatusOK,
+			expectedCalls:  []string{"ReadApplication app-1"},
+		},
+		{
+			name:           "Missing application is not found",
+			method:         http.MethodGet,
+			path:           "/v1/applications/app-1",
+			token:          testToken,
+			driverErr:      postgresdriver.ErrNotFound,
+			expectedStatus: http.StatusNotFound,
+			expectedCalls:  []string{"ReadApplication app-1"},
+		},
+		{
+			name:           "Load balancers are listed",
+			method:         http.MethodGet,
+			path:           "/v1/loadbalancers?applicationID=app-1",
+			token:          testToken,
+			expectedStatus: http.StatusOK,
+			expectedCalls:  []string{"ReadLoadBalancersPage  app-1"},
+		},
+		{
+			name:           "Load balancer is returned",
+			method:         http.MethodGet,
+			path:           "/v1/loadbalancers/lb-1",
+			token:          testToken,
+			expectedStatus: http.StatusOK,
+			expectedCalls:  []string{"ReadLoadBalancer lb-1"},
+		},
+		{
+			name:           "Blockchains are listed",
+			method:         http.MethodGet,
+			path:           "/v1/blockchains?updatedSince=2022-01-01T00:00:00Z",
+			token:          testToken,
+			expectedStatus: http.StatusOK,
+			expectedCalls:  []string{"ReadBlockchainsPage 2022-01-01T00:00:00Z"},
+			expectedBody:   `{"data":[]}`,
+		},
+		{
+			name:           "Invalid updatedSince is rejected",
+			method:         http.MethodGet,
+			path:           "/v1/blockchains?updatedSince=yesterday",
+			token:          testToken,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Audit log is returned",
+			method:         http.MethodGet,
+			path:           "/v1/audit/app-1",
+			token:          testToken,
+			expectedStatus: http.StatusOK,
+			expectedCalls:  []string{"ReadAuditLog app-1 admin"},
+			expectedBody:   `[{"id":1,"actor":"admin","operation":"REMOVE_APPLICATION","entityID":"app-1","table":"applications","before":{"status":"IN_SERVICE"},"after":{"status":"AWAITING_GRACE_PERIOD"},"createdAt":"2022-01-01T00:00:00Z"}]`,
+		},
+		{
+			name:           "Audit log of the actor in the header",
+			method:         http.MethodGet,
+			path:           "/v1/audit/app-1",
+			token:          testToken,
+			actor:          "ops@pokt.network",
+			expectedStatus: http.StatusOK,
+			expectedCalls:  []string{"ReadAuditLog app-1 ops@pokt.network"},
+		},
+		{
+			name:           "Unknown path is not found",
+			method:         http.MethodGet,
+			path:           "/v1/users",
+			token:          testToken,
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			driver := &fakeDriver{err: tc.driverErr}
+			server := NewAdminServer(driver, testToken, logger.New())
+
+			req := httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body))
+			if tc.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tc.token)
+			}
+			if tc.actor != "" {
+				req.Header.Set("X-Actor", tc.actor)
+			}
+			resp := httptest.NewRecorder()
+			server.ServeHTTP(resp, req)
+
+			if resp.Code != tc.expectedStatus {
+				t.Fatalf("Expected status: %d, got: %d, body: %s", tc.expectedStatus, resp.Code, resp.Body.String())
+			}
+			if diff := cmp.Diff(tc.expectedCalls, driver.calls); diff != "" {
+				t.Errorf("unexpected value (-want +got):\n%s", diff)
+			}
+			if tc.expectedBody != "" && strings.TrimSpace(resp.Body.String()) != tc.expectedBody {
+				t.Errorf("Expected body: %s, got: %s", tc.expectedBody, resp.Body.String())
+			}
+			if strings.Contains(resp.Body.String(), "private-key") {
+				t.Errorf("Secret leaked in response: %s", resp.Body.String())
+			}
+		})
+	}
+}
+
+type fakeDriver struct {
+	calls []string
+	err   error
+}
+
+func (f *fakeDriver) call(name string, args ...string) {
+	f.calls = append(f.calls, strings.Join(append([]string{name}, args...), " "))
+}
+
+func (f *fakeDriver) WriteApplicationContext(ctx context.Context, app *repository.Application) (*repository.Application, error) {
+	f.call("WriteApplication")
+	app.ID = "app-1"
+	return app, f.err
+}
+
+func (f *fakeDriver) UpdateApplicationContext(ctx context.Context, id string, fieldsToUpdate *repository.UpdateApplication) error {
+	f.call("UpdateApplication", id)
+	return f.err
+}
+
+func (f *fakeDriver) RemoveApplicationContext(ctx context.Context, id string) error {
+	f.call("RemoveApplication", id)
+	return f.err
+}
+
+func (f *fakeDriver) WriteLoadBalancerContext(ctx context.Context, loadBalancer *repository.LoadBalancer) (*repository.LoadBalancer, error) {
+	f.call("WriteLoadBalancer")
+	return loadBalancer, f.err
+}
+
+func (f *fakeDriver) UpdateLoadBalancerContext(ctx context.Context, id string, fieldsToUpdate *repository.UpdateLoadBalancer) error {
+	f.call("UpdateLoadBalancer", id)
+	return f.err
+}
+
+func (f *fakeDriver) RemoveLoadBalancerContext(ctx context.Context, id string) error {
+	f.call("RemoveLoadBalancer", id)
+	return f.err
+}
+
+func (f *fakeDriver) AddLbAppsContext(ctx context.Context, id string, appIDs []string) error {
+	f.call("AddLbApps", append([]string{id}, appIDs...)...)
+	return f.err
+}
+
+func (f *fakeDriver) RemoveLbAppsContext(ctx context.Context, id string, appIDs []string) error {
+	f.call("RemoveLbApps", append([]string{id}, appIDs...)...)
+	return f.err
+}
+
+func (f *fakeDriver) WriteBlockchainContext(ctx context.Context, blockchain *repository.Blockchain) (*repository.Blockchain, error) {
+	f.call("WriteBlockchain")
+	return blockchain, f.err
+}
+
+func (f *fakeDriver) UpdateBlockchainContext(ctx context.Context, id string, fieldsToUpdate *repository.UpdateBlockchain) error {
+	f.call("UpdateBlockchain", id)
+	return f.err
+}
+
+func (f *fakeDriver) RemoveRedirectContext(ctx context.Context, blockchainID, domain string) error {
+	f.call("RemoveRedirect", blockchainID, domain)
+	return f.err
+}
+
+func (f *fakeDriver) ActivateBlockchainContext(ctx context.Context, id string, active bool) error {
+	if active {
+		f.call("ActivateBlockchain", id, "true")
+	} else {
+		f.call("ActivateBlockchain", id, "false")
+	}
+	return f.err
+}
+
+func (f *fakeDriver) WriteRedirectContext(ctx context.Context, redirect *repository.Redirect) (*repository.Redirect, error) {
+	f.call("WriteRedirect")
+	return redirect, f.err
+}
+
+func (f *fakeDriver) ReadPayPlansContext(ctx context.Context) ([]*repository.PayPlan, error) {
+	f.call("ReadPayPlans")
+	return []*repository.PayPlan{{Type: repository.FreetierV0, Limit: 250000}}, f.err
+}
+
+func (f *fakeDriver) ReadAuditLogContext(ctx context.Context, entityID string) ([]*postgresdriver.AuditEntry, error) {
+	actor := postgresdriver.ActorFromContext(ctx)
+	f.call("ReadAuditLog", entityID, actor)
+	return []*postgresdriver.AuditEntry{{
+		ID:        1,
+		Actor:     actor,
+		Operation: "REMOVE_APPLICATION",
+		EntityID:  entityID,
+		Table:     "applications",
+		Before:    json.RawMessage(`{"status":"IN_SERVICE"}`),
+		After:     json.RawMessage(`{"status":"AWAITING_GRACE_PERIOD"}`),
+		CreatedAt: time.Date(2022, time.January, 1, 0, 0, 0, 0, time.UTC),
+	}}, f.err
+}
+
+func (f *fakeDriver) ReadApplicationContext(ctx context.Context, id string) (*repository.Application, error) {
+	f.call("ReadApplication", id)
+	return &repository.Application{ID: id}, f.err
+}
+
+func (f *fakeDriver) ReadApplicationsPageContext(ctx context.Context, filter postgresdriver.ApplicationFilter, page postgresdriver.Page) ([]*repository.Application, string, error) {
+	f.call("ReadApplicationsPage", filter.UserID, string(filter.Status), string(filter.PayPlan), filter.LoadBalancerID,
+		filter.UpdatedSince.Format(time.RFC3339), page.Cursor, strconv.Itoa(page.Limit))
+	return []*repository.Application{{
+		ID:         "app-1",
+		UserID:     filter.UserID,
+		Status:     filter.Status,
+		GatewayAAT: repository.GatewayAAT{PrivateKey: "private-key"},
+	}}, "app-1", f.err
+}
+
+func (f *fakeDriver) ReadLoadBalancerContext(ctx context.Context, id string) (*repository.LoadBalancer, error) {
+	f.call("ReadLoadBalancer", id)
+	return &repository.LoadBalancer{ID: id}, f.err
+}
+
+func (f *fakeDriver) ReadLoadBalancersPageContext(ctx context.Context, filter postgresdriver.LoadBalancerFilter, page postgresdriver.Page) ([]*repository.LoadBalancer, string, error) {
+	f.call("ReadLoadBalancersPage", filter.UserID, filter.ApplicationID)
+	return []*repository.LoadBalancer{}, "", f.err
+}
+
+func (f *fakeDriver) ReadBlockchainsPageContext(ctx context.Context, filter postgresdriver.BlockchainFilter, page postgresdriver.Page) ([]*repository.Blockchain, string, error) {
+	f.call("ReadBlockchainsPage", filter.UpdatedSince.Format(time.RFC3339))
+	return []*repository.Blockchain{}, "", f.err
+}
@@ -0,0 +1,138 @@
+package admin
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	postgresdriver "github.com/pokt-foundation/portal-api-go/postgres-driver"
+	"github.com/pokt-foundation/portal-api-go/repository"
+)
+
+// PageResponse is the body of list responses: the entities of the page and the cursor
+// to request the next one with, empty on the last page
+type PageResponse struct {
+	Data       any    `json:"data"`
+	NextCursor string `json:"nextCursor,omitempty"`
+}
+
+func (s *adminServer) listApplications(w http.ResponseWriter, req *http.Request) {
+	query := req.URL.Query()
+
+	page, updatedSince, err := parsePage(query)
+	if err != nil {
+		s.writeError(w, req, http.StatusBadRequest, err)
+		return
+	}
+
+	filter := postgresdriver.ApplicationFilter{
+		UserID:         query.Get("userID"),
+		Status:         repository.AppStatus(query.Get("status")),
+		PayPlan:        repository.PayPlanType(query.Get("payPlan")),
+		LoadBalancerID: query.Get("loadBalancerID"),
+		UpdatedSince:   updatedSince,
+	}
+	if !repository.ValidAppStatuses[filter.Status] {
+		s.writeError(w, req, http.StatusBadRequest, repository.ErrInvalidAppStatus)
+		return
+	}
+	if err := s.validatePayPlan(req.Context(), filter.PayPlan); err != nil {
+		s.writeDriverError(w, req, err)
+		return
+	}
+
+	apps, cursor, err := s.driver.ReadApplicationsPageContext(req.Context(), filter, page)
+	if err != nil {
+		s.writeDriverError(w, req, err)
+		return
+	}
+
+	s.writeJSON(w, http.StatusOK, PageResponse{Data: apps, NextCursor: cursor})
+}
+
+func (s *adminServer) listLoadBalancers(w http.ResponseWriter, req *http.Request) {
+	query := req.URL.Query()
+
+	page, updatedSince, err := parsePage(query)
+	if err != nil {
+		s.writeError(w, req, http.StatusBadRequest, err)
+		return
+	}
+
+	filter := postgresdriver.LoadBalancerFilter{
+		UserID:        query.Get("userID"),
+		ApplicationID: query.Get("applicationID"),
+		UpdatedSince:  updatedSince,
+	}
+
+	lbs, cursor, err := s.driver.ReadLoadBalancersPageContext(req.Context(), filter, page)
+	if err != nil {
+		s.writeDriverError(w, req, err)
+		return
+	}
+
+	s.writeJSON(w, http.StatusOK, PageResponse{Data: lbs, NextCursor: cursor})
+}
+
+func (s *adminServer) listBlockchains(w http.ResponseWriter, req *http.Request) {
+	page, updatedSince, err := parsePage(req.URL.Query())
+	if err != nil {
+		s.writeError(w, req, http.StatusBadRequest, err)
+		return
+	}
+
+	blockchains, cursor, err := s.driver.ReadBlockchainsPageContext(req.Context(), postgresdriver.BlockchainFilter{UpdatedSince: updatedSince}, page)
+	if err != nil {
+		s.writeDriverError(w, req, err)
+		return
+	}
+
+	s.writeJSON(w, http.StatusOK, PageResponse{Data: blockchains, NextCursor: cursor})
+}
+
+// validatePayPlan checks the plan type against the pay plans catalogue of the database
+func (s *adminServer) validatePayPlan(ctx context.Context, planType repository.PayPlanType) error {
+	if planType == "" {
+		return nil
+	}
+
+	plans, err := s.driver.ReadPayPlansContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	catalogue := make(repository.PayPlans, len(plans))
+	for _, plan := range plans {
+		catalogue[plan.Type] = *plan
+	}
+
+	return catalogue.Validate(planType)
+}
+
+// parsePage returns the page and the updatedSince filter, as RFC 3339, of a list request
+func parsePage(query url.Values) (postgresdriver.Page, time.Time, error) {
+	page := postgresdriver.Page{Cursor: query.Get("cursor")}
+
+	if rawLimit := query.Get("limit"); rawLimit != "" {
+		limit, err := strconv.Atoi(rawLimit)
+		if err != nil || limit < 1 {
+			return postgresdriver.Page{}, time.Time{}, fmt.Errorf("%w: limit %q", ErrInvalidQuery, rawLimit)
+		}
+		page.Limit = limit
+	}
+
+	var updatedSince time.Time
+
+	if rawSince := query.Get("updatedSince"); rawSince != "" {
+		since, err := time.Parse(time.RFC3339, rawSince)
+		if err != nil {
+			return postgresdriver.Page{}, time.Time{}, fmt.Errorf("%w: updatedSince %q", ErrInvalidQuery, rawSince)
+		}
+		updatedSince = since
+	}
+
+	return page, updatedSince, nil
+}
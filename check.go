@@ -0,0 +1,167 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/pokt-foundation/pocket-go/provider"
+	"github.com/pokt-foundation/pocket-go/relayer"
+	"github.com/pokt-foundation/pocket-go/signer"
+	logger "github.com/sirupsen/logrus"
+
+	"github.com/pokt-foundation/portal-api-go/qos"
+	"github.com/pokt-foundation/portal-api-go/repository"
+	"github.com/pokt-foundation/portal-api-go/session"
+)
+
+const checkAppCommand = "check-app"
+
+// checkApp runs the chain check against the nodes of the sessions of an application, and prints the nodes supporting
+// each blockchain:
+//
+//	check-app [flags] <application ID> [blockchain ID or alias...]
+//
+// All the blockchains allowed by the application's pay plan are checked if none is specified.
+func checkApp(args []string, out io.Writer, log *logger.Logger) error {
+	settings, positional, err := gatherCommandSettings(args, settings.validate)
+	if err != nil {
+		return err
+	}
+	log.SetLevel(settings.LogLevel)
+	if len(positional) < 1 {
+		return fmt.Errorf("missing application ID")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), settings.QoS.CheckTimeout)
+	defer cancel()
+
+	repo, driver, err := openRepository(ctx, settings, log)
+	if err != nil {
+		return err
+	}
+	if driver != nil {
+		defer driver.CloseListener()
+	}
+
+	app, err := repo.GetApplication(positional[0])
+	if err != nil {
+		return err
+	}
+
+	chains, err := appChains(repo, app, positional[1:])
+	if err != nil {
+		return err
+	}
+
+	checker, err := newChainChecker(settings, log)
+	if err != nil {
+		return err
+	}
+
+	// Chains are checked one at a time, for the sessions of the results to be matched to their blockchain
+	var checks []chainCheck
+	for _, chain := range chains {
+		results, err := checker.NodesSupportingApp(ctx, &app, []*repository.Blockchain{chain})
+		if err != nil {
+			return err
+		}
+		if len(results) == 0 {
+			// The errors of sessions that could not be retrieved, or checked, are logged by the checker
+			checks = append(checks, chainCheck{Blockchain: chain.ID})
+		}
+		for key, nodes := range results {
+			checks = append(checks, chainCheck{Blockchain: chain.ID, SessionKey: key, Nodes: nodes})
+		}
+	}
+
+	return printChainChecks(out, checks)
+}
+
+// chainCheck holds the nodes of a session of the application that support the blockchain.
+// Blockchains whose session could not be checked have no session key.
+type chainCheck struct {
+	Blockchain string
+	SessionKey string
+	Nodes      []*provider.Node
+}
+
+// appChains returns the blockchains of the IDs or aliases, or all the blockchains allowed by the application's pay plan
+func appChains(repo repository.ReloadableRepository, app repository.Application, aliases []string) ([]*repository.Blockchain, error) {
+	var chains []*repository.Blockchain
+	for _, alias := range aliases {
+		b, err := repo.GetBlockchain(alias)
+		if err != nil {
+			return nil, err
+		}
+		chains = append(chains, &b)
+	}
+	if len(aliases) > 0 {
+		return chains, nil
+	}
+
+	plan, err := repo.GetPayPlan(app.Limit.PayPlan.Type)
+	for _, b := range repo.Blockchains() {
+		if err == nil && !plan.AllowsChain(b.ID) {
+			continue
+		}
+		b := b
+		chains = append(chains, &b)
+	}
+	return chains, nil
+}
+
+// newChainChecker returns a chain checker sending relays signed with the gateway's private key,
+// to the nodes of the sessions dispatched by the RPC URLs
+func newChainChecker(settings settings, log *logger.Logger) (qos.ChainChecker, error) {
+	reqSigner, err := signer.NewSignerFromPrivateKey(settings.PrivateKey)
+	if err != nil {
+		return nil, fmt.Errorf("error creating wallet: %w", err)
+	}
+
+	rpcProvider := provider.NewProvider(settings.RPCURLs[0], settings.RPCURLs)
+	rpcProvider.UpdateRequestConfig(settings.Session.Retries, settings.Session.Timeout)
+
+	sessionManager := session.NewSessionManager(settings.RPCURLs, settings.Session)
+	sessions := func(ctx context.Context, app *repository.Application, chainID string) (*provider.Session, error) {
+		return sessionManager.GetSession(session.Key{PublicKey: app.GatewayAAT.ApplicationPublicKey, BlockchainID: chainID})
+	}
+
+	return qos.NewChainChecker(relayer.NewRelayer(reqSigner, rpcProvider), sessions, log)
+}
+
+// printChainChecks prints a table of the nodes supporting each blockchain, sorted by blockchain, session and node.
+// Sessions without supporting nodes, and blockchains whose session could not be checked, are printed without nodes.
+func printChainChecks(out io.Writer, checks []chainCheck) error {
+	sort.Slice(checks, func(i, j int) bool {
+		if checks[i].Blockchain != checks[j].Blockchain {
+			return checks[i].Blockchain < checks[j].Blockchain
+		}
+		return checks[i].SessionKey < checks[j].SessionKey
+	})
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "BLOCKCHAIN\tSESSION\tSUPPORTING NODE")
+	for _, check := range checks {
+		sessionKey := check.SessionKey
+		if sessionKey == "" {
+			sessionKey = "-"
+		}
+		if len(check.Nodes) == 0 {
+			fmt.Fprintf(w, "%s\t%s\t-\n", check.Blockchain, sessionKey)
+			continue
+		}
+
+		nodes := make([]string, 0, len(check.Nodes))
+		for _, node := range check.Nodes {
+			nodes = append(nodes, node.PublicKey)
+		}
+		sort.Strings(nodes)
+		for _, node := range nodes {
+			fmt.Fprintf(w, "%s\t%s\t%s\n", check.Blockchain, sessionKey, node)
+		}
+	}
+	return w.Flush()
+}
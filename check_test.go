@@ -0,0 +1,34 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/pokt-foundation/pocket-go/provider"
+)
+
+func TestPrintChainChecks(t *testing.T) {
+	checks := []chainCheck{
+		{Blockchain: "0040"},
+		{Blockchain: "0021", SessionKey: "session-2"},
+		{Blockchain: "0021", SessionKey: "session-1", Nodes: []*provider.Node{{PublicKey: "node-2"}, {PublicKey: "node-1"}}},
+	}
+
+	var out bytes.Buffer
+	if err := printChainChecks(&out, checks); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := strings.Join([]string{
+		"BLOCKCHAIN  SESSION    SUPPORTING NODE",
+		"0021        session-1  node-1",
+		"0021        session-1  node-2",
+		"0021        session-2  -",
+		"0040        -          -",
+		"",
+	}, "\n")
+	if out.String() != expected {
+		t.Errorf("Expected output:\n%s\ngot:\n%s", expected, out.String())
+	}
+}
@@ -0,0 +1,518 @@
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/pokt-foundation/pocket-go/signer"
+	logger "github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
+
+	postgresdriver "github.com/pokt-foundation/portal-api-go/postgres-driver"
+	"github.com/pokt-foundation/portal-api-go/relay"
+	"github.com/pokt-foundation/portal-api-go/repository"
+	"github.com/pokt-foundation/portal-api-go/session"
+	"github.com/pokt-foundation/portal-api-go/sticky"
+	"github.com/pokt-foundation/portal-api-go/web"
+)
+
+const (
+	// envPrefix prefixes the environment variables overriding the settings, e.g. PORTAL_POSTGRES_DSN for -postgresDSN
+	envPrefix = "PORTAL_"
+
+	repositoryBackendFiles    = "files"
+	repositoryBackendPostgres = "postgres"
+
+	stickyDuration   = 30 * time.Second
+	stickyRelayLimit = 100
+	stickyMaxErrors  = 3
+	qosCheckTimeout  = 30 * time.Second
+	metricsPath      = "/metrics"
+)
+
+// settings are gathered from defaults, then an optional YAML or JSON file, then environment variables and
+// finally flags: each source overrides the previous ones. JSON files are parsed as YAML, of which JSON is a subset.
+type settings struct {
+	RPCURLs        []string     `yaml:"rpcUrls"`
+	PrivateKey     string       `yaml:"privateKey"`
+	Port           int          `yaml:"port"`
+	LogLevel       logger.Level `yaml:"logLevel"`
+	TrustedProxies []string     `yaml:"trustedProxies"`
+	// ForwardingHeader is the header the trusted proxies report client IPs in, e.g. X-Forwarded-For for AWS ALBs
+	ForwardingHeader string `yaml:"forwardingHeader"`
+
+	Server          web.ServerSettings `yaml:"server"`
+	ShutdownTimeout time.Duration      `yaml:"shutdownTimeout"`
+	// DrainDelay is how long the server keeps serving once reported not ready on shutdown, e.g. a couple of
+	// readiness probe periods, for load balancers to stop routing requests to it
+	DrainDelay time.Duration `yaml:"drainDelay"`
+
+	Admin      adminSettings         `yaml:"admin"`
+	Repository repositorySettings    `yaml:"repository"`
+	Postgres   postgresSettings      `yaml:"postgres"`
+	Sticky     stickySettings        `yaml:"sticky"`
+	Session    session.Settings      `yaml:"session"`
+	QoS        qosSettings           `yaml:"qos"`
+	RateLimit  web.RateLimitSettings `yaml:"rateLimit"`
+	Metrics    metricsSettings       `yaml:"metrics"`
+	Relay      relaySettings         `yaml:"relay"`
+}
+
+type adminSettings struct {
+	Port int `yaml:"port"`
+	// Token is the bearer token required by the admin API: the admin API is disabled if not set
+	Token string `yaml:"token"`
+}
+
+type repositorySettings struct {
+	// Backend is either files, the json files in Path, or postgres
+	Backend        string        `yaml:"backend"`
+	Path           string        `yaml:"path"`
+	ReloadInterval time.Duration `yaml:"reloadInterval"`
+}
+
+type postgresSettings struct {
+	DSN string `yaml:"dsn"`
+	// SecretKey is the hex encoded 32 bytes key encrypting the application secrets stored in postgres
+	SecretKey        string        `yaml:"secretKey"`
+	StatementTimeout time.Duration `yaml:"statementTimeout"`
+	MaxOpenConns     int           `yaml:"maxOpenConns"`
+	MaxIdleConns     int           `yaml:"maxIdleConns"`
+	ConnMaxLifetime  time.Duration `yaml:"connMaxLifetime"`
+}
+
+type stickySettings struct {
+	// Duration is the TTL of the relay and error counts of sticky clients, and of the default sticky options
+	Duration   time.Duration `yaml:"duration"`
+	RelayLimit int           `yaml:"relayLimit"`
+	MaxErrors  int           `yaml:"maxErrors"`
+	// Stickiness, Origins and Max are the sticky options of load balancers without any
+	Stickiness bool     `yaml:"stickiness"`
+	Origins    []string `yaml:"origins"`
+	Max        int      `yaml:"max"`
+}
+
+func (s stickySettings) nodeSettings() sticky.StickyNodeSettings {
+	return sticky.StickyNodeSettings{
+		Duration:   s.Duration,
+		RelayLimit: s.RelayLimit,
+		MaxErrors:  s.MaxErrors,
+		DefaultStickinessOptions: repository.StickyOptions{
+			Duration:      strconv.Itoa(int(s.Duration.Seconds())),
+			StickyOrigins: s.Origins,
+			StickyMax:     s.Max,
+			Stickiness:    s.Stickiness,
+		},
+	}
+}
+
+type qosSettings struct {
+	// CheckTimeout bounds the checks of the nodes supporting the chains of an application
+	CheckTimeout time.Duration `yaml:"checkTimeout"`
+}
+
+type metricsSettings struct {
+	// Port serves the metrics: 0 disables them
+	Port int    `yaml:"port"`
+	Path string `yaml:"path"`
+}
+
+type relaySettings struct {
+	// AatPlan is used for applications whose pay plan has no AAT plan set nor an entry in AatPlans
+	AatPlan repository.AatPlan `yaml:"aatPlan"`
+	// AatPlans replaces the relayer's default AAT plans of pay plans if set
+	AatPlans map[repository.PayPlanType]repository.AatPlan `yaml:"aatPlans"`
+}
+
+// relayerSettings returns the relayer settings, with the relayer's defaults for settings not set
+func (s settings) relayerSettings() relay.RelayerSettings {
+	r := relay.DefaultSettings()
+	r.AatPlan = s.Relay.AatPlan
+	if s.Relay.AatPlans != nil {
+		r.AatPlans = s.Relay.AatPlans
+	}
+	r.Sticky = s.Sticky.nodeSettings()
+	return r
+}
+
+func defaultSettings() settings {
+	return settings{
+		Port:             webServerPort,
+		LogLevel:         logger.InfoLevel,
+		ForwardingHeader: web.HeaderXForwardedFor,
+		Server:           web.DefaultServerSettings(),
+		ShutdownTimeout:  shutdownTimeout,
+		DrainDelay:       drainDelay,
+		Admin: adminSettings{
+			Port: adminServerPort,
+		},
+		Repository: repositorySettings{
+			Backend:        repositoryBackendFiles,
+			ReloadInterval: repositoryReloadInterval,
+		},
+		Postgres: postgresSettings{
+			StatementTimeout: postgresdriver.DefaultStatementTimeout,
+			MaxIdleConns:     postgresMaxIdleConns,
+		},
+		Sticky: stickySettings{
+			Duration:   stickyDuration,
+			RelayLimit: stickyRelayLimit,
+			MaxErrors:  stickyMaxErrors,
+		},
+		Session: session.DefaultSettings(),
+		QoS: qosSettings{
+			CheckTimeout: qosCheckTimeout,
+		},
+		Metrics: metricsSettings{
+			Path: metricsPath,
+		},
+		Relay: relaySettings{
+			AatPlan: relay.DefaultSettings().AatPlan,
+		},
+	}
+}
+
+// newFlagSet returns the flags of the settings, bound to s, and the flag of the configuration file path
+func newFlagSet(s *settings) (*flag.FlagSet, *string) {
+	fs := flag.NewFlagSet("PortalAPI", flag.ContinueOnError)
+
+	configFile := fs.String("config", "", "Path of a YAML or JSON configuration file, overridden by environment variables and flags")
+	fs.Var((*stringList)(&s.RPCURLs), "rpcUrls", "Comma-separated list of RPC URLs")
+	fs.StringVar(&s.PrivateKey, "privateKey", s.PrivateKey, "Private key used for signing relays")
+	fs.IntVar(&s.Port, "port", s.Port, "Port to listen on")
+	fs.Var((*logLevel)(&s.LogLevel), "logLevel", "Logging level: accepted values are warn, info, and debug")
+	fs.Var((*stringList)(&s.TrustedProxies), "trustedProxies", "Comma-separated list of CIDRs or IPs of the proxies trusted to set the client IP forwarding header")
+	fs.StringVar(&s.ForwardingHeader, "forwardingHeader", s.ForwardingHeader, "Header the trusted proxies report client IPs in: accepted values are X-Forwarded-For, Forwarded and X-Real-IP")
+
+	fs.DurationVar(&s.Server.ReadTimeout, "readTimeout", s.Server.ReadTimeout, "Maximum duration for reading requests, headers included")
+	fs.DurationVar(&s.Server.WriteTimeout, "writeTimeout", s.Server.WriteTimeout, "Maximum duration for writing responses, i.e. for serving relays")
+	fs.DurationVar(&s.Server.IdleTimeout, "idleTimeout", s.Server.IdleTimeout, "Maximum duration to wait for the next request on keep-alive connections")
+	fs.Int64Var(&s.Server.MaxBodySize, "maxBodySize", s.Server.MaxBodySize, "Maximum size in bytes of relay request bodies: 0 means unlimited")
+	fs.DurationVar(&s.Server.SubscriptionPollInterval, "subscriptionPollInterval", s.Server.SubscriptionPollInterval, "Interval between polls of the nodes of WebSocket connections for the notifications of their subscriptions")
+	fs.DurationVar(&s.ShutdownTimeout, "shutdownTimeout", s.ShutdownTimeout, "Maximum duration to wait for in-flight relays to complete on shutdown")
+	fs.DurationVar(&s.DrainDelay, "drainDelay", s.DrainDelay, "Duration to keep serving relays on shutdown once reported not ready, for load balancers to stop routing to the gateway")
+
+	fs.IntVar(&s.Admin.Port, "adminPort", s.Admin.Port, "Port the admin API listens on")
+	fs.StringVar(&s.Admin.Token, "adminToken", s.Admin.Token, "Bearer token required by the admin API: the admin API is disabled if not set")
+
+	fs.StringVar(&s.Repository.Backend, "repositoryBackend", s.Repository.Backend, "Backend of the repository: accepted values are files and postgres")
+	fs.StringVar(&s.Repository.Path, "repositoryPath", s.Repository.Path, "Directory of the json files of the files repository backend")
+	fs.DurationVar(&s.Repository.ReloadInterval, "repositoryReloadInterval", s.Repository.ReloadInterval, "Interval between checks of the repository files for changes")
+
+	fs.StringVar(&s.Postgres.DSN, "postgresDSN", s.Postgres.DSN, "Connection string of the postgres database")
+	fs.StringVar(&s.Postgres.SecretKey, "secretKey", s.Postgres.SecretKey, "Hex encoded 32 bytes key used to encrypt application secrets stored in postgres")
+	fs.DurationVar(&s.Postgres.StatementTimeout, "postgresStatementTimeout", s.Postgres.StatementTimeout, "Maximum duration of every postgres call: 0 disables the timeout")
+	fs.IntVar(&s.Postgres.MaxOpenConns, "postgresMaxOpenConns", s.Postgres.MaxOpenConns, "Maximum number of open postgres connections: 0 means unlimited")
+	fs.IntVar(&s.Postgres.MaxIdleConns, "postgresMaxIdleConns", s.Postgres.MaxIdleConns, "Maximum number of idle postgres connections")
+	fs.DurationVar(&s.Postgres.ConnMaxLifetime, "postgresConnMaxLifetime", s.Postgres.ConnMaxLifetime, "Maximum amount of time a postgres connection may be reused: 0 means forever")
+
+	fs.DurationVar(&s.Sticky.Duration, "stickyDuration", s.Sticky.Duration, "Duration of the stickiness of relays to nodes")
+	fs.IntVar(&s.Sticky.RelayLimit, "stickyRelayLimit", s.Sticky.RelayLimit, "Relays to a sticky node after which stickiness is dropped")
+	fs.IntVar(&s.Sticky.MaxErrors, "stickyMaxErrors", s.Sticky.MaxErrors, "Errors of a sticky node after which stickiness is dropped")
+	fs.BoolVar(&s.Sticky.Stickiness, "stickiness", s.Sticky.Stickiness, "Whether relays of load balancers without sticky options stick to nodes")
+	fs.Var((*stringList)(&s.Sticky.Origins), "stickyOrigins", "Comma-separated list of origins whose relays stick to nodes, for load balancers without sticky options")
+	fs.IntVar(&s.Sticky.Max, "stickyMax", s.Sticky.Max, "Maximum number of sticky clients of load balancers without sticky options")
+
+	fs.DurationVar(&s.Session.TTL, "sessionTTL", s.Session.TTL, "Duration sessions are cached for")
+	fs.DurationVar(&s.Session.Timeout, "sessionTimeout", s.Session.Timeout, "Maximum duration of session dispatch requests")
+	fs.IntVar(&s.Session.Retries, "sessionRetries", s.Session.Retries, "Retries of failed session dispatch requests")
+
+	fs.DurationVar(&s.QoS.CheckTimeout, "qosCheckTimeout", s.QoS.CheckTimeout, "Maximum duration of the checks of the nodes supporting an application's chains")
+
+	fs.Float64Var(&s.RateLimit.PerIP.PerSecond, "rateLimitPerIP", s.RateLimit.PerIP.PerSecond, "Relay requests per second allowed from each client IP: 0 means unlimited")
+	fs.Float64Var(&s.RateLimit.PerApplication.PerSecond, "rateLimitPerApplication", s.RateLimit.PerApplication.PerSecond, "Relay requests per second allowed for each application: 0 means unlimited")
+	fs.Float64Var(&s.RateLimit.PerLoadBalancer.PerSecond, "rateLimitPerLoadBalancer", s.RateLimit.PerLoadBalancer.PerSecond, "Relay requests per second allowed for each load balancer: 0 means unlimited")
+
+	fs.IntVar(&s.Metrics.Port, "metricsPort", s.Metrics.Port, "Port the metrics are served on: 0 disables them")
+	fs.StringVar(&s.Metrics.Path, "metricsPath", s.Metrics.Path, "Path the metrics are served on")
+
+	fs.Var((*aatPlan)(&s.Relay.AatPlan), "aatPlan", "AAT plan of applications whose pay plan sets none: accepted values are Premium and Freemium")
+
+	return fs, configFile
+}
+
+// gatherSettings returns the settings of commands taking no positional arguments, e.g. serve,
+// validated by the validate function of the command, e.g. settings.validate
+func gatherSettings(args []string, validate func(settings) validationErrors) (settings, error) {
+	s, positional, err := gatherCommandSettings(args, validate)
+	if err != nil {
+		return settings{}, err
+	}
+	if len(positional) > 0 {
+		return settings{}, fmt.Errorf("unexpected arguments: %v", positional)
+	}
+	return s, nil
+}
+
+// gatherCommandSettings returns the settings of the flags, environment variables and configuration file,
+// validated by the validate function of the command, and the positional arguments following the flags
+func gatherCommandSettings(args []string, validate func(settings) validationErrors) (settings, []string, error) {
+	s := defaultSettings()
+	fs, configFile := newFlagSet(&s)
+
+	if err := fs.Parse(args); err != nil {
+		fmt.Println(err)
+		return settings{}, nil, err
+	}
+
+	explicit := make(map[string]string)
+	fs.Visit(func(f *flag.Flag) {
+		explicit[f.Name] = f.Value.String()
+	})
+
+	path := *configFile
+	if _, ok := explicit["config"]; !ok {
+		path = os.Getenv(envName("config"))
+	}
+	if path != "" {
+		if err := loadSettingsFile(path, &s); err != nil {
+			return settings{}, nil, err
+		}
+	}
+
+	var errs validationErrors
+	fs.VisitAll(func(f *flag.Flag) {
+		value, ok := os.LookupEnv(envName(f.Name))
+		if !ok || f.Name == "config" {
+			return
+		}
+		if err := fs.Set(f.Name, value); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", envName(f.Name), err))
+		}
+	})
+
+	// Flags win over the file and the environment
+	for name, value := range explicit {
+		if err := fs.Set(name, value); err != nil {
+			errs = append(errs, fmt.Errorf("-%s: %w", name, err))
+		}
+	}
+
+	errs = append(errs, validate(s)...)
+	if len(errs) > 0 {
+		return settings{}, nil, errs
+	}
+	return s, fs.Args(), nil
+}
+
+// loadSettingsFile overrides the settings set in the YAML or JSON file, unknown settings are rejected
+func loadSettingsFile(path string, s *settings) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("error reading configuration file: %w", err)
+	}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(s); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("invalid configuration file %s: %w", path, err)
+	}
+	return nil
+}
+
+// envName returns the environment variable overriding the flag, e.g. PORTAL_RATE_LIMIT_PER_IP for rateLimitPerIP
+func envName(flagName string) string {
+	var b strings.Builder
+	b.WriteString(envPrefix)
+
+	runes := []rune(flagName)
+	for i, r := range runes {
+		wordStart := unicode.IsUpper(r) && i > 0 &&
+			(unicode.IsLower(runes[i-1]) || i+1 < len(runes) && unicode.IsLower(runes[i+1]))
+		if wordStart {
+			b.WriteByte('_')
+		}
+		b.WriteRune(unicode.ToUpper(r))
+	}
+	return b.String()
+}
+
+// validationErrors reports all the invalid settings at once
+type validationErrors []error
+
+func (e validationErrors) Error() string {
+	messages := make([]string, 0, len(e))
+	for _, err := range e {
+		messages = append(messages, err.Error())
+	}
+	return "invalid settings: " + strings.Join(messages, "; ")
+}
+
+// validate returns the invalid settings of the commands relaying or checking nodes, e.g. serve, which require them all
+func (s settings) validate() validationErrors {
+	errs := s.validateConfig()
+	if s.PrivateKey == "" {
+		errs = append(errs, errors.New("privateKey: required to sign relays"))
+	}
+	return errs
+}
+
+// validateDispatch returns the invalid settings of the dispatch command, which only dispatches sessions
+func (s settings) validateDispatch() validationErrors {
+	var errs validationErrors
+	check := func(valid bool, format string, args ...any) {
+		if !valid {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	check(len(s.RPCURLs) > 0, "rpcUrls: at least one RPC URL is required")
+	for _, rpcURL := range s.RPCURLs {
+		u, err := url.Parse(rpcURL)
+		check(err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "", "rpcUrls: invalid URL %q", rpcURL)
+	}
+
+	check(s.Session.TTL > 0 && s.Session.Timeout > 0, "session: ttl and timeout must be positive")
+	check(s.Session.Retries >= 0, "session.retries: can not be negative")
+
+	return errs
+}
+
+// validateConfig returns the invalid settings of the validate-config command: the private key, which may not be
+// available where configurations are validated, is only validated if set
+func (s settings) validateConfig() validationErrors {
+	errs := s.validateDispatch()
+	check := func(valid bool, format string, args ...any) {
+		if !valid {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	if s.PrivateKey != "" {
+		if _, err := signer.NewSignerFromPrivateKey(s.PrivateKey); err != nil {
+			errs = append(errs, fmt.Errorf("privateKey: %w", err))
+		}
+	}
+	check(validPort(s.Port), "port: invalid port %d", s.Port)
+	if _, err := web.NewClientIPResolver(s.TrustedProxies, s.ForwardingHeader); errors.Is(err, web.ErrInvalidForwardingHeader) {
+		errs = append(errs, fmt.Errorf("forwardingHeader: %w", err))
+	} else if err != nil {
+		errs = append(errs, fmt.Errorf("trustedProxies: %w", err))
+	}
+
+	check(s.Server.ReadTimeout >= 0 && s.Server.WriteTimeout >= 0 && s.Server.IdleTimeout >= 0, "server: timeouts can not be negative")
+	check(s.Server.MaxBodySize >= 0, "server.maxBodySize: can not be negative")
+	check(s.Server.SubscriptionPollInterval > 0, "server.subscriptionPollInterval: must be positive")
+	check(s.ShutdownTimeout >= 0, "shutdownTimeout: can not be negative")
+	check(s.DrainDelay >= 0, "drainDelay: can not be negative")
+
+	if s.Admin.Token != "" {
+		check(validPort(s.Admin.Port), "admin.port: invalid port %d", s.Admin.Port)
+		check(s.Postgres.DSN != "", "admin.token: the admin API requires postgres.dsn")
+	}
+
+	switch s.Repository.Backend {
+	case repositoryBackendFiles:
+		check(s.Repository.Path != "", "repository.path: required by the %s backend", repositoryBackendFiles)
+	case repositoryBackendPostgres:
+		check(s.Postgres.DSN != "", "postgres.dsn: required by the %s repository backend", repositoryBackendPostgres)
+	default:
+		errs = append(errs, fmt.Errorf("repository.backend: invalid backend %q, accepted values are %s and %s",
+			s.Repository.Backend, repositoryBackendFiles, repositoryBackendPostgres))
+	}
+	check(s.Repository.ReloadInterval > 0, "repository.reloadInterval: must be positive")
+
+	if s.Postgres.SecretKey != "" {
+		if _, err := secretCipher(s.Postgres.SecretKey); err != nil {
+			errs = append(errs, fmt.Errorf("postgres.secretKey: %w", err))
+		}
+	}
+	check(s.Postgres.StatementTimeout >= 0 && s.Postgres.ConnMaxLifetime >= 0, "postgres: durations can not be negative")
+	check(s.Postgres.MaxOpenConns >= 0 && s.Postgres.MaxIdleConns >= 0, "postgres: connection limits can not be negative")
+
+	check(s.Sticky.Duration >= time.Second, "sticky.duration: must be at least 1s")
+	check(s.Sticky.RelayLimit > 0, "sticky.relayLimit: must be positive")
+	check(s.Sticky.MaxErrors >= 0 && s.Sticky.Max >= 0, "sticky: limits can not be negative")
+
+	check(s.QoS.CheckTimeout > 0, "qos.checkTimeout: must be positive")
+
+	rates := map[string]web.Rate{
+		"perIP":           s.RateLimit.PerIP,
+		"perApplication":  s.RateLimit.PerApplication,
+		"perLoadBalancer": s.RateLimit.PerLoadBalancer,
+	}
+	for name, rate := range rates {
+		check(rate.PerSecond >= 0 && rate.Burst >= 0, "rateLimit.%s: can not be negative", name)
+	}
+
+	if s.Metrics.Port != 0 {
+		check(validPort(s.Metrics.Port), "metrics.port: invalid port %d", s.Metrics.Port)
+		check(strings.HasPrefix(s.Metrics.Path, "/"), "metrics.path: must start with /")
+	}
+
+	check(validAatPlan(s.Relay.AatPlan), "relay.aatPlan: invalid AAT plan %q", s.Relay.AatPlan)
+	for planType, plan := range s.Relay.AatPlans {
+		check(validAatPlan(plan), "relay.aatPlans.%s: invalid AAT plan %q", planType, plan)
+	}
+
+	return errs
+}
+
+func validPort(port int) bool {
+	return port > 0 && port <= 65535
+}
+
+func validAatPlan(plan repository.AatPlan) bool {
+	return plan == repository.AatPlanPremium || plan == repository.AatPlanFreemium
+}
+
+func secretCipher(secretKey string) (postgresdriver.SecretCipher, error) {
+	key, err := hex.DecodeString(secretKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid secret key: %w", err)
+	}
+	return postgresdriver.NewEnvelopeCipher(key)
+}
+
+// stringList is a flag of comma-separated values: empty values are dropped, so an empty flag sets no values
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(value string) error {
+	*l = nil
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			*l = append(*l, v)
+		}
+	}
+	return nil
+}
+
+type logLevel logger.Level
+
+func (l *logLevel) String() string {
+	return logger.Level(*l).String()
+}
+
+func (l *logLevel) Set(value string) error {
+	level, err := logger.ParseLevel(value)
+	if err != nil {
+		return err
+	}
+	*l = logLevel(level)
+	return nil
+}
+
+type aatPlan repository.AatPlan
+
+func (p *aatPlan) String() string {
+	return string(*p)
+}
+
+func (p *aatPlan) Set(value string) error {
+	*p = aatPlan(value)
+	return nil
+}
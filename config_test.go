@@ -0,0 +1,358 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/pokt-foundation/pocket-go/signer"
+	logger "github.com/sirupsen/logrus"
+
+	"github.com/pokt-foundation/portal-api-go/repository"
+	"github.com/pokt-foundation/portal-api-go/session"
+	"github.com/pokt-foundation/portal-api-go/web"
+)
+
+func TestGatherSettings(t *testing.T) {
+	appSigner, err := signer.NewRandomSigner()
+	if err != nil {
+		t.Fatalf("Error creating signer: %v", err)
+	}
+	privateKey := appSigner.GetPrivateKey()
+
+	withDefaults := func(update func(*settings)) settings {
+		s := defaultSettings()
+		s.RPCURLs = []string{"https://url1"}
+		s.PrivateKey = privateKey
+		s.Repository.Path = "/repository"
+		update(&s)
+		return s
+	}
+	required := []string{"-rpcUrls", "https://url1", "-privateKey", privateKey, "-repositoryPath", "/repository"}
+
+	testCases := []struct {
+		name        string
+		args        []string
+		file        string
+		env         map[string]string
+		expected    settings
+		expectedErr string
+	}{
+		{
+			name:     "RPC URLs string",
+			args:     append(required, "-rpcUrls", "https://url1,https://url2"),
+			expected: withDefaults(func(s *settings) { s.RPCURLs = []string{"https://url1", "https://url2"} }),
+		},
+		{
+			name: "Set all values",
+			args: append(required,
+				"-port", "8191",
+				"-logLevel", "Debug",
+				"-adminPort", "8192",
+				"-adminToken", "adminToken",
+				"-postgresDSN", "postgres://localhost/portal",
+				"-secretKey", strings.Repeat("ab", 32),
+				"-postgresStatementTimeout", "5s",
+				"-postgresMaxOpenConns", "20",
+				"-postgresMaxIdleConns", "10",
+				"-postgresConnMaxLifetime", "1h",
+				"-rateLimitPerIP", "50",
+				"-rateLimitPerApplication", "20",
+				"-rateLimitPerLoadBalancer", "0.5",
+				"-trustedProxies", "10.0.0.0/8,192.168.1.1",
+				"-forwardingHeader", "Forwarded",
+				"-readTimeout", "5s",
+				"-writeTimeout", "20s",
+				"-idleTimeout", "1m",
+				"-maxBodySize", "1024",
+				"-subscriptionPollInterval", "5s",
+				"-shutdownTimeout", "10s",
+				"-drainDelay", "15s",
+				"-repositoryBackend", "postgres",
+				"-repositoryReloadInterval", "1m",
+				"-stickyDuration", "1m",
+				"-stickyRelayLimit", "10",
+				"-stickyMaxErrors", "1",
+				"-stickiness",
+				"-stickyOrigins", "https://app.portal,https://dapp",
+				"-stickyMax", "300",
+				"-sessionTTL", "2m",
+				"-sessionTimeout", "3s",
+				"-sessionRetries", "2",
+				"-qosCheckTimeout", "1m",
+				"-metricsPort", "9090",
+				"-metricsPath", "/stats",
+				"-aatPlan", "Premium",
+			),
+			expected: settings{
+				RPCURLs:          []string{"https://url1"},
+				PrivateKey:       privateKey,
+				Port:             8191,
+				LogLevel:         logger.DebugLevel,
+				TrustedProxies:   []string{"10.0.0.0/8", "192.168.1.1"},
+				ForwardingHeader: "Forwarded",
+
+				Server: web.ServerSettings{
+					ReadTimeout:  5 * time.Second,
+					WriteTimeout: 20 * time.Second,
+					IdleTimeout:  time.Minute,
+					MaxBodySize:  1024,
+
+					SubscriptionPollInterval: 5 * time.Second,
+				},
+				ShutdownTimeout: 10 * time.Second,
+				DrainDelay:      15 * time.Second,
+
+				Admin: adminSettings{Port: 8192, Token: "adminToken"},
+				Repository: repositorySettings{
+					Backend:        repositoryBackendPostgres,
+					Path:           "/repository",
+					ReloadInterval: time.Minute,
+				},
+				Postgres: postgresSettings{
+					DSN:              "postgres://localhost/portal",
+					SecretKey:        strings.Repeat("ab", 32),
+					StatementTimeout: 5 * time.Second,
+					MaxOpenConns:     20,
+					MaxIdleConns:     10,
+					ConnMaxLifetime:  time.Hour,
+				},
+				Sticky: stickySettings{
+					Duration:   time.Minute,
+					RelayLimit: 10,
+					MaxErrors:  1,
+					Stickiness: true,
+					Origins:    []string{"https://app.portal", "https://dapp"},
+					Max:        300,
+				},
+				Session: session.Settings{TTL: 2 * time.Minute, Timeout: 3 * time.Second, Retries: 2},
+				QoS:     qosSettings{CheckTimeout: time.Minute},
+				RateLimit: web.RateLimitSettings{
+					PerIP:           web.Rate{PerSecond: 50},
+					PerApplication:  web.Rate{PerSecond: 20},
+					PerLoadBalancer: web.Rate{PerSecond: 0.5},
+				},
+				Metrics: metricsSettings{Port: 9090, Path: "/stats"},
+				Relay:   relaySettings{AatPlan: repository.AatPlanPremium},
+			},
+		},
+		{
+			name: "File is overridden by environment variables and flags",
+			args: append(required, "-port", "8193"),
+			file: `
+port: 8191
+logLevel: debug
+rpcUrls: ["https://file1", "https://file2"]
+session:
+  ttl: 5m
+rateLimit:
+  perIP: {perSecond: 10, burst: 20}
+relay:
+  aatPlans:
+    ENTERPRISE: Premium
+`,
+			env: map[string]string{
+				"PORTAL_PORT":              "8192",
+				"PORTAL_SESSION_TTL":       "10m",
+				"PORTAL_RATE_LIMIT_PER_IP": "15",
+			},
+			expected: withDefaults(func(s *settings) {
+				s.Port = 8193
+				s.LogLevel = logger.DebugLevel
+				s.Session.TTL = 10 * time.Minute
+				s.RateLimit.PerIP = web.Rate{PerSecond: 15, Burst: 20}
+				s.Relay.AatPlans = map[repository.PayPlanType]repository.AatPlan{repository.Enterprise: repository.AatPlanPremium}
+			}),
+		},
+		{
+			name: "JSON file",
+			args: required,
+			file: `{"port": 8191, "sticky": {"duration": "1m", "origins": ["https://dapp"]}}`,
+			expected: withDefaults(func(s *settings) {
+				s.Port = 8191
+				s.Sticky.Duration = time.Minute
+				s.Sticky.Origins = []string{"https://dapp"}
+			}),
+		},
+		{
+			name:        "Unknown setting in file returns error",
+			args:        required,
+			file:        `prot: 8191`,
+			expectedErr: "field prot not found",
+		},
+		{
+			name:        "Empty RPC URLs returns error",
+			args:        []string{"-rpcUrls", "", "-privateKey", privateKey, "-repositoryPath", "/repository"},
+			expectedErr: "rpcUrls: at least one RPC URL is required",
+		},
+		{
+			name: "All invalid settings are reported",
+			args: []string{"-rpcUrls", "url1", "-repositoryBackend", "mongo", "-sessionTTL", "0s", "-aatPlan", "Gold", "-forwardingHeader", "X-Client-IP", "-subscriptionPollInterval", "0s", "-drainDelay", "-1s"},
+			env:  map[string]string{"PORTAL_STICKY_RELAY_LIMIT": "many"},
+			expectedErr: strings.Join([]string{
+				`PORTAL_STICKY_RELAY_LIMIT: parse error`,
+				`rpcUrls: invalid URL "url1"`,
+				`privateKey:`,
+				`forwardingHeader: invalid forwarding header: "X-Client-IP"`,
+				`server.subscriptionPollInterval: must be positive`,
+				`drainDelay: can not be negative`,
+				`repository.backend: invalid backend "mongo"`,
+				`session: ttl and timeout must be positive`,
+				`relay.aatPlan: invalid AAT plan "Gold"`,
+			}, "|"),
+		},
+		{
+			name:        "Admin API without postgres returns error",
+			args:        append(required, "-adminToken", "adminToken"),
+			expectedErr: "admin.token: the admin API requires postgres.dsn",
+		},
+		{
+			name:        "Invalid port number returns error",
+			args:        []string{"-port", "foo"},
+			expectedErr: "invalid value",
+		},
+		{
+			name:        "invalid arg returns error",
+			args:        []string{"-invalid", "arg"},
+			expectedErr: "flag provided but not defined",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.file != "" {
+				file := path.Join(t.TempDir(), "config.yaml")
+				if err := os.WriteFile(file, []byte(tc.file), 0600); err != nil {
+					t.Fatalf("Error writing configuration file: %v", err)
+				}
+				t.Setenv("PORTAL_CONFIG", file)
+			}
+			for key, value := range tc.env {
+				t.Setenv(key, value)
+			}
+
+			actual, err := gatherSettings(tc.args, settings.validate)
+			if tc.expectedErr != "" {
+				if err == nil {
+					t.Fatalf("Expected error: %s, got nil", tc.expectedErr)
+				}
+				for _, expected := range strings.Split(tc.expectedErr, "|") {
+					if !strings.Contains(err.Error(), expected) {
+						t.Errorf("Expected error containing: %q, got: %v", expected, err)
+					}
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tc.expected, actual); diff != "" {
+				t.Errorf("unexpected value (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestGatherCommandSettings(t *testing.T) {
+	appSigner, err := signer.NewRandomSigner()
+	if err != nil {
+		t.Fatalf("Error creating signer: %v", err)
+	}
+	args := []string{"-rpcUrls", "https://url1", "-privateKey", appSigner.GetPrivateKey(), "-repositoryPath", "/repository", "app-1", "0021"}
+
+	_, positional, err := gatherCommandSettings(args, settings.validate)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if diff := cmp.Diff([]string{"app-1", "0021"}, positional); diff != "" {
+		t.Errorf("unexpected arguments (-want +got):\n%s", diff)
+	}
+
+	if _, err := gatherSettings(args, settings.validate); err == nil || !strings.Contains(err.Error(), "unexpected arguments") {
+		t.Errorf("Expected unexpected arguments error, got: %v", err)
+	}
+}
+
+func TestCommandValidation(t *testing.T) {
+	testCases := []struct {
+		name        string
+		args        []string
+		validate    func(settings) validationErrors
+		expectedErr string
+	}{
+		{
+			name:     "dispatch only requires RPC URLs",
+			args:     []string{"-rpcUrls", "https://url1", "-repositoryBackend", "mongo"},
+			validate: settings.validateDispatch,
+		},
+		{
+			name:        "dispatch validates RPC URLs and sessions",
+			args:        []string{"-rpcUrls", "url1", "-sessionTTL", "0s"},
+			validate:    settings.validateDispatch,
+			expectedErr: `rpcUrls: invalid URL "url1"|session: ttl and timeout must be positive`,
+		},
+		{
+			name:     "validate-config does not require a private key",
+			args:     []string{"-rpcUrls", "https://url1", "-repositoryPath", "/repository"},
+			validate: settings.validateConfig,
+		},
+		{
+			name:        "validate-config validates the private key if set",
+			args:        []string{"-rpcUrls", "https://url1", "-repositoryPath", "/repository", "-privateKey", strings.Repeat("zz", 64)},
+			validate:    settings.validateConfig,
+			expectedErr: "privateKey:",
+		},
+		{
+			name:        "serve requires a private key",
+			args:        []string{"-rpcUrls", "https://url1", "-repositoryPath", "/repository"},
+			validate:    settings.validate,
+			expectedErr: "privateKey: required",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := gatherSettings(tc.args, tc.validate)
+			if tc.expectedErr == "" {
+				if err != nil {
+					t.Errorf("Unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("Expected error: %s, got nil", tc.expectedErr)
+			}
+			for _, expected := range strings.Split(tc.expectedErr, "|") {
+				if !strings.Contains(err.Error(), expected) {
+					t.Errorf("Expected error containing: %q, got: %v", expected, err)
+				}
+			}
+		})
+	}
+}
+
+func TestGatherSettingsMissingFile(t *testing.T) {
+	_, err := gatherSettings([]string{"-config", path.Join(t.TempDir(), "missing.yaml")}, settings.validate)
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Expected error: %v, got: %v", os.ErrNotExist, err)
+	}
+}
+
+func TestEnvName(t *testing.T) {
+	for flagName, expected := range map[string]string{
+		"rpcUrls":         "PORTAL_RPC_URLS",
+		"postgresDSN":     "PORTAL_POSTGRES_DSN",
+		"rateLimitPerIP":  "PORTAL_RATE_LIMIT_PER_IP",
+		"sessionTTL":      "PORTAL_SESSION_TTL",
+		"qosCheckTimeout": "PORTAL_QOS_CHECK_TIMEOUT",
+		"port":            "PORTAL_PORT",
+	} {
+		if name := envName(flagName); name != expected {
+			t.Errorf("Expected environment variable of %s: %s, got: %s", flagName, expected, name)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/pokt-foundation/pocket-go/provider"
+	logger "github.com/sirupsen/logrus"
+
+	"github.com/pokt-foundation/portal-api-go/session"
+)
+
+const dispatchCommand = "dispatch"
+
+// dispatch prints the session dispatched for an application's public key and a blockchain ID:
+//
+//	dispatch [flags] <application public key> <blockchain ID>
+func dispatch(args []string, out io.Writer, log *logger.Logger) error {
+	settings, positional, err := gatherCommandSettings(args, settings.validateDispatch)
+	if err != nil {
+		return err
+	}
+	log.SetLevel(settings.LogLevel)
+	if len(positional) != 2 {
+		return fmt.Errorf("expected an application public key and a blockchain ID, got: %v", positional)
+	}
+
+	sessionManager := session.NewSessionManager(settings.RPCURLs, settings.Session)
+	s, err := sessionManager.GetSession(session.Key{PublicKey: positional[0], BlockchainID: positional[1]})
+	if err != nil {
+		return fmt.Errorf("error dispatching session: %w", err)
+	}
+
+	return printSession(out, s)
+}
+
+// printSession prints the key and header of the session, followed by a table of its nodes
+func printSession(out io.Writer, s *provider.Session) error {
+	fmt.Fprintf(out, "Session: %s\n", s.Key)
+	if s.Header != nil {
+		fmt.Fprintf(out, "Application: %s\nBlockchain: %s\nHeight: %d\n", s.Header.AppPublicKey, s.Header.Chain, s.Header.SessionHeight)
+	}
+	fmt.Fprintln(out)
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NODE\tADDRESS\tSERVICE URL\tJAILED")
+	for _, node := range s.Nodes {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%t\n", node.PublicKey, node.Address, node.ServiceURL, node.Jailed)
+	}
+	return w.Flush()
+}
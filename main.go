@@ -1,106 +1,273 @@
 package main
 
 import (
-	"flag"
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"os"
+	"os/signal"
 	"strings"
+	"syscall"
+	"time"
 
+	"github.com/lib/pq"
 	logger "github.com/sirupsen/logrus"
 
+	"github.com/pokt-foundation/portal-api-go/admin"
+	postgresdriver "github.com/pokt-foundation/portal-api-go/postgres-driver"
 	"github.com/pokt-foundation/portal-api-go/relay"
 	"github.com/pokt-foundation/portal-api-go/repository"
 	"github.com/pokt-foundation/portal-api-go/session"
-	"github.com/pokt-foundation/portal-api-go/sticky"
 	"github.com/pokt-foundation/portal-api-go/web"
 )
 
 const (
-	webServerPort = 8090
-	loggingLevel  = "info"
+	webServerPort            = 8090
+	adminServerPort          = 8091
+	repositoryReloadInterval = 30 * time.Second
+	shutdownTimeout          = 30 * time.Second
+	drainDelay               = 10 * time.Second
+
+	listenerMinReconnectInterval = 10 * time.Second
+	listenerMaxReconnectInterval = time.Minute
+	postgresMaxIdleConns         = 2
 )
 
-type settings struct {
-	RPCURLs    []string
-	PrivateKey string
-	Port       int
-	LogLevel   logger.Level
+const serveCommand = "serve"
+
+// commands run the subcommand named by the first argument, serve is run if the first argument is a flag or missing
+var commands = map[string]func(args []string, log *logger.Logger) error{
+	serveCommand:          serve,
+	migrateCommand:        migrate,
+	checkAppCommand:       func(args []string, log *logger.Logger) error { return checkApp(args, os.Stdout, log) },
+	dispatchCommand:       func(args []string, log *logger.Logger) error { return dispatch(args, os.Stdout, log) },
+	validateConfigCommand: func(args []string, log *logger.Logger) error { return validateConfig(args, os.Stdout, log) },
 }
 
-func gatherSettings(args []string) (settings, error) {
-	var (
-		urls  string
-		level string
-		s     settings
-	)
-	fs := flag.NewFlagSet("PortalAPI", flag.ContinueOnError)
-	fs.StringVar(&urls, "rpcUrls", "", "Comma-separated list of RPC URLs")
-	fs.StringVar(&s.PrivateKey, "privateKey", "", "Private key used for signing relays")
-	fs.IntVar(&s.Port, "port", webServerPort, "Port to listen on")
-	fs.StringVar(&level, "logLevel", loggingLevel, "Logging level: accepted values are warn, info, and debug")
+func main() {
+	log := logger.New()
 
-	if err := fs.Parse(args); err != nil {
-		fmt.Println(err)
-		return settings{}, err
+	command, args := serveCommand, os.Args[1:]
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		command, args = args[0], args[1:]
 	}
 
-	logLevel, err := logger.ParseLevel(level)
-	if err != nil {
-		fmt.Printf("Invalid logging level: %q, set to info.", level)
-		s.LogLevel = logger.InfoLevel
-	} else {
-		s.LogLevel = logLevel
+	run, ok := commands[command]
+	if !ok {
+		log.WithFields(logger.Fields{"command": command}).Error("Unknown command: accepted commands are serve, check-app, dispatch, validate-config and migrate")
+		os.Exit(2)
 	}
 
-	rpcUrls := strings.Split(urls, ",")
-	if len(rpcUrls) < 1 {
-		return settings{}, fmt.Errorf("invalid list of rpc urls: %q", urls)
+	if err := run(args, log); err != nil {
+		log.WithFields(logger.Fields{"error": err, "command": command}).Error("Command failed")
+		os.Exit(1)
 	}
-	s.RPCURLs = rpcUrls
-	return s, nil
 }
 
-func main() {
-	log := logger.New()
-	settings, err := gatherSettings(os.Args)
+// serve serves relays until a SIGTERM or SIGINT is received, then drains the servers
+func serve(args []string, log *logger.Logger) error {
+	settings, err := gatherSettings(args, settings.validate)
 	if err != nil {
-		log.WithFields(logger.Fields{"error": err}).Warn("Error gathering settings")
-		os.Exit(1)
+		return fmt.Errorf("error gathering settings: %w", err)
 	}
 	log.SetLevel(settings.LogLevel)
 
-	repo, err := repository.NewRepository("/tmp", log)
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, syscall.SIGINT)
+	defer stop()
+
+	repo, driver, err := openRepository(ctx, settings, log)
 	if err != nil {
-		fmt.Printf("Error setting up repository: %v\n", err)
+		return err
 	}
 
-	sessionManager := session.NewSessionManager(settings.RPCURLs)
+	reload := make(chan os.Signal, 1)
+	signal.Notify(reload, syscall.SIGHUP)
+	go repo.Watch(ctx, settings.Repository.ReloadInterval, reload)
 
-	relayerSettings := relay.FreemiumSettings()
-	relayerSettings.DefaultStickyOptions = repository.StickyOptions{
-		Duration: "30",
+	if driver != nil {
+		repo.SetChangeSource(driver)
+		go repo.Listen(driver.NotificationChannel())
+		go func() {
+			for err := range driver.ErrorChannel() {
+				log.WithFields(logger.Fields{"error": err}).Warn("Error parsing postgres notification")
+			}
+		}()
 	}
-	relayerSettings.DefaultClientStickyOptions = sticky.StickyClient{}
+
+	sessionManager := session.NewSessionManager(settings.RPCURLs, settings.Session)
 
 	relayer, err := relay.NewRelayServer(
 		settings.RPCURLs,
 		settings.PrivateKey,
-		relayerSettings,
+		settings.relayerSettings(),
 		repo,
 		sessionManager,
 		log,
 	)
 	if err != nil {
-		log.WithFields(logger.Fields{"error": err}).Warn("Error creating relayer")
-		return
+		return fmt.Errorf("error creating relayer: %w", err)
+	}
+
+	ips, err := web.NewClientIPResolver(settings.TrustedProxies, settings.ForwardingHeader)
+	if err != nil {
+		return fmt.Errorf("error setting up trusted proxies: %w", err)
+	}
+
+	var adminServer, metricsServer *http.Server
+	if settings.Admin.Token != "" {
+		adminServer = web.NewServer(fmt.Sprintf(":%d", settings.Admin.Port), admin.NewAdminServer(driver, settings.Admin.Token, log), settings.Server)
+		go func() {
+			log.WithFields(logger.Fields{"port": settings.Admin.Port}).Info("Starting admin server")
+			if err := adminServer.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+				log.WithFields(logger.Fields{"error": err}).Error("Admin server stopped")
+			}
+		}()
+	}
+
+	if settings.Metrics.Port != 0 {
+		mux := http.NewServeMux()
+		mux.HandleFunc(settings.Metrics.Path, metricsHandler(repo, driver))
+		metricsServer = web.NewServer(fmt.Sprintf(":%d", settings.Metrics.Port), mux, settings.Server)
+		go func() {
+			log.WithFields(logger.Fields{"port": settings.Metrics.Port}).Info("Starting metrics server")
+			if err := metricsServer.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+				log.WithFields(logger.Fields{"error": err}).Error("Metrics server stopped")
+			}
+		}()
+	}
+
+	health := web.NewHealth(map[string]web.ReadinessCheck{
+		"repository": func(context.Context) error { return repo.Ready() },
+		"dispatchers": func(ctx context.Context) error {
+			return session.PingDispatchers(ctx, settings.RPCURLs)
+		},
+	})
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", health.Liveness)
+	mux.HandleFunc("/readyz", health.Readiness)
+	relays := web.WebSocket(web.GetHTTPServer(relayer, ips, log), relayer, repo, ips, settings.Server, log)
+	mux.Handle("/", web.MaxBodySize(web.RateLimit(relays, repo, settings.RateLimit, ips, log), settings.Server.MaxBodySize))
+	server := web.NewServer(fmt.Sprintf(":%d", settings.Port), mux, settings.Server)
+
+	serverErr := make(chan error, 1)
+	go func() {
+		log.WithFields(logger.Fields{"port": settings.Port}).Info("Starting http server")
+		serverErr <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-serverErr:
+		return fmt.Errorf("http server stopped: %w", err)
+	case <-ctx.Done():
 	}
 
-	log.Info("Starting http server")
-	http.HandleFunc("/", web.GetHTTPServer(relayer, log))
+	log.WithFields(logger.Fields{"drainDelay": settings.DrainDelay}).Info("Shutting down")
+	// Relays are still served while the load balancers' readiness probes notice the drain
+	health.Drain()
+	time.Sleep(settings.DrainDelay)
+	shutdown(settings.ShutdownTimeout, []*http.Server{server, adminServer, metricsServer}, driver, log)
+	log.Info("Shut down")
+	return nil
+}
+
+// shutdown stops accepting requests and waits up to the timeout for in-flight requests, e.g. relays,
+// to complete. The postgres listener is closed afterwards, which stops the repository from listening.
+func shutdown(timeout time.Duration, servers []*http.Server, driver *postgresdriver.PostgresDriver, log *logger.Logger) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	for _, server := range servers {
+		if server == nil {
+			continue
+		}
+		if err := server.Shutdown(ctx); err != nil {
+			log.WithFields(logger.Fields{"error": err, "address": server.Addr}).Warn("Error draining http server")
+		}
+	}
+
+	if driver != nil {
+		if err := driver.CloseListener(); err != nil {
+			log.WithFields(logger.Fields{"error": err}).Warn("Error closing postgres listener")
+		}
+	}
+}
+
+// openRepository returns the repository of the configured backend, and the postgres driver if a DSN is set
+func openRepository(ctx context.Context, settings settings, log *logger.Logger) (repository.ReloadableRepository, *postgresdriver.PostgresDriver, error) {
+	var driver *postgresdriver.PostgresDriver
+	if settings.Postgres.DSN != "" {
+		var err error
+		driver, err = newPostgresDriver(settings, log)
+		if err != nil {
+			return nil, nil, fmt.Errorf("error setting up postgres driver: %w", err)
+		}
+	}
 
-	err = http.ListenAndServe(fmt.Sprintf(":%d", settings.Port), nil)
+	repo, err := newRepository(ctx, settings, driver, log)
 	if err != nil {
-		panic(err)
+		if driver != nil {
+			driver.CloseListener()
+		}
+		return nil, nil, fmt.Errorf("error setting up repository: %w", err)
+	}
+	return repo, driver, nil
+}
+
+// newRepository returns the repository of the configured backend
+func newRepository(ctx context.Context, settings settings, driver *postgresdriver.PostgresDriver, log *logger.Logger) (repository.ReloadableRepository, error) {
+	if settings.Repository.Backend == repositoryBackendPostgres {
+		return repository.NewRepositoryFromSource(ctx, driver, log)
 	}
+	return repository.NewRepository(settings.Repository.Path, log)
+}
+
+type metrics struct {
+	RepositoryReady     bool                          `json:"repositoryReady"`
+	RepositoryWatermark time.Time                     `json:"repositoryWatermark"`
+	PostgresListener    *postgresdriver.ListenerStats `json:"postgresListener,omitempty"`
+}
+
+// metricsHandler serves the state of the repository and the counters of the postgres listener as JSON
+func metricsHandler(repo repository.ReloadableRepository, driver *postgresdriver.PostgresDriver) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		m := metrics{
+			RepositoryReady:     repo.Ready() == nil,
+			RepositoryWatermark: repo.Watermark(),
+		}
+		if driver != nil {
+			stats := driver.ListenerStats()
+			m.PostgresListener = &stats
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(m)
+	}
+}
+
+func newPostgresDriver(settings settings, log *logger.Logger) (*postgresdriver.PostgresDriver, error) {
+	options := []postgresdriver.Option{
+		postgresdriver.WithStatementTimeout(settings.Postgres.StatementTimeout),
+		postgresdriver.WithMaxOpenConns(settings.Postgres.MaxOpenConns),
+		postgresdriver.WithMaxIdleConns(settings.Postgres.MaxIdleConns),
+		postgresdriver.WithConnMaxLifetime(settings.Postgres.ConnMaxLifetime),
+	}
+	if settings.Postgres.SecretKey != "" {
+		secrets, err := secretCipher(settings.Postgres.SecretKey)
+		if err != nil {
+			return nil, err
+		}
+		options = append(options, postgresdriver.WithSecretCipher(secrets))
+	}
+
+	listener := pq.NewListener(settings.Postgres.DSN, listenerMinReconnectInterval, listenerMaxReconnectInterval,
+		func(event pq.ListenerEventType, err error) {
+			if err != nil {
+				log.WithFields(logger.Fields{"error": err}).Warn("Postgres listener error")
+			}
+		})
+
+	return postgresdriver.NewPostgresDriverFromConnectionString(settings.Postgres.DSN, listener, options...)
 }
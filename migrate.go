@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"strconv"
+
+	logger "github.com/sirupsen/logrus"
+
+	postgresdriver "github.com/pokt-foundation/portal-api-go/postgres-driver"
+)
+
+const migrateCommand = "migrate"
+
+type migrateSettings struct {
+	PostgresDSN string
+	Direction   string
+	Steps       int
+}
+
+// gatherMigrateSettings parses the arguments of: migrate -postgresDSN <dsn> up|down [steps]|version
+func gatherMigrateSettings(args []string) (migrateSettings, error) {
+	var s migrateSettings
+
+	fs := flag.NewFlagSet("PortalAPI migrate", flag.ContinueOnError)
+	fs.StringVar(&s.PostgresDSN, "postgresDSN", "", "Connection string of the postgres database to migrate")
+
+	if err := fs.Parse(args); err != nil {
+		return migrateSettings{}, err
+	}
+
+	if s.PostgresDSN == "" {
+		return migrateSettings{}, fmt.Errorf("missing postgresDSN")
+	}
+
+	if fs.NArg() < 1 {
+		return migrateSettings{}, fmt.Errorf("missing migration direction: accepted values are up, down and version")
+	}
+
+	s.Direction = fs.Arg(0)
+	switch s.Direction {
+	case "up", "version":
+		if fs.NArg() > 1 {
+			return migrateSettings{}, fmt.Errorf("unexpected arguments: %v", fs.Args()[1:])
+		}
+	case "down":
+		s.Steps = 1
+		if fs.NArg() > 2 {
+			return migrateSettings{}, fmt.Errorf("unexpected arguments: %v", fs.Args()[2:])
+		}
+		if fs.NArg() == 2 {
+			steps, err := strconv.Atoi(fs.Arg(1))
+			if err != nil || steps < 1 {
+				return migrateSettings{}, fmt.Errorf("invalid number of steps: %q", fs.Arg(1))
+			}
+			s.Steps = steps
+		}
+	default:
+		return migrateSettings{}, fmt.Errorf("invalid migration direction: %q", s.Direction)
+	}
+
+	return s, nil
+}
+
+func migrate(args []string, log *logger.Logger) error {
+	settings, err := gatherMigrateSettings(args)
+	if err != nil {
+		return err
+	}
+
+	db, err := sql.Open("postgres", settings.PostgresDSN)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	migrator, err := postgresdriver.NewMigrator(db)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+
+	switch settings.Direction {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		log.WithFields(logger.Fields{"applied": applied}).Info("Applied migrations")
+		if err != nil {
+			return err
+		}
+	case "down":
+		reverted, err := migrator.Down(ctx, settings.Steps)
+		log.WithFields(logger.Fields{"reverted": reverted}).Info("Reverted migrations")
+		if err != nil {
+			return err
+		}
+	}
+
+	version, err := migrator.Version(ctx)
+	if err != nil {
+		return err
+	}
+
+	log.WithFields(logger.Fields{"version": version}).Info("Database schema version")
+
+	return nil
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestGatherMigrateSettings(t *testing.T) {
+	testCases := []struct {
+		name        string
+		args        []string
+		expected    migrateSettings
+		expectedErr bool
+	}{
+		{
+			name:     "Up",
+			args:     []string{"-postgresDSN", "postgres://localhost/portal", "up"},
+			expected: migrateSettings{PostgresDSN: "postgres://localhost/portal", Direction: "up"},
+		},
+		{
+			name:     "Down defaults to one step",
+			args:     []string{"-postgresDSN", "postgres://localhost/portal", "down"},
+			expected: migrateSettings{PostgresDSN: "postgres://localhost/portal", Direction: "down", Steps: 1},
+		},
+		{
+			name:     "Down with steps",
+			args:     []string{"-postgresDSN", "postgres://localhost/portal", "down", "3"},
+			expected: migrateSettings{PostgresDSN: "postgres://localhost/portal", Direction: "down", Steps: 3},
+		},
+		{
+			name:     "Version",
+			args:     []string{"-postgresDSN", "postgres://localhost/portal", "version"},
+			expected: migrateSettings{PostgresDSN: "postgres://localhost/portal", Direction: "version"},
+		},
+		{
+			name:        "Missing DSN returns error",
+			args:        []string{"up"},
+			expectedErr: true,
+		},
+		{
+			name:        "Missing direction returns error",
+			args:        []string{"-postgresDSN", "postgres://localhost/portal"},
+			expectedErr: true,
+		},
+		{
+			name:        "Invalid direction returns error",
+			args:        []string{"-postgresDSN", "postgres://localhost/portal", "sideways"},
+			expectedErr: true,
+		},
+		{
+			name:        "Invalid steps returns error",
+			args:        []string{"-postgresDSN", "postgres://localhost/portal", "down", "0"},
+			expectedErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := gatherMigrateSettings(tc.args)
+			if tc.expectedErr {
+				if err == nil {
+					t.Fatalf("Expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tc.expected, actual); diff != "" {
+				t.Errorf("unexpected value (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
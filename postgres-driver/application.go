@@ -1,6 +1,7 @@
 package postgresdriver
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
 	"strings"
@@ -12,7 +13,8 @@ import (
 )
 
 const (
-	selectApplications = `
+	selectApplications       = selectApplicationsScript + groupApplicationsScript
+	selectApplicationsScript = `
 	WITH app_whitelists AS (
 		SELECT application_id
 		FROM whitelist_contracts
@@ -80,7 +82,8 @@ const (
 		LEFT JOIN app_limits AS al ON a.application_id = al.application_id
 		LEFT JOIN pay_plans AS pp ON al.pay_plan = pp.plan_type
 		LEFT JOIN whitelist_contracts wc ON a.application_id = wc.application_id
-		LEFT JOIN whitelist_methods wm ON a.application_id = wm.application_id
+		LEFT JOIN whitelist_methods wm ON a.application_id = wm.application_id`
+	groupApplicationsScript = `
 	GROUP BY a.application_id,
 		a.contact_email,
 		a.created_at,
@@ -114,21 +117,6 @@ const (
 		pp.daily_limit,
 		wc.application_id,
 		wm.application_id`
-	selectAppLimit = `
-	SELECT application_id, pay_plan, custom_limit
-	FROM app_limits WHERE application_id = $1`
-	selectGatewaySettings = `
-	SELECT application_id, secret_key, secret_key_required, whitelist_blockchains, whitelist_origins, whitelist_user_agents
-	FROM gateway_settings WHERE application_id = $1`
-	selectWhitelistContracts = `
-	SELECT application_id, blockchain_id, contracts
-	FROM whitelist_contracts WHERE application_id = $1 AND blockchain_id = $2`
-	selectWhitelistMethods = `
-	SELECT application_id, blockchain_id, methods
-	FROM whitelist_methods WHERE application_id = $1 AND blockchain_id = $2`
-	selectNotificationSettings = `
-	SELECT application_id, signed_up, on_quarter, on_half, on_three_quarters, on_full
-	FROM notification_settings WHERE application_id = $1`
 	insertApplicationScript = `
 	INSERT into applications (application_id, user_id, name, contact_email, description, owner, url, status, dummy, created_at, updated_at)
 	VALUES (:application_id, :user_id, :name, :contact_email, :description, :owner, :url, :status, :dummy, :created_at, :updated_at)`
@@ -148,10 +136,11 @@ const (
 	UPDATE applications
 	SET name = COALESCE($1, name), status = COALESCE($2, status), first_date_surpassed = COALESCE($3, first_date_surpassed), updated_at = $4
 	WHERE application_id = $5`
-	updateAppLimitScript = `
-	UPDATE app_limits
-	SET pay_plan = :pay_plan, custom_limit = :custom_limit
-	WHERE application_id = :application_id`
+	upsertAppLimitScript = `
+	INSERT into app_limits (application_id, pay_plan, custom_limit)
+	VALUES (:application_id, :pay_plan, :custom_limit)
+	ON CONFLICT (application_id)
+	DO UPDATE SET pay_plan = EXCLUDED.pay_plan, custom_limit = EXCLUDED.custom_limit`
 	updateFirstDateSurpassedScript = `
 	UPDATE applications
 	SET first_date_surpassed = :first_date_surpassed, updated_at = :updated_at
@@ -160,28 +149,32 @@ const (
 	UPDATE applications
 	SET status = COALESCE($1, status), updated_at = $2
 	WHERE application_id = $3`
-	updateGatewaySettings = `
-	UPDATE gateway_settings
-	SET secret_key = :secret_key, secret_key_required = :secret_key_required, whitelist_origins = :whitelist_origins, whitelist_user_agents = :whitelist_user_agents, whitelist_blockchains = :whitelist_blockchains
-	WHERE application_id = :application_id`
+	upsertGatewaySettingsScript = `
+	INSERT into gateway_settings (application_id, secret_key, secret_key_required, whitelist_origins, whitelist_user_agents, whitelist_blockchains)
+	VALUES (:application_id, :secret_key, :secret_key_required, :whitelist_origins, :whitelist_user_agents, :whitelist_blockchains)
+	ON CONFLICT (application_id)
+	DO UPDATE SET secret_key = EXCLUDED.secret_key, secret_key_required = EXCLUDED.secret_key_required, whitelist_origins = EXCLUDED.whitelist_origins, whitelist_user_agents = EXCLUDED.whitelist_user_agents, whitelist_blockchains = EXCLUDED.whitelist_blockchains`
 	insertWhitelistContractsScript = `
 	INSERT INTO whitelist_contracts (application_id, blockchain_id, contracts)
 	VALUES (:application_id, :blockchain_id, :contracts)`
 	insertWhitelistMethodsScript = `
 	INSERT INTO whitelist_methods (application_id, blockchain_id, methods)
 	VALUES (:application_id, :blockchain_id, :methods)`
-	updateWhitelistContractsScript = `
-	UPDATE whitelist_contracts
-	SET contracts = :contracts
-	WHERE application_id = :application_id AND blockchain_id = :blockchain_id`
-	updateWhitelistMethodsScript = `
-	UPDATE whitelist_methods
-	SET methods = :methods
-	WHERE application_id = :application_id AND blockchain_id = :blockchain_id`
-	updateNotificationSettings = `
-	UPDATE notification_settings
-	SET signed_up = :signed_up, on_quarter = :on_quarter, on_half = :on_half, on_three_quarters = :on_three_quarters, on_full = :on_full
-	WHERE application_id = :application_id`
+	upsertWhitelistContractsScript = `
+	INSERT INTO whitelist_contracts (application_id, blockchain_id, contracts)
+	VALUES (:application_id, :blockchain_id, :contracts)
+	ON CONFLICT (application_id, blockchain_id)
+	DO UPDATE SET contracts = EXCLUDED.contracts`
+	upsertWhitelistMethodsScript = `
+	INSERT INTO whitelist_methods (application_id, blockchain_id, methods)
+	VALUES (:application_id, :blockchain_id, :methods)
+	ON CONFLICT (application_id, blockchain_id)
+	DO UPDATE SET methods = EXCLUDED.methods`
+	upsertNotificationSettingsScript = `
+	INSERT into notification_settings (application_id, signed_up, on_quarter, on_half, on_three_quarters, on_full)
+	VALUES (:application_id, :signed_up, :on_quarter, :on_half, :on_three_quarters, :on_full)
+	ON CONFLICT (application_id)
+	DO UPDATE SET signed_up = EXCLUDED.signed_up, on_quarter = EXCLUDED.on_quarter, on_half = EXCLUDED.on_half, on_three_quarters = EXCLUDED.on_three_quarters, on_full = EXCLUDED.on_full`
 )
 
 type dbApplication struct {
@@ -222,7 +215,17 @@ type dbApplication struct {
 	UpdatedAt            sql.NullTime   `db:"updated_at"`
 }
 
-func (a *dbApplication) toApplication() *repository.Application {
+func (a *dbApplication) toApplication(secrets SecretCipher) (*repository.Application, error) {
+	privateKey, err := decryptSecret(secrets, a.GAPrivateKey.String)
+	if err != nil {
+		return nil, err
+	}
+
+	secretKey, err := decryptSecret(secrets, a.SecretKey.String)
+	if err != nil {
+		return nil, err
+	}
+
 	return &repository.Application{
 		ID:                 a.ApplicationID,
 		UserID:             a.UserID.String,
@@ -242,11 +245,11 @@ func (a *dbApplication) toApplication() *repository.Application {
 			ApplicationPublicKey: a.GAPublicKey.String,
 			ApplicationSignature: a.GASignature.String,
 			ClientPublicKey:      a.GAClientPublicKey.String,
-			PrivateKey:           a.GAPrivateKey.String,
+			PrivateKey:           privateKey,
 			Version:              a.GAVersion.String,
 		},
 		GatewaySettings: repository.GatewaySettings{
-			SecretKey:            a.SecretKey.String,
+			SecretKey:            secretKey,
 			SecretKeyRequired:    a.SecretKeyRequired.Bool,
 			WhitelistBlockchains: a.WhitelistBlockchains,
 			WhitelistContracts:   nullStringToWhitelistContracts(a.WhitelistContracts),
@@ -268,7 +271,7 @@ func (a *dbApplication) toApplication() *repository.Application {
 			ThreeQuarters: a.ThreeQuarters.Bool,
 			Full:          a.Full.Bool,
 		},
-	}
+	}, nil
 }
 
 type dbAppJSON struct {
@@ -363,17 +366,6 @@ func (i *insertAppLimit) isUpdatable() bool {
 	return i != nil
 }
 
-func (i *insertAppLimit) read(driver *PostgresDriver, ids []string) (updatable, error) {
-	var limit insertAppLimit
-
-	err := driver.Get(&limit, selectAppLimit, ids[0])
-	if err != nil {
-		return nil, err
-	}
-
-	return &limit, nil
-}
-
 func extractInsertDBAppLimit(app *repository.Application) *insertAppLimit {
 	return &insertAppLimit{
 		ApplicationID: app.ID,
@@ -404,16 +396,21 @@ type dbGatewayAATJSON struct {
 	Version         string `json:"version"`
 }
 
-func (j dbGatewayAATJSON) toOutput() *repository.GatewayAAT {
+func (j dbGatewayAATJSON) toOutput(secrets SecretCipher) (*repository.GatewayAAT, error) {
+	privateKey, err := decryptSecret(secrets, j.PrivateKey)
+	if err != nil {
+		return nil, err
+	}
+
 	return &repository.GatewayAAT{
 		ID:                   j.ApplicationID,
 		Address:              j.Address,
 		ClientPublicKey:      j.ClientPublicKey,
-		PrivateKey:           j.PrivateKey,
+		PrivateKey:           privateKey,
 		ApplicationPublicKey: j.PublicKey,
 		ApplicationSignature: j.Signature,
 		Version:              j.Version,
-	}
+	}, nil
 }
 
 type insertGatewayAAT struct {
@@ -430,16 +427,21 @@ func (i *insertGatewayAAT) isNotNull() bool {
 	return i.Address.Valid || i.PublicKey.Valid || i.Signature.Valid || i.ClientPublicKey.Valid || i.Version.Valid || i.PrivateKey.Valid
 }
 
-func extractInsertGatewayAAT(app *repository.Application) *insertGatewayAAT {
+func extractInsertGatewayAAT(app *repository.Application, secrets SecretCipher) (*insertGatewayAAT, error) {
+	privateKey, err := encryptSecret(secrets, app.GatewayAAT.PrivateKey)
+	if err != nil {
+		return nil, err
+	}
+
 	return &insertGatewayAAT{
 		ApplicationID:   app.ID,
 		Address:         newSQLNullString(app.GatewayAAT.Address),
 		ClientPublicKey: newSQLNullString(app.GatewayAAT.ClientPublicKey),
-		PrivateKey:      newSQLNullString(app.GatewayAAT.PrivateKey),
+		PrivateKey:      privateKey,
 		PublicKey:       newSQLNullString(app.GatewayAAT.ApplicationPublicKey),
 		Signature:       newSQLNullString(app.GatewayAAT.ApplicationSignature),
 		Version:         newSQLNullString(app.GatewayAAT.Version),
-	}
+	}, nil
 }
 
 type dbGatewaySettingsJSON struct {
@@ -453,17 +455,22 @@ type dbGatewaySettingsJSON struct {
 	WhitelistBlockchains []string                       `json:"whitelist_blockchains"`
 }
 
-func (j dbGatewaySettingsJSON) toOutput() *repository.GatewaySettings {
+func (j dbGatewaySettingsJSON) toOutput(secrets SecretCipher) (*repository.GatewaySettings, error) {
+	secretKey, err := decryptSecret(secrets, j.SecretKey)
+	if err != nil {
+		return nil, err
+	}
+
 	return &repository.GatewaySettings{
 		ID:                   j.ApplicationID,
-		SecretKey:            j.SecretKey,
+		SecretKey:            secretKey,
 		SecretKeyRequired:    j.SecretKeyRequired,
 		WhitelistContracts:   j.WhitelistContracts,
 		WhitelistMethods:     j.WhitelistMethods,
 		WhitelistOrigins:     j.WhitelistOrigins,
 		WhitelistUserAgents:  j.WhitelistUserAgents,
 		WhitelistBlockchains: j.WhitelistBlockchains,
-	}
+	}, nil
 }
 
 type dbWhitelistContractJSON struct {
@@ -511,30 +518,24 @@ func (i *insertGatewaySettings) isUpdatable() bool {
 	return i != nil
 }
 
-func (i *insertGatewaySettings) read(driver *PostgresDriver, ids []string) (updatable, error) {
-	var settings insertGatewaySettings
+func convertRepositoryToDBGatewaySettings(id string, settings *repository.GatewaySettings, secrets SecretCipher) (*insertGatewaySettings, error) {
+	if settings == nil {
+		return nil, nil
+	}
 
-	err := driver.Get(&settings, selectGatewaySettings, ids[0])
+	secretKey, err := encryptSecret(secrets, settings.SecretKey)
 	if err != nil {
 		return nil, err
 	}
 
-	return &settings, nil
-}
-
-func convertRepositoryToDBGatewaySettings(id string, settings *repository.GatewaySettings) *insertGatewaySettings {
-	if settings == nil {
-		return nil
-	}
-
 	return &insertGatewaySettings{
 		ApplicationID:        id,
-		SecretKey:            newSQLNullString(settings.SecretKey),
+		SecretKey:            secretKey,
 		SecretKeyRequired:    settings.SecretKeyRequired,
 		WhitelistOrigins:     settings.WhitelistOrigins,
 		WhitelistUserAgents:  settings.WhitelistUserAgents,
 		WhitelistBlockchains: settings.WhitelistBlockchains,
-	}
+	}, nil
 }
 
 type insertWhitelistContracts struct {
@@ -546,17 +547,6 @@ type insertWhitelistContracts struct {
 func (i *insertWhitelistContracts) isUpdatable() bool {
 	return i != nil
 }
-func (i *insertWhitelistContracts) read(driver *PostgresDriver, ids []string) (updatable, error) {
-	var contracts insertWhitelistContracts
-
-	err := driver.Get(&contracts, selectWhitelistContracts, ids[0], ids[1])
-	if err != nil {
-		return nil, err
-	}
-
-	return &contracts, nil
-}
-
 func convertRepositoryToDBWhitelistContracts(id string, updateContract *repository.WhitelistContract) *insertWhitelistContracts {
 	if len(updateContract.Contracts) == 0 {
 		return nil
@@ -578,17 +568,6 @@ type insertWhitelistMethods struct {
 func (i *insertWhitelistMethods) isUpdatable() bool {
 	return i != nil
 }
-func (i *insertWhitelistMethods) read(driver *PostgresDriver, ids []string) (updatable, error) {
-	var methods insertWhitelistMethods
-
-	err := driver.Get(&methods, selectWhitelistMethods, ids[0], ids[1])
-	if err != nil {
-		return nil, err
-	}
-
-	return &methods, nil
-}
-
 func convertRepositoryToDBWhitelistMethods(id string, updateContract *repository.WhitelistMethod) *insertWhitelistMethods {
 	if len(updateContract.Methods) == 0 {
 		return nil
@@ -601,15 +580,20 @@ func convertRepositoryToDBWhitelistMethods(id string, updateContract *repository
 	}
 }
 
-func extractInsertGatewaySettings(app *repository.Application) *insertGatewaySettings {
+func extractInsertGatewaySettings(app *repository.Application, secrets SecretCipher) (*insertGatewaySettings, error) {
+	secretKey, err := encryptSecret(secrets, app.GatewaySettings.SecretKey)
+	if err != nil {
+		return nil, err
+	}
+
 	return &insertGatewaySettings{
 		ApplicationID:        app.ID,
-		SecretKey:            newSQLNullString(app.GatewaySettings.SecretKey),
+		SecretKey:            secretKey,
 		SecretKeyRequired:    app.GatewaySettings.SecretKeyRequired,
 		WhitelistOrigins:     app.GatewaySettings.WhitelistOrigins,
 		WhitelistUserAgents:  app.GatewaySettings.WhitelistUserAgents,
 		WhitelistBlockchains: app.GatewaySettings.WhitelistBlockchains,
-	}
+	}, nil
 }
 
 func nullStringToWhitelistContracts(rawContracts sql.NullString) []repository.WhitelistContract {
@@ -685,17 +669,6 @@ func (i *insertNotificationSettings) isUpdatable() bool {
 	return i != nil
 }
 
-func (i *insertNotificationSettings) read(driver *PostgresDriver, ids []string) (updatable, error) {
-	var settings insertNotificationSettings
-
-	err := driver.Get(&settings, selectNotificationSettings, ids[0])
-	if err != nil {
-		return nil, err
-	}
-
-	return &settings, nil
-}
-
 func extractInsertNotificationSettings(app *repository.Application) *insertNotificationSettings {
 	return &insertNotificationSettings{
 		ApplicationID: app.ID,
@@ -724,9 +697,17 @@ func convertRepositoryToDBNotificationSettings(id string, settings *repository.N
 
 // ReadApplications returns all applications on the database
 func (d *PostgresDriver) ReadApplications() ([]*repository.Application, error) {
+	return d.ReadApplicationsContext(context.Background())
+}
+
+// ReadApplicationsContext is ReadApplications bounded by the context and the driver's statement timeout
+func (d *PostgresDriver) ReadApplicationsContext(ctx context.Context) ([]*repository.Application, error) {
+	ctx, cancel := d.withStatementTimeout(ctx)
+	defer cancel()
+
 	var dbApplications []*dbApplication
 
-	err := d.Select(&dbApplications, selectApplications)
+	err := d.SelectContext(ctx, &dbApplications, selectApplications)
 	if err != nil {
 		return nil, err
 	}
@@ -734,14 +715,125 @@ func (d *PostgresDriver) ReadApplications() ([]*repository.Application, error) {
 	var applications []*repository.Application
 
 	for _, dbApplication := range dbApplications {
-		applications = append(applications, dbApplication.toApplication())
+		application, err := dbApplication.toApplication(d.secrets)
+		if err != nil {
+			return nil, err
+		}
+
+		applications = append(applications, application)
 	}
 
 	return applications, nil
 }
 
+// ReadApplication returns the application with the given ID, ErrNotFound if it does not exist
+func (d *PostgresDriver) ReadApplication(id string) (*repository.Application, error) {
+	return d.ReadApplicationContext(context.Background(), id)
+}
+
+// ReadApplicationContext is ReadApplication bounded by the context and the driver's statement timeout
+func (d *PostgresDriver) ReadApplicationContext(ctx context.Context, id string) (*repository.Application, error) {
+	ctx, cancel := d.withStatementTimeout(ctx)
+	defer cancel()
+
+	if id == "" {
+		return nil, ErrMissingID
+	}
+
+	c := &conditions{}
+	c.add("a.application_id = ?", id)
+
+	var dbApplications []*dbApplication
+
+	err := d.SelectContext(ctx, &dbApplications, c.query(selectApplicationsScript, groupApplicationsScript), c.args...)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(dbApplications) == 0 {
+		return nil, ErrNotFound
+	}
+
+	return dbApplications[0].toApplication(d.secrets)
+}
+
+// ReadApplicationsPage returns a page of the applications matching the filter,
+// and the cursor of the next page, empty if it is the last one
+func (d *PostgresDriver) ReadApplicationsPage(filter ApplicationFilter, page Page) ([]*repository.Application, string, error) {
+	return d.ReadApplicationsPageContext(context.Background(), filter, page)
+}
+
+// ReadApplicationsPageContext is ReadApplicationsPage bounded by the context and the driver's statement timeout
+func (d *PostgresDriver) ReadApplicationsPageContext(ctx context.Context, filter ApplicationFilter, page Page) ([]*repository.Application, string, error) {
+	ctx, cancel := d.withStatementTimeout(ctx)
+	defer cancel()
+
+	c := filter.conditions()
+	query := c.pageQuery(selectApplicationsScript, groupApplicationsScript, "a.application_id", page)
+
+	var dbApplications []*dbApplication
+
+	err := d.SelectContext(ctx, &dbApplications, query, c.args...)
+	if err != nil {
+		return nil, "", err
+	}
+
+	count, cursor := nextCursor(len(dbApplications), page, func(i int) string {
+		return dbApplications[i].ApplicationID
+	})
+
+	applications := []*repository.Application{}
+
+	for _, dbApplication := range dbApplications[:count] {
+		application, err := dbApplication.toApplication(d.secrets)
+		if err != nil {
+			return nil, "", err
+		}
+
+		applications = append(applications, application)
+	}
+
+	return applications, cursor, nil
+}
+
+// ReadApplicationsUpdatedSince returns all applications updated at or after since
+func (d *PostgresDriver) ReadApplicationsUpdatedSince(since time.Time) ([]*repository.Application, error) {
+	return d.ReadApplicationsUpdatedSinceContext(context.Background(), since)
+}
+
+// ReadApplicationsUpdatedSinceContext is ReadApplicationsUpdatedSince bounded by the context,
+// each page read is bounded by the driver's statement timeout
+func (d *PostgresDriver) ReadApplicationsUpdatedSinceContext(ctx context.Context, since time.Time) ([]*repository.Application, error) {
+	filter := ApplicationFilter{UpdatedSince: since}
+	page := Page{Limit: MaxPageSize}
+
+	applications := []*repository.Application{}
+
+	for {
+		read, cursor, err := d.ReadApplicationsPageContext(ctx, filter, page)
+		if err != nil {
+			return nil, err
+		}
+
+		applications = append(applications, read...)
+
+		if cursor == "" {
+			return applications, nil
+		}
+		page.Cursor = cursor
+	}
+}
+
 // WriteApplication saves input application in the database
 func (d *PostgresDriver) WriteApplication(app *repository.Application) (*repository.Application, error) {
+	return d.WriteApplicationContext(context.Background(), app)
+}
+
+// WriteApplicationContext is WriteApplication bounded by the context and the driver's statement timeout
+func (d *PostgresDriver) WriteApplicationContext(ctx context.Context, app *repository.Application) (*repository.Application, error) {
+	ctx, cancel := d.withStatementTimeout(ctx)
+	defer cancel()
+
 	appIsInvalid := app.Validate()
 	if appIsInvalid != nil {
 		return nil, appIsInvalid
@@ -759,47 +851,58 @@ func (d *PostgresDriver) WriteApplication(app *repository.Application) (*reposit
 	insertApp := extractInsertDBApp(app)
 	insertAppLimit := extractInsertDBAppLimit(app)
 
+	insertGatewayAAT, err := extractInsertGatewayAAT(app, d.secrets)
+	if err != nil {
+		return nil, err
+	}
+
+	insertGatewaySettings, err := extractInsertGatewaySettings(app, d.secrets)
+	if err != nil {
+		return nil, err
+	}
+
 	nullables := []nullable{}
 	nullablesScripts := []string{}
 
-	nullables = append(nullables, extractInsertGatewayAAT(app))
+	nullables = append(nullables, insertGatewayAAT)
 	nullablesScripts = append(nullablesScripts, insertGatewayAATScript)
 
-	nullables = append(nullables, extractInsertGatewaySettings(app))
+	nullables = append(nullables, insertGatewaySettings)
 	nullablesScripts = append(nullablesScripts, insertGatewaySettingsScript)
 
 	nullables = append(nullables, extractInsertNotificationSettings(app))
 	nullablesScripts = append(nullablesScripts, insertNotificationSettingsScript)
 
-	tx, err := d.Beginx()
-	if err != nil {
-		return nil, err
-	}
+	err = d.withTx(ctx, func(tx *sqlx.Tx) error {
+		_, err := tx.NamedExecContext(ctx, insertApplicationScript, insertApp)
+		if err != nil {
+			return err
+		}
 
-	_, err = tx.NamedExec(insertApplicationScript, insertApp)
-	if err != nil {
-		return nil, err
-	}
+		_, err = tx.NamedExecContext(ctx, insertAppLimitScript, insertAppLimit)
+		if err != nil {
+			return payPlanError(err, app.Limit.PayPlan.Type)
+		}
 
-	_, err = tx.NamedExec(insertAppLimitScript, insertAppLimit)
+		return insertNullables(ctx, tx, nullables, nullablesScripts)
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	for i := 0; i < len(nullables); i++ {
-		if nullables[i].isNotNull() {
-			_, err = tx.NamedExec(nullablesScripts[i], nullables[i])
-			if err != nil {
-				return nil, err
-			}
-		}
-	}
-
-	return app, tx.Commit()
+	return app, nil
 }
 
-// UpdateApplication updates fields available in options in db
+// UpdateApplication updates fields available in options in db, recording the changes in the audit log
 func (d *PostgresDriver) UpdateApplication(id string, fieldsToUpdate *repository.UpdateApplication) error {
+	return d.UpdateApplicationContext(context.Background(), id, fieldsToUpdate)
+}
+
+// UpdateApplicationContext is UpdateApplication bounded by the context and the driver's statement timeout
+func (d *PostgresDriver) UpdateApplicationContext(ctx context.Context, id string, fieldsToUpdate *repository.UpdateApplication) error {
+	ctx, cancel := d.withStatementTimeout(ctx)
+	defer cancel()
+
 	if id == "" {
 		return ErrMissingID
 	}
@@ -809,13 +912,7 @@ func (d *PostgresDriver) UpdateApplication(id string, fieldsToUpdate *repository
 		return invalidUpdate
 	}
 
-	tx, err := d.Beginx()
-	if err != nil {
-		return err
-	}
-
-	_, err = tx.Exec(updateApplication, newSQLNullString(fieldsToUpdate.Name), newSQLNullString(string(fieldsToUpdate.Status)),
-		newSQLNullTime(fieldsToUpdate.FirstDateSurpassed), time.Now(), id)
+	gatewaySettings, err := convertRepositoryToDBGatewaySettings(id, fieldsToUpdate.GatewaySettings, d.secrets)
 	if err != nil {
 		return err
 	}
@@ -823,49 +920,57 @@ func (d *PostgresDriver) UpdateApplication(id string, fieldsToUpdate *repository
 	updates := []*update{}
 
 	updates = append(updates, &update{
-		insertScript: insertAppLimitScript,
-		updateScript: updateAppLimitScript,
+		upsertScript: upsertAppLimitScript,
 		toUpdate:     convertRepositoryToDBAppLimit(id, fieldsToUpdate.Limit),
+		audited:      applicationRow("app_limits", id),
 	})
 
 	updates = append(updates, &update{
-		insertScript: insertGatewaySettingsScript,
-		updateScript: updateGatewaySettings,
-		toUpdate:     convertRepositoryToDBGatewaySettings(id, fieldsToUpdate.GatewaySettings),
+		upsertScript: upsertGatewaySettingsScript,
+		toUpdate:     gatewaySettings,
+		audited:      applicationRow("gateway_settings", id),
 	})
 	if fieldsToUpdate.GatewaySettings != nil {
-		for _, contract := range fieldsToUpdate.GatewaySettings.WhitelistContracts {
+		for i := range fieldsToUpdate.GatewaySettings.WhitelistContracts {
 			updates = append(updates, &update{
-				insertScript: insertWhitelistContractsScript,
-				updateScript: updateWhitelistContractsScript,
-				toUpdate:     convertRepositoryToDBWhitelistContracts(id, &contract),
-				secondaryID:  contract.BlockchainID,
+				upsertScript: upsertWhitelistContractsScript,
+				toUpdate:     convertRepositoryToDBWhitelistContracts(id, &fieldsToUpdate.GatewaySettings.WhitelistContracts[i]),
+				audited:      whitelistRow("whitelist_contracts", id, fieldsToUpdate.GatewaySettings.WhitelistContracts[i].BlockchainID),
 			})
 		}
-		for _, method := range fieldsToUpdate.GatewaySettings.WhitelistMethods {
+		for i := range fieldsToUpdate.GatewaySettings.WhitelistMethods {
 			updates = append(updates, &update{
-				insertScript: insertWhitelistMethodsScript,
-				updateScript: updateWhitelistMethodsScript,
-				toUpdate:     convertRepositoryToDBWhitelistMethods(id, &method),
-				secondaryID:  method.BlockchainID,
+				upsertScript: upsertWhitelistMethodsScript,
+				toUpdate:     convertRepositoryToDBWhitelistMethods(id, &fieldsToUpdate.GatewaySettings.WhitelistMethods[i]),
+				audited:      whitelistRow("whitelist_methods", id, fieldsToUpdate.GatewaySettings.WhitelistMethods[i].BlockchainID),
 			})
 		}
 	}
 
 	updates = append(updates, &update{
-		insertScript: insertNotificationSettingsScript,
-		updateScript: updateNotificationSettings,
+		upsertScript: upsertNotificationSettingsScript,
 		toUpdate:     convertRepositoryToDBNotificationSettings(id, fieldsToUpdate.NotificationSettings),
+		audited:      applicationRow("notification_settings", id),
 	})
 
-	for _, update := range updates {
-		err = d.doUpdate(id, update, tx)
-		if err != nil {
-			return err
-		}
+	rows := append([]auditedRow{applicationRow("applications", id)}, auditedRows(updates)...)
+
+	err = d.withTx(ctx, func(tx *sqlx.Tx) error {
+		return audited(ctx, tx, d.secrets, auditUpdateApplication, id, rows, func() error {
+			err := rowsFound(tx.ExecContext(ctx, updateApplication, newSQLNullString(fieldsToUpdate.Name), newSQLNullString(string(fieldsToUpdate.Status)),
+				newSQLNullTime(fieldsToUpdate.FirstDateSurpassed), time.Now(), id))
+			if err != nil {
+				return err
+			}
+
+			return doUpdates(ctx, tx, updates)
+		})
+	})
+	if err != nil && fieldsToUpdate.Limit != nil {
+		return payPlanError(err, fieldsToUpdate.Limit.PayPlan.Type)
 	}
 
-	return tx.Commit()
+	return err
 }
 
 type updateFirstDateSurpassed struct {
@@ -875,6 +980,14 @@ type updateFirstDateSurpassed struct {
 }
 
 func (d *PostgresDriver) UpdateFirstDateSurpassed(firstDateSurpassed *repository.UpdateFirstDateSurpassed) error {
+	return d.UpdateFirstDateSurpassedContext(context.Background(), firstDateSurpassed)
+}
+
+// UpdateFirstDateSurpassedContext is UpdateFirstDateSurpassed bounded by the context and the driver's statement timeout
+func (d *PostgresDriver) UpdateFirstDateSurpassedContext(ctx context.Context, firstDateSurpassed *repository.UpdateFirstDateSurpassed) error {
+	ctx, cancel := d.withStatementTimeout(ctx)
+	defer cancel()
+
 	query, args, err := sqlx.Named(updateFirstDateSurpassedScript, &updateFirstDateSurpassed{
 		ApplicationIDs:     firstDateSurpassed.ApplicationIDs,
 		FirstDateSurpassed: firstDateSurpassed.FirstDateSurpassed,
@@ -891,7 +1004,7 @@ func (d *PostgresDriver) UpdateFirstDateSurpassed(firstDateSurpassed *repository
 
 	query = d.Rebind(query)
 
-	_, err = d.Exec(query, args...)
+	_, err = d.ExecContext(ctx, query, args...)
 	if err != nil {
 		return err
 	}
@@ -899,16 +1012,25 @@ func (d *PostgresDriver) UpdateFirstDateSurpassed(firstDateSurpassed *repository
 	return nil
 }
 
-// RemoveApplication updates fields available in options in db
+// RemoveApplication sets the application awaiting its grace period, recording the change in the audit log
 func (d *PostgresDriver) RemoveApplication(id string) error {
+	return d.RemoveApplicationContext(context.Background(), id)
+}
+
+// RemoveApplicationContext is RemoveApplication bounded by the context and the driver's statement timeout
+func (d *PostgresDriver) RemoveApplicationContext(ctx context.Context, id string) error {
+	ctx, cancel := d.withStatementTimeout(ctx)
+	defer cancel()
+
 	if id == "" {
 		return ErrMissingID
 	}
 
-	_, err := d.Exec(removeApplication, newSQLNullString(string(repository.AwaitingGracePeriod)), time.Now(), id)
-	if err != nil {
-		return err
-	}
+	rows := []auditedRow{applicationRow("applications", id)}
 
-	return nil
+	return d.withTx(ctx, func(tx *sqlx.Tx) error {
+		return audited(ctx, tx, d.secrets, auditRemoveApplication, id, rows, func() error {
+			return rowsFound(tx.ExecContext(ctx, removeApplication, newSQLNullString(string(repository.AwaitingGracePeriod)), time.Now(), id))
+		})
+	})
 }
@@ -41,6 +41,83 @@ func TestPostgresDriver_ReadApplications(t *testing.T) {
 	c.Empty(applications)
 }
 
+func TestPostgresDriver_ReadApplication(t *testing.T) {
+	c := require.New(t)
+
+	db, mock, err := sqlmock.New()
+	c.NoError(err)
+
+	defer db.Close()
+
+	rows := sqlmock.NewRows([]string{"application_id", "name", "user_id", "pay_plan"}).
+		AddRow("5f62b7d8be3591c4dea85661", "Wawawa", "6068da279aab4900333ec6dd", "FREETIER_V0")
+
+	mock.ExpectQuery("^WITH (.+) FROM applications (.+) WHERE a.application_id = (.+) GROUP BY").
+		WithArgs("5f62b7d8be3591c4dea85661").WillReturnRows(rows)
+
+	driver := NewPostgresDriverFromSQLDBInstance(db, &ListenerMock{})
+
+	application, err := driver.ReadApplication("5f62b7d8be3591c4dea85661")
+	c.NoError(err)
+	c.Equal("Wawawa", application.Name)
+	c.Equal(repository.FreetierV0, application.Limit.PayPlan.Type)
+
+	mock.ExpectQuery("^WITH (.+) FROM applications").WithArgs("not-an-id").
+		WillReturnRows(sqlmock.NewRows([]string{"application_id"}))
+
+	_, err = driver.ReadApplication("not-an-id")
+	c.Equal(ErrNotFound, err)
+
+	_, err = driver.ReadApplication("")
+	c.Equal(ErrMissingID, err)
+}
+
+func TestPostgresDriver_ReadApplicationsPage(t *testing.T) {
+	c := require.New(t)
+
+	db, mock, err := sqlmock.New()
+	c.NoError(err)
+
+	defer db.Close()
+
+	since := time.Now()
+
+	rows := sqlmock.NewRows([]string{"application_id", "user_id"}).
+		AddRow("1", "6068da279aab4900333ec6dd").
+		AddRow("2", "6068da279aab4900333ec6dd").
+		AddRow("3", "6068da279aab4900333ec6dd")
+
+	mock.ExpectQuery("^WITH (.+) WHERE a.user_id = (.+) AND a.updated_at >= (.+) GROUP BY (.+) ORDER BY a.application_id LIMIT").
+		WithArgs("6068da279aab4900333ec6dd", since, 3).WillReturnRows(rows)
+
+	driver := NewPostgresDriverFromSQLDBInstance(db, &ListenerMock{})
+
+	filter := ApplicationFilter{UserID: "6068da279aab4900333ec6dd", UpdatedSince: since}
+
+	applications, cursor, err := driver.ReadApplicationsPage(filter, Page{Limit: 2})
+	c.NoError(err)
+	c.Len(applications, 2)
+	c.Equal("2", cursor)
+
+	rows = sqlmock.NewRows([]string{"application_id", "user_id"}).
+		AddRow("3", "6068da279aab4900333ec6dd")
+
+	mock.ExpectQuery("^WITH (.+) AND a.application_id > (.+) ORDER BY a.application_id LIMIT").
+		WithArgs("6068da279aab4900333ec6dd", since, "2", 3).WillReturnRows(rows)
+
+	applications, cursor, err = driver.ReadApplicationsPage(filter, Page{Cursor: cursor, Limit: 2})
+	c.NoError(err)
+	c.Len(applications, 1)
+	c.Empty(cursor)
+
+	mock.ExpectQuery("^WITH (.+) FROM applications").WillReturnError(errors.New("dummy error"))
+
+	applications, cursor, err = driver.ReadApplicationsPage(ApplicationFilter{}, Page{})
+	c.EqualError(err, "dummy error")
+	c.Empty(applications)
+	c.Empty(cursor)
+}
+
 func TestPostgresDriver_WriteApplication(t *testing.T) {
 	c := require.New(t)
 
@@ -137,6 +214,8 @@ func TestPostgresDriver_WriteApplication(t *testing.T) {
 		"60ddc61b6e29c3003378361D", "klk", "yes@yes.com", "a life", "juancito", "app.com", "ORPHANED", false, sqlmock.AnyArg(), sqlmock.AnyArg()).
 		WillReturnError(errors.New("error in applications"))
 
+	mock.ExpectRollback()
+
 	app, err = driver.WriteApplication(appToSend)
 	c.EqualError(err, "error in applications")
 	c.Empty(app)
@@ -159,6 +238,8 @@ func TestPostgresDriver_WriteApplication(t *testing.T) {
 		"1").
 		WillReturnError(errors.New("error in gateway_aat"))
 
+	mock.ExpectRollback()
+
 	app, err = driver.WriteApplication(appToSend)
 	c.EqualError(err, "error in gateway_aat")
 	c.Empty(app)
@@ -169,12 +250,43 @@ func TestPostgresDriver_WriteApplication(t *testing.T) {
 	c.Equal(repository.ErrInvalidAppStatus, err)
 	c.Empty(app)
 
+	/* Pay plans missing from the catalogue are rejected by the database */
 	appToSend.Status = repository.Orphaned
-	appToSend.Limit.PayPlan.Type = "wrong"
+	appToSend.Limit = repository.AppLimit{PayPlan: repository.PayPlan{Type: "wrong"}}
+
+	mock.ExpectBegin()
+
+	mock.ExpectExec("INSERT into applications").WillReturnResult(sqlmock.NewResult(1, 1))
+
+	mock.ExpectExec("INSERT into app_limits").WithArgs(sqlmock.AnyArg(), "wrong", nil).
+		WillReturnError(&pq.Error{Code: foreignKeyViolation, Constraint: payPlanConstraint})
+
+	mock.ExpectRollback()
 
 	app, err = driver.WriteApplication(appToSend)
-	c.Equal(repository.ErrInvalidPayPlanType, err)
+	c.True(errors.Is(err, repository.ErrInvalidPayPlanType))
 	c.Empty(app)
+
+	/* Applications without a pay plan are written with a null one */
+	appToSend.Limit = repository.AppLimit{}
+
+	mock.ExpectBegin()
+
+	mock.ExpectExec("INSERT into applications").WillReturnResult(sqlmock.NewResult(1, 1))
+
+	mock.ExpectExec("INSERT into app_limits").WithArgs(sqlmock.AnyArg(), nil, nil).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	mock.ExpectExec("INSERT into gateway_aat").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("INSERT into gateway_settings").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("INSERT into notification_settings").WillReturnResult(sqlmock.NewResult(1, 1))
+
+	mock.ExpectCommit()
+
+	app, err = driver.WriteApplication(appToSend)
+	c.NoError(err)
+	c.NotEmpty(app)
+	c.NoError(mock.ExpectationsWereMet())
 }
 
 func TestPostgresDriver_UpdateApplication(t *testing.T) {
@@ -189,35 +301,28 @@ func TestPostgresDriver_UpdateApplication(t *testing.T) {
 
 	/* Update works when all fields provided */
 	mock.ExpectBegin()
+	expectAuditSnapshots(mock, "applications", "app_limits", "gateway_settings", "whitelist_contracts", "whitelist_methods", "notification_settings")
 
 	mock.ExpectExec("UPDATE applications").WithArgs("pablo", "ORPHANED", sqlmock.AnyArg(), sqlmock.AnyArg(), "60e85042bf95f5003559b791").
 		WillReturnResult(sqlmock.NewResult(1, 1))
 
-	mock.ExpectQuery("^SELECT (.+) FROM app_limits (.+)").WillReturnRows(sqlmock.NewRows([]string{"application_id", "pay_plan", "custom_limit"}).
-		AddRow("5f62b7d8be3591c4dea85661", "PAY_AS_YOU_GO_V0", 0))
-
-	mock.ExpectExec("UPDATE app_limits").WithArgs("PAY_AS_YOU_GO_V0", nil, "60e85042bf95f5003559b791").
+	mock.ExpectExec("INSERT into app_limits (.+) ON CONFLICT").WithArgs("60e85042bf95f5003559b791", "PAY_AS_YOU_GO_V0", nil).
 		WillReturnResult(sqlmock.NewResult(1, 1))
 
-	mock.ExpectQuery("^SELECT (.+) FROM gateway_settings (.+)").WillReturnRows(sqlmock.NewRows([]string{"application_id"}).
-		AddRow("5f62b7d8be3591c4dea85661"))
-	mock.ExpectExec("UPDATE gateway_settings").WithArgs("54y4p93body6qco2nrhonz6bltn1k5e8", true, pq.StringArray([]string{"url.com"}), pq.StringArray([]string{"gecko.com"}), pq.StringArray([]string{"0021"}), "60e85042bf95f5003559b791").
+	mock.ExpectExec("INSERT into gateway_settings (.+) ON CONFLICT").WithArgs("60e85042bf95f5003559b791", "54y4p93body6qco2nrhonz6bltn1k5e8",
+		true, pq.StringArray([]string{"url.com"}), pq.StringArray([]string{"gecko.com"}), pq.StringArray([]string{"0021"})).
 		WillReturnResult(sqlmock.NewResult(1, 1))
 
-	mock.ExpectQuery("SELECT (.+) FROM whitelist_contracts (.+)").WillReturnRows(sqlmock.NewRows([]string{"application_id", "blockchain_id"}).
-		AddRow("5f62b7d8be3591c4dea85661", "0021"))
-	mock.ExpectExec("UPDATE whitelist_contracts").WithArgs(pq.StringArray([]string{"ajua"}), "60e85042bf95f5003559b791", "0021").
+	mock.ExpectExec("INSERT INTO whitelist_contracts (.+) ON CONFLICT").WithArgs("60e85042bf95f5003559b791", "0021", pq.StringArray([]string{"ajua"})).
 		WillReturnResult(sqlmock.NewResult(1, 1))
 
-	mock.ExpectQuery("SELECT (.+) FROM whitelist_methods (.+)").WillReturnRows(sqlmock.NewRows([]string{"application_id", "blockchain_id"}).
-		AddRow("5f62b7d8be3591c4dea85661", "0021"))
-	mock.ExpectExec("UPDATE whitelist_methods").WithArgs(pq.StringArray([]string{"POST"}), "60e85042bf95f5003559b791", "0021").
+	mock.ExpectExec("INSERT INTO whitelist_methods (.+) ON CONFLICT").WithArgs("60e85042bf95f5003559b791", "0021", pq.StringArray([]string{"POST"})).
 		WillReturnResult(sqlmock.NewResult(1, 1))
 
-	mock.ExpectQuery("^SELECT (.+) FROM notification_settings (.+)").WillReturnRows(sqlmock.NewRows([]string{"application_id", "signed_up", "on_quarter", "on_half", "on_three_quarters", "on_full"}).
-		AddRow("5f62b7d8be3591c4dea85661", false, false, false, false, false))
+	mock.ExpectExec("INSERT into notification_settings (.+) ON CONFLICT").WithArgs("60e85042bf95f5003559b791", true, true, true, true, true).
+		WillReturnResult(sqlmock.NewResult(1, 1))
 
-	mock.ExpectExec("UPDATE notification_settings").WithArgs(true, true, true, true, true, "60e85042bf95f5003559b791").WillReturnResult(sqlmock.NewResult(1, 1))
+	expectAuditSnapshots(mock, "applications", "app_limits", "gateway_settings", "whitelist_contracts", "whitelist_methods", "notification_settings")
 
 	mock.ExpectCommit()
 
@@ -267,52 +372,17 @@ func TestPostgresDriver_UpdateApplication(t *testing.T) {
 	})
 	c.NoError(err)
 
-	/* Update works when Notification Settings missing */
-	mock.ExpectBegin()
-
-	mock.ExpectExec("UPDATE applications").WithArgs("pablo", "ORPHANED", sqlmock.AnyArg(), sqlmock.AnyArg(), "60e85042bf95f5003559b791").
-		WillReturnResult(sqlmock.NewResult(1, 1))
-
-	mock.ExpectQuery("^SELECT (.+) FROM app_limits (.+)").WillReturnRows(sqlmock.NewRows([]string{"application_id", "pay_plan", "custom_limit"}).
-		AddRow("5f62b7d8be3591c4dea85661", "PAY_AS_YOU_GO_V0", 0))
-
-	mock.ExpectExec("UPDATE app_limits").WithArgs("PAY_AS_YOU_GO_V0", nil, "60e85042bf95f5003559b791").
-		WillReturnResult(sqlmock.NewResult(1, 1))
-
-	mock.ExpectQuery("^SELECT (.+) FROM gateway_settings (.+)").WillReturnRows(sqlmock.NewRows(nil))
-
-	mock.ExpectExec("INSERT into gateway_settings").WithArgs("60e85042bf95f5003559b791", "54y4p93body6qco2nrhonz6bltn1k5e8",
-		true, pq.StringArray([]string{"url.com"}), pq.StringArray([]string{"gecko.com"}), pq.StringArray([]string{"0021"})).
-		WillReturnResult(sqlmock.NewResult(1, 1))
-	mock.ExpectQuery("SELECT (.+) FROM whitelist_contracts (.+)").WillReturnRows(sqlmock.NewRows([]string{"application_id", "blockchain_id"}).
-		AddRow("5f62b7d8be3591c4dea85661", "0021"))
-	mock.ExpectExec("UPDATE whitelist_contracts").WithArgs(pq.StringArray([]string{"ajua"}), "60e85042bf95f5003559b791", "0021").
-		WillReturnResult(sqlmock.NewResult(1, 1))
-
-	mock.ExpectQuery("SELECT (.+) FROM whitelist_methods (.+)").WillReturnRows(sqlmock.NewRows([]string{"application_id", "blockchain_id"}).
-		AddRow("5f62b7d8be3591c4dea85661", "0021"))
-	mock.ExpectExec("UPDATE whitelist_methods").WithArgs(pq.StringArray([]string{"POST"}), "60e85042bf95f5003559b791", "0021").
-		WillReturnResult(sqlmock.NewResult(1, 1))
-
-	mock.ExpectCommit()
-
-	err = driver.UpdateApplication("60e85042bf95f5003559b791", &repository.UpdateApplication{
-		Name:            "pablo",
-		Status:          repository.Orphaned,
-		Limit:           limitToSend,
-		GatewaySettings: settingsToSend,
-	})
-	c.NoError(err)
-
 	/* Update works when Gateway Settings and Limit missing */
 	mock.ExpectBegin()
+	expectAuditSnapshots(mock, "applications", "notification_settings")
 
 	mock.ExpectExec("UPDATE applications").WithArgs("pablo", "ORPHANED", sqlmock.AnyArg(), sqlmock.AnyArg(), "60e85042bf95f5003559b791").
 		WillReturnResult(sqlmock.NewResult(1, 1))
 
-	mock.ExpectQuery("^SELECT (.+) FROM notification_settings (.+)").WillReturnRows(sqlmock.NewRows(nil))
+	mock.ExpectExec("INSERT into notification_settings (.+) ON CONFLICT").WithArgs("60e85042bf95f5003559b791", true, true, true, true, true).
+		WillReturnResult(sqlmock.NewResult(1, 1))
 
-	mock.ExpectExec("INSERT into notification_settings").WithArgs("60e85042bf95f5003559b791", true, true, true, true, true).WillReturnResult(sqlmock.NewResult(1, 1))
+	expectAuditSnapshots(mock, "applications", "notification_settings")
 
 	mock.ExpectCommit()
 
@@ -325,16 +395,16 @@ func TestPostgresDriver_UpdateApplication(t *testing.T) {
 
 	/* Update works when Notification Settings and Gateway Settings missing */
 	mock.ExpectBegin()
+	expectAuditSnapshots(mock, "applications", "app_limits")
 
 	mock.ExpectExec("UPDATE applications").WithArgs("pablo", "ORPHANED", sqlmock.AnyArg(), sqlmock.AnyArg(), "60e85042bf95f5003559b791").
 		WillReturnResult(sqlmock.NewResult(1, 1))
 
-	mock.ExpectQuery("^SELECT (.+) FROM app_limits (.+)").WillReturnRows(sqlmock.NewRows([]string{"application_id", "pay_plan", "custom_limit"}).
-		AddRow("5f62b7d8be3591c4dea85661", "PAY_AS_YOU_GO_V0", 0))
-
-	mock.ExpectExec("UPDATE app_limits").WithArgs("PAY_AS_YOU_GO_V0", nil, "60e85042bf95f5003559b791").
+	mock.ExpectExec("INSERT into app_limits (.+) ON CONFLICT").WithArgs("60e85042bf95f5003559b791", "PAY_AS_YOU_GO_V0", nil).
 		WillReturnResult(sqlmock.NewResult(1, 1))
 
+	expectAuditSnapshots(mock, "applications", "app_limits")
+
 	mock.ExpectCommit()
 
 	err = driver.UpdateApplication("60e85042bf95f5003559b791", &repository.UpdateApplication{
@@ -344,12 +414,15 @@ func TestPostgresDriver_UpdateApplication(t *testing.T) {
 	})
 	c.NoError(err)
 
-	/* Update errors as expected on Applications table Error */
+	/* Update rolls back as expected on Applications table Error */
 	mock.ExpectBegin()
+	expectAuditSnapshots(mock, "applications", "gateway_settings", "whitelist_contracts", "whitelist_methods", "notification_settings")
 
 	mock.ExpectExec("UPDATE applications").WithArgs("pablo", "ORPHANED", sqlmock.AnyArg(), sqlmock.AnyArg(), "60e85042bf95f5003559b791").
 		WillReturnError(errors.New("error in applications"))
 
+	mock.ExpectRollback()
+
 	err = driver.UpdateApplication("60e85042bf95f5003559b791", &repository.UpdateApplication{
 		Name:                 "pablo",
 		Status:               repository.Orphaned,
@@ -358,47 +431,22 @@ func TestPostgresDriver_UpdateApplication(t *testing.T) {
 	})
 	c.EqualError(err, "error in applications")
 
-	/* Update errors as expected on select Error */
+	/* Update rolls back as expected on upsert Error */
 	mock.ExpectBegin()
+	expectAuditSnapshots(mock, "applications", "app_limits", "gateway_settings", "whitelist_contracts", "whitelist_methods")
 
 	mock.ExpectExec("UPDATE applications").WithArgs("pablo", "ORPHANED", sqlmock.AnyArg(), sqlmock.AnyArg(), "60e85042bf95f5003559b791").
 		WillReturnResult(sqlmock.NewResult(1, 1))
 
-	mock.ExpectQuery("^SELECT (.+) FROM app_limits (.+)").WillReturnRows(sqlmock.NewRows([]string{"application_id", "pay_plan", "custom_limit"}).
-		AddRow("5f62b7d8be3591c4dea85661", "PAY_AS_YOU_GO_V0", 0))
-
-	mock.ExpectExec("UPDATE app_limits").WithArgs("PAY_AS_YOU_GO_V0", nil, "60e85042bf95f5003559b791").
+	mock.ExpectExec("INSERT into app_limits (.+) ON CONFLICT").WithArgs("60e85042bf95f5003559b791", "PAY_AS_YOU_GO_V0", nil).
 		WillReturnResult(sqlmock.NewResult(1, 1))
 
-	mock.ExpectQuery("^SELECT (.+) FROM gateway_settings (.+)").WillReturnError(errors.New("error reading gateway_settings"))
-
-	err = driver.UpdateApplication("60e85042bf95f5003559b791", &repository.UpdateApplication{
-		Name:            "pablo",
-		Status:          repository.Orphaned,
-		Limit:           limitToSend,
-		GatewaySettings: settingsToSend,
-	})
-	c.EqualError(err, "error reading gateway_settings")
-
-	/* Update errors as expected on update Error */
-	mock.ExpectBegin()
-
-	mock.ExpectExec("UPDATE applications").WithArgs("pablo", "ORPHANED", sqlmock.AnyArg(), sqlmock.AnyArg(), "60e85042bf95f5003559b791").
-		WillReturnResult(sqlmock.NewResult(1, 1))
-
-	mock.ExpectQuery("^SELECT (.+) FROM app_limits (.+)").WillReturnRows(sqlmock.NewRows([]string{"application_id", "pay_plan", "custom_limit"}).
-		AddRow("5f62b7d8be3591c4dea85661", "PAY_AS_YOU_GO_V0", 0))
-
-	mock.ExpectExec("UPDATE app_limits").WithArgs("PAY_AS_YOU_GO_V0", nil, "60e85042bf95f5003559b791").
-		WillReturnResult(sqlmock.NewResult(1, 1))
-
-	mock.ExpectQuery("^SELECT (.+) FROM gateway_settings (.+)").WillReturnRows(sqlmock.NewRows([]string{"application_id"}).
-		AddRow("5f62b7d8be3591c4dea85661"))
-
-	mock.ExpectExec("UPDATE gateway_settings").WithArgs("54y4p93body6qco2nrhonz6bltn1k5e8",
-		true, pq.StringArray([]string{"url.com"}), pq.StringArray([]string{"gecko.com"}), pq.StringArray([]string{"0021"}), "60e85042bf95f5003559b791").
+	mock.ExpectExec("INSERT into gateway_settings (.+) ON CONFLICT").WithArgs("60e85042bf95f5003559b791", "54y4p93body6qco2nrhonz6bltn1k5e8",
+		true, pq.StringArray([]string{"url.com"}), pq.StringArray([]string{"gecko.com"}), pq.StringArray([]string{"0021"})).
 		WillReturnError(errors.New("error in settings"))
 
+	mock.ExpectRollback()
+
 	err = driver.UpdateApplication("60e85042bf95f5003559b791", &repository.UpdateApplication{
 		Name:            "pablo",
 		Status:          repository.Orphaned,
@@ -407,31 +455,24 @@ func TestPostgresDriver_UpdateApplication(t *testing.T) {
 	})
 	c.EqualError(err, "error in settings")
 
-	/* Update errors as expected on insert Error */
+	/* Update errors as expected on commit Error */
 	mock.ExpectBegin()
+	expectAuditSnapshots(mock, "applications")
 
 	mock.ExpectExec("UPDATE applications").WithArgs("pablo", "ORPHANED", sqlmock.AnyArg(), sqlmock.AnyArg(), "60e85042bf95f5003559b791").
 		WillReturnResult(sqlmock.NewResult(1, 1))
 
-	mock.ExpectQuery("^SELECT (.+) FROM app_limits (.+)").WillReturnRows(sqlmock.NewRows([]string{"application_id", "pay_plan", "custom_limit"}).
-		AddRow("5f62b7d8be3591c4dea85661", "PAY_AS_YOU_GO_V0", 0))
-
-	mock.ExpectExec("UPDATE app_limits").WithArgs("PAY_AS_YOU_GO_V0", nil, "60e85042bf95f5003559b791").
-		WillReturnResult(sqlmock.NewResult(1, 1))
-
-	mock.ExpectQuery("^SELECT (.+) FROM gateway_settings (.+)").WillReturnRows(sqlmock.NewRows(nil))
+	expectAuditSnapshots(mock, "applications")
 
-	mock.ExpectExec("INSERT into gateway_settings").WithArgs("60e85042bf95f5003559b791", "54y4p93body6qco2nrhonz6bltn1k5e8",
-		true, pq.StringArray([]string{"url.com"}), pq.StringArray([]string{"gecko.com"}), pq.StringArray([]string{"0021"})).
-		WillReturnError(errors.New("error in inserting settings"))
+	mock.ExpectCommit().WillReturnError(errors.New("error in commit"))
 
 	err = driver.UpdateApplication("60e85042bf95f5003559b791", &repository.UpdateApplication{
-		Name:            "pablo",
-		Status:          repository.Orphaned,
-		Limit:           limitToSend,
-		GatewaySettings: settingsToSend,
+		Name:   "pablo",
+		Status: repository.Orphaned,
 	})
-	c.EqualError(err, "error in inserting settings")
+	c.EqualError(err, "error in commit")
+
+	c.NoError(mock.ExpectationsWereMet())
 
 	/* Update errors as expected when no fields provided */
 	err = driver.UpdateApplication("60e85042bf95f5003559b791", nil)
@@ -448,6 +489,16 @@ func TestPostgresDriver_UpdateApplication(t *testing.T) {
 	c.Equal(repository.ErrInvalidAppStatus, err)
 
 	/* Update errors as expected when invalid pay plan type provided */
+	mock.ExpectBegin()
+	expectAuditSnapshots(mock, "applications", "app_limits")
+
+	mock.ExpectExec("UPDATE applications").WillReturnResult(sqlmock.NewResult(1, 1))
+
+	mock.ExpectExec("INSERT into app_limits (.+) ON CONFLICT").WithArgs("60e85042bf95f5003559b791", "wrong", nil).
+		WillReturnError(&pq.Error{Code: foreignKeyViolation, Constraint: payPlanConstraint})
+
+	mock.ExpectRollback()
+
 	err = driver.UpdateApplication("60e85042bf95f5003559b791", &repository.UpdateApplication{
 		Status: repository.Orphaned,
 		Limit: &repository.AppLimit{
@@ -456,7 +507,7 @@ func TestPostgresDriver_UpdateApplication(t *testing.T) {
 			},
 		},
 	})
-	c.Equal(repository.ErrInvalidPayPlanType, err)
+	c.True(errors.Is(err, repository.ErrInvalidPayPlanType))
 
 	/* Update errors as expected when attempting to update a non Enterprise Plan with a custom limit */
 	err = driver.UpdateApplication("60e85042bf95f5003559b791", &repository.UpdateApplication{
@@ -492,18 +543,36 @@ func TestPostgresDriver_RemoveApplication(t *testing.T) {
 
 	driver := NewPostgresDriverFromSQLDBInstance(db, &ListenerMock{})
 
+	mock.ExpectBegin()
+	expectAuditSnapshots(mock, "applications")
 	mock.ExpectExec("UPDATE applications").WithArgs("AWAITING_GRACE_PERIOD", sqlmock.AnyArg(), "60ddc61b6e2936fhtrns63h2").
 		WillReturnResult(sqlmock.NewResult(1, 1))
+	expectAuditSnapshots(mock, "applications")
+	mock.ExpectCommit()
 
 	err = driver.RemoveApplication("60ddc61b6e2936fhtrns63h2")
 	c.NoError(err)
 
+	mock.ExpectBegin()
+	expectAuditSnapshots(mock, "applications")
 	mock.ExpectExec("UPDATE applications").WithArgs("AWAITING_GRACE_PERIOD", sqlmock.AnyArg(), "not-an-id").
 		WillReturnError(errors.New("dummy error"))
+	mock.ExpectRollback()
 
 	err = driver.RemoveApplication("not-an-id")
 	c.EqualError(err, "dummy error")
 
+	/* Removing a missing application records no change */
+	mock.ExpectBegin()
+	expectAuditSnapshots(mock, "applications")
+	mock.ExpectExec("UPDATE applications").WithArgs("AWAITING_GRACE_PERIOD", sqlmock.AnyArg(), "missing").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+
+	err = driver.RemoveApplication("missing")
+	c.ErrorIs(err, ErrNotFound)
+	c.NoError(mock.ExpectationsWereMet())
+
 	err = driver.RemoveApplication("")
 	c.Equal(ErrMissingID, err)
 }
@@ -0,0 +1,294 @@
+package postgresdriver
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pokt-foundation/portal-api-go/repository"
+)
+
+const (
+	// UnknownActor is recorded as the actor of changes made with a context without one
+	UnknownActor = "unknown"
+
+	auditUpdateApplication   = "UPDATE_APPLICATION"
+	auditRemoveApplication   = "REMOVE_APPLICATION"
+	auditUpdateLoadBalancer  = "UPDATE_LOAD_BALANCER"
+	auditActivateBlockchain  = "ACTIVATE_BLOCKCHAIN"
+	auditRedactedValue       = `"` + repository.RedactedSecret + `"`
+	selectAuditSnapshotQuery = `SELECT row_to_json(t)::TEXT FROM %s AS t WHERE %s`
+
+	insertAuditEntryScript = `
+	INSERT INTO audit_log (actor, operation, entity_id, table_name, before, after, created_at)
+	VALUES ($1, $2, $3, $4, $5, $6, $7)`
+	selectAuditLogScript = `
+	SELECT id, actor, operation, entity_id, table_name, before::TEXT, after::TEXT, created_at
+	FROM audit_log
+	WHERE entity_id = $1
+	ORDER BY created_at DESC, id DESC`
+)
+
+var (
+	// auditIgnoredColumns are not recorded as changes, the audit entry has its own timestamp
+	auditIgnoredColumns = map[string]bool{
+		"updated_at": true,
+	}
+	// auditRedactedColumns are recorded as changed without their values
+	auditRedactedColumns = map[string]bool{
+		"secret_key":  true,
+		"private_key": true,
+	}
+)
+
+type actorKey struct{}
+
+// WithActor returns a copy of the context carrying who makes the changes, recorded in the audit log
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// ActorFromContext returns the actor carried by the context, UnknownActor if there is none
+func ActorFromContext(ctx context.Context) string {
+	actor, ok := ctx.Value(actorKey{}).(string)
+	if !ok || actor == "" {
+		return UnknownActor
+	}
+
+	return actor
+}
+
+// AuditEntry is a change of a single row of the entity, with the values of
+// the changed columns before and after it; Before is null for inserted rows
+type AuditEntry struct {
+	ID        int64           `json:"id"`
+	Actor     string          `json:"actor"`
+	Operation string          `json:"operation"`
+	EntityID  string          `json:"entityID"`
+	Table     string          `json:"table"`
+	Before    json.RawMessage `json:"before"`
+	After     json.RawMessage `json:"after"`
+	CreatedAt time.Time       `json:"createdAt"`
+}
+
+type dbAuditEntry struct {
+	ID        int64          `db:"id"`
+	Actor     string         `db:"actor"`
+	Operation string         `db:"operation"`
+	EntityID  string         `db:"entity_id"`
+	Table     string         `db:"table_name"`
+	Before    sql.NullString `db:"before"`
+	After     sql.NullString `db:"after"`
+	CreatedAt time.Time      `db:"created_at"`
+}
+
+func (e *dbAuditEntry) toAuditEntry() *AuditEntry {
+	entry := &AuditEntry{
+		ID:        e.ID,
+		Actor:     e.Actor,
+		Operation: e.Operation,
+		EntityID:  e.EntityID,
+		Table:     e.Table,
+		CreatedAt: e.CreatedAt,
+	}
+	if e.Before.Valid {
+		entry.Before = json.RawMessage(e.Before.String)
+	}
+	if e.After.Valid {
+		entry.After = json.RawMessage(e.After.String)
+	}
+
+	return entry
+}
+
+// auditedRow is a single row changed by an audited operation
+type auditedRow struct {
+	table string
+	where string
+	args  []interface{}
+}
+
+func applicationRow(table, id string) auditedRow {
+	return auditedRow{table: table, where: "application_id = $1", args: []interface{}{id}}
+}
+
+func whitelistRow(table, id, blockchainID string) auditedRow {
+	return auditedRow{table: table, where: "application_id = $1 AND blockchain_id = $2", args: []interface{}{id, blockchainID}}
+}
+
+func loadBalancerRow(table, id string) auditedRow {
+	return auditedRow{table: table, where: "lb_id = $1", args: []interface{}{id}}
+}
+
+func blockchainRow(table, id string) auditedRow {
+	return auditedRow{table: table, where: "blockchain_id = $1", args: []interface{}{id}}
+}
+
+// snapshot returns the columns of the row, nil if the row does not exist
+func (r auditedRow) snapshot(ctx context.Context, tx *sqlx.Tx) (map[string]json.RawMessage, error) {
+	var raw string
+
+	err := tx.GetContext(ctx, &raw, fmt.Sprintf(selectAuditSnapshotQuery, r.table, r.where), r.args...)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var columns map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(raw), &columns); err != nil {
+		return nil, err
+	}
+
+	return columns, nil
+}
+
+// audited runs change within the transaction and records, in the same transaction,
+// an audit entry for every row whose columns were changed by it. Secrets are compared decrypted with secrets.
+func audited(ctx context.Context, tx *sqlx.Tx, secrets SecretCipher, operation, entityID string, rows []auditedRow, change func() error) error {
+	before := make([]map[string]json.RawMessage, len(rows))
+	for i, row := range rows {
+		snapshot, err := row.snapshot(ctx, tx)
+		if err != nil {
+			return err
+		}
+		before[i] = snapshot
+	}
+
+	if err := change(); err != nil {
+		return err
+	}
+
+	actor := ActorFromContext(ctx)
+	now := time.Now()
+
+	for i, row := range rows {
+		after, err := row.snapshot(ctx, tx)
+		if err != nil {
+			return err
+		}
+
+		beforeDiff, afterDiff, changed := diffColumns(before[i], after, secrets)
+		if !changed {
+			continue
+		}
+
+		_, err = tx.ExecContext(ctx, insertAuditEntryScript, actor, operation, entityID, row.table, beforeDiff, afterDiff, now)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// diffColumns returns the changed columns of a row with their values before and after the change
+func diffColumns(before, after map[string]json.RawMessage, secrets SecretCipher) (sql.NullString, sql.NullString, bool) {
+	beforeDiff := map[string]json.RawMessage{}
+	afterDiff := map[string]json.RawMessage{}
+
+	for column, value := range after {
+		if auditIgnoredColumns[column] || sameValue(column, before[column], value, secrets) {
+			continue
+		}
+		afterDiff[column] = auditValue(column, value)
+		if previous, ok := before[column]; ok {
+			beforeDiff[column] = auditValue(column, previous)
+		}
+	}
+
+	for column, value := range before {
+		if _, ok := after[column]; ok || auditIgnoredColumns[column] {
+			continue
+		}
+		beforeDiff[column] = auditValue(column, value)
+	}
+
+	if len(beforeDiff) == 0 && len(afterDiff) == 0 {
+		return sql.NullString{}, sql.NullString{}, false
+	}
+
+	return auditJSON(before, beforeDiff), auditJSON(after, afterDiff), true
+}
+
+// sameValue returns whether the column has the same value before and after the change. Redacted columns are
+// compared decrypted: the cipher encrypts every write with a new nonce, even if the secret is unchanged.
+func sameValue(column string, before, after json.RawMessage, secrets SecretCipher) bool {
+	if bytes.Equal(before, after) {
+		return true
+	}
+	if !auditRedactedColumns[column] || before == nil || string(before) == "null" || string(after) == "null" {
+		return false
+	}
+
+	var beforeSecret, afterSecret string
+	if json.Unmarshal(before, &beforeSecret) != nil || json.Unmarshal(after, &afterSecret) != nil {
+		return false
+	}
+	// Secrets that can not be decrypted are compared as stored
+	beforePlaintext, err := secrets.Decrypt(beforeSecret)
+	if err != nil {
+		return false
+	}
+	afterPlaintext, err := secrets.Decrypt(afterSecret)
+	if err != nil {
+		return false
+	}
+
+	return beforePlaintext == afterPlaintext
+}
+
+func auditValue(column string, value json.RawMessage) json.RawMessage {
+	if auditRedactedColumns[column] && string(value) != "null" {
+		return json.RawMessage(auditRedactedValue)
+	}
+
+	return value
+}
+
+// auditJSON returns the changed columns, null if the row did not exist
+func auditJSON(row, diff map[string]json.RawMessage) sql.NullString {
+	if row == nil {
+		return sql.NullString{}
+	}
+
+	raw, _ := json.Marshal(diff)
+
+	return sql.NullString{String: string(raw), Valid: true}
+}
+
+// ReadAuditLog returns the changes recorded for the entity, latest first
+func (d *PostgresDriver) ReadAuditLog(entityID string) ([]*AuditEntry, error) {
+	return d.ReadAuditLogContext(context.Background(), entityID)
+}
+
+// ReadAuditLogContext is ReadAuditLog bounded by the context and the driver's statement timeout
+func (d *PostgresDriver) ReadAuditLogContext(ctx context.Context, entityID string) ([]*AuditEntry, error) {
+	ctx, cancel := d.withStatementTimeout(ctx)
+	defer cancel()
+
+	if entityID == "" {
+		return nil, ErrMissingID
+	}
+
+	var dbEntries []*dbAuditEntry
+
+	err := d.SelectContext(ctx, &dbEntries, selectAuditLogScript, entityID)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := []*AuditEntry{}
+
+	for _, dbEntry := range dbEntries {
+		entries = append(entries, dbEntry.toAuditEntry())
+	}
+
+	return entries, nil
+}
@@ -0,0 +1,271 @@
+package postgresdriver
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/pokt-foundation/portal-api-go/repository"
+	"github.com/stretchr/testify/require"
+)
+
+// expectAuditSnapshots expects the audit snapshots of rows not changed by the operation
+func expectAuditSnapshots(mock sqlmock.Sqlmock, tables ...string) {
+	for _, table := range tables {
+		expectAuditSnapshot(mock, table, `{}`)
+	}
+}
+
+// expectAuditSnapshot expects the audit snapshot of a row, an empty row means the row does not exist
+func expectAuditSnapshot(mock sqlmock.Sqlmock, table, row string) {
+	rows := sqlmock.NewRows([]string{"row_to_json"})
+	if row != "" {
+		rows.AddRow(row)
+	}
+
+	mock.ExpectQuery(`SELECT row_to_json\(t\)::TEXT FROM ` + table + ` AS t`).WillReturnRows(rows)
+}
+
+func TestDiffColumns(t *testing.T) {
+	secrets, err := NewEnvelopeCipher(testSecretKey)
+	require.NoError(t, err)
+
+	encrypt := func(secret string) string {
+		encrypted, err := secrets.Encrypt(secret)
+		require.NoError(t, err)
+		return encrypted
+	}
+
+	testCases := []struct {
+		name           string
+		before         string
+		after          string
+		expectedBefore interface{}
+		expectedAfter  interface{}
+		expectedChange bool
+	}{
+		{
+			name:           "Changed columns",
+			before:         `{"application_id":"1","name":"old","status":"IN_SERVICE","updated_at":"2022-01-01T00:00:00"}`,
+			after:          `{"application_id":"1","name":"new","status":"IN_SERVICE","updated_at":"2022-01-02T00:00:00"}`,
+			expectedBefore: `{"name":"old"}`,
+			expectedAfter:  `{"name":"new"}`,
+			expectedChange: true,
+		},
+		{
+			name:           "Only updated_at changed",
+			before:         `{"application_id":"1","updated_at":"2022-01-01T00:00:00"}`,
+			after:          `{"application_id":"1","updated_at":"2022-01-02T00:00:00"}`,
+			expectedChange: false,
+		},
+		{
+			name:           "Inserted row",
+			after:          `{"application_id":"1","pay_plan":"FREETIER_V0"}`,
+			expectedBefore: nil,
+			expectedAfter:  `{"application_id":"1","pay_plan":"FREETIER_V0"}`,
+			expectedChange: true,
+		},
+		{
+			name:           "Secrets are redacted",
+			before:         `{"secret_key":"old","secret_key_required":false}`,
+			after:          `{"secret_key":"new","secret_key_required":false}`,
+			expectedBefore: `{"secret_key":"[REDACTED]"}`,
+			expectedAfter:  `{"secret_key":"[REDACTED]"}`,
+			expectedChange: true,
+		},
+		{
+			name:           "Encrypted secrets are compared decrypted",
+			before:         fmt.Sprintf(`{"secret_key":%q,"private_key":%q}`, encrypt("secret"), encrypt("old")),
+			after:          fmt.Sprintf(`{"secret_key":%q,"private_key":%q}`, encrypt("secret"), encrypt("new")),
+			expectedBefore: `{"private_key":"[REDACTED]"}`,
+			expectedAfter:  `{"private_key":"[REDACTED]"}`,
+			expectedChange: true,
+		},
+		{
+			name:           "Secrets encrypted again without change are not recorded",
+			before:         fmt.Sprintf(`{"secret_key":%q,"secret_key_required":false}`, encrypt("secret")),
+			after:          fmt.Sprintf(`{"secret_key":%q,"secret_key_required":false}`, encrypt("secret")),
+			expectedChange: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := require.New(t)
+
+			var before, after map[string]json.RawMessage
+			if tc.before != "" {
+				c.NoError(json.Unmarshal([]byte(tc.before), &before))
+			}
+			if tc.after != "" {
+				c.NoError(json.Unmarshal([]byte(tc.after), &after))
+			}
+
+			beforeDiff, afterDiff, changed := diffColumns(before, after, secrets)
+			c.Equal(tc.expectedChange, changed)
+			if !changed {
+				return
+			}
+
+			c.Equal(tc.expectedBefore, nullStringValue(beforeDiff))
+			c.Equal(tc.expectedAfter, nullStringValue(afterDiff))
+		})
+	}
+}
+
+func nullStringValue(s sql.NullString) interface{} {
+	if !s.Valid {
+		return nil
+	}
+
+	return s.String
+}
+
+func TestPostgresDriver_AuditedChanges(t *testing.T) {
+	c := require.New(t)
+
+	db, mock, err := sqlmock.New()
+	c.NoError(err)
+
+	defer db.Close()
+
+	driver := NewPostgresDriverFromSQLDBInstance(db, &ListenerMock{})
+
+	/* Changes are recorded with the actor of the context in the same transaction */
+	mock.ExpectBegin()
+	expectAuditSnapshot(mock, "blockchains", `{"blockchain_id":"0021","active":false,"updated_at":"2022-01-01T00:00:00"}`)
+	mock.ExpectExec("UPDATE blockchains").WithArgs(true, sqlmock.AnyArg(), "0021").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	expectAuditSnapshot(mock, "blockchains", `{"blockchain_id":"0021","active":true,"updated_at":"2022-01-02T00:00:00"}`)
+	mock.ExpectExec("INSERT INTO audit_log").WithArgs("ops@pokt.network", "ACTIVATE_BLOCKCHAIN", "0021", "blockchains",
+		`{"active":false}`, `{"active":true}`, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	err = driver.ActivateBlockchainContext(WithActor(context.Background(), "ops@pokt.network"), "0021", true)
+	c.NoError(err)
+
+	/* Failing to record the change rolls it back */
+	mock.ExpectBegin()
+	expectAuditSnapshot(mock, "applications", `{"application_id":"1","status":"IN_SERVICE"}`)
+	mock.ExpectExec("UPDATE applications").WithArgs("AWAITING_GRACE_PERIOD", sqlmock.AnyArg(), "1").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	expectAuditSnapshot(mock, "applications", `{"application_id":"1","status":"AWAITING_GRACE_PERIOD"}`)
+	mock.ExpectExec("INSERT INTO audit_log").WithArgs(UnknownActor, "REMOVE_APPLICATION", "1", "applications",
+		`{"status":"IN_SERVICE"}`, `{"status":"AWAITING_GRACE_PERIOD"}`, sqlmock.AnyArg()).
+		WillReturnError(errors.New("error in audit log"))
+	mock.ExpectRollback()
+
+	err = driver.RemoveApplication("1")
+	c.EqualError(err, "error in audit log")
+
+	c.NoError(mock.ExpectationsWereMet())
+}
+
+func TestPostgresDriver_AuditedSecrets(t *testing.T) {
+	c := require.New(t)
+
+	db, mock, err := sqlmock.New()
+	c.NoError(err)
+
+	defer db.Close()
+
+	secrets, err := NewEnvelopeCipher(testSecretKey)
+	c.NoError(err)
+
+	driver := NewPostgresDriverFromSQLDBInstance(db, &ListenerMock{}, WithSecretCipher(secrets))
+
+	before, err := secrets.Encrypt("54y4p93body6qco2nrhonz6bltn1k5e8")
+	c.NoError(err)
+	after, err := secrets.Encrypt("54y4p93body6qco2nrhonz6bltn1k5e8")
+	c.NoError(err)
+
+	/* Updating other settings writes the unchanged secret encrypted again, without recording it as changed */
+	mock.ExpectBegin()
+	expectAuditSnapshot(mock, "applications", `{"application_id":"1"}`)
+	expectAuditSnapshot(mock, "gateway_settings", fmt.Sprintf(`{"application_id":"1","secret_key":%q,"whitelist_origins":["url.com"]}`, before))
+	mock.ExpectExec("UPDATE applications").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("INSERT into gateway_settings (.+) ON CONFLICT").WillReturnResult(sqlmock.NewResult(1, 1))
+	expectAuditSnapshot(mock, "applications", `{"application_id":"1"}`)
+	expectAuditSnapshot(mock, "gateway_settings", fmt.Sprintf(`{"application_id":"1","secret_key":%q,"whitelist_origins":["url.com","dapp.com"]}`, after))
+	mock.ExpectExec("INSERT INTO audit_log").WithArgs(UnknownActor, "UPDATE_APPLICATION", "1", "gateway_settings",
+		`{"whitelist_origins":["url.com"]}`, `{"whitelist_origins":["url.com","dapp.com"]}`, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	err = driver.UpdateApplication("1", &repository.UpdateApplication{
+		GatewaySettings: &repository.GatewaySettings{
+			SecretKey:        "54y4p93body6qco2nrhonz6bltn1k5e8",
+			WhitelistOrigins: []string{"url.com", "dapp.com"},
+		},
+	})
+	c.NoError(err)
+
+	c.NoError(mock.ExpectationsWereMet())
+}
+
+func TestPostgresDriver_ReadAuditLog(t *testing.T) {
+	c := require.New(t)
+
+	db, mock, err := sqlmock.New()
+	c.NoError(err)
+
+	defer db.Close()
+
+	createdAt := time.Date(2022, time.January, 2, 0, 0, 0, 0, time.UTC)
+
+	rows := sqlmock.NewRows([]string{"id", "actor", "operation", "entity_id", "table_name", "before", "after", "created_at"}).
+		AddRow(2, "ops@pokt.network", "UPDATE_APPLICATION", "1", "app_limits", nil, `{"pay_plan": "FREETIER_V0"}`, createdAt).
+		AddRow(1, "ops@pokt.network", "UPDATE_APPLICATION", "1", "applications", `{"name": "old"}`, `{"name": "new"}`, createdAt)
+
+	mock.ExpectQuery("SELECT (.+) FROM audit_log WHERE entity_id = (.+) ORDER BY created_at DESC").WithArgs("1").
+		WillReturnRows(rows)
+
+	driver := NewPostgresDriverFromSQLDBInstance(db, &ListenerMock{})
+
+	entries, err := driver.ReadAuditLog("1")
+	c.NoError(err)
+	c.Equal([]*AuditEntry{
+		{
+			ID:        2,
+			Actor:     "ops@pokt.network",
+			Operation: "UPDATE_APPLICATION",
+			EntityID:  "1",
+			Table:     "app_limits",
+			After:     json.RawMessage(`{"pay_plan": "FREETIER_V0"}`),
+			CreatedAt: createdAt,
+		},
+		{
+			ID:        1,
+			Actor:     "ops@pokt.network",
+			Operation: "UPDATE_APPLICATION",
+			EntityID:  "1",
+			Table:     "applications",
+			Before:    json.RawMessage(`{"name": "old"}`),
+			After:     json.RawMessage(`{"name": "new"}`),
+			CreatedAt: createdAt,
+		},
+	}, entries)
+
+	mock.ExpectQuery("SELECT (.+) FROM audit_log").WithArgs("2").WillReturnError(errors.New("dummy error"))
+
+	entries, err = driver.ReadAuditLog("2")
+	c.EqualError(err, "dummy error")
+	c.Empty(entries)
+
+	_, err = driver.ReadAuditLog("")
+	c.Equal(ErrMissingID, err)
+}
+
+func TestActorFromContext(t *testing.T) {
+	c := require.New(t)
+
+	c.Equal(UnknownActor, ActorFromContext(context.Background()))
+	c.Equal(UnknownActor, ActorFromContext(WithActor(context.Background(), "")))
+	c.Equal("ops@pokt.network", ActorFromContext(WithActor(context.Background(), "ops@pokt.network")))
+}
@@ -1,15 +1,17 @@
 package postgresdriver
 
 import (
+	"context"
 	"database/sql"
 	"time"
 
+	"github.com/jmoiron/sqlx"
 	"github.com/lib/pq"
 	"github.com/pokt-foundation/portal-api-go/repository"
 )
 
 const (
-	selectBlockchainsScript = `SELECT b.blockchain_id, b.altruist, b.blockchain, b.blockchain_aliases, b.chain_id, b.chain_id_check, b.description, b.enforce_result, b.log_limit_blocks, b.network, b.path, b.request_timeout, b.ticker, b.active,
+	selectBlockchainsScript = `SELECT b.blockchain_id, b.altruist, b.blockchain, b.blockchain_aliases, b.chain_id, b.chain_id_check, b.description, b.enforce_result, b.log_limit_blocks, b.network, b.path, b.request_timeout, b.ticker, b.active, b.created_at, b.updated_at,
 	s.synccheck as s_sync_check, s.allowance as s_allowance, s.body as s_body, s.path as s_path, s.result_key as s_result_key
 	FROM blockchains as b
 	LEFT JOIN sync_check_options AS s ON b.blockchain_id=s.blockchain_id`
@@ -23,6 +25,15 @@ const (
 	UPDATE blockchains
 	SET active = :active, updated_at = :updated_at
 	WHERE blockchain_id = :blockchain_id`
+	updateBlockchain = `
+	UPDATE blockchains
+	SET blockchain_aliases = COALESCE($1, blockchain_aliases), chain_id_check = COALESCE($2, chain_id_check), log_limit_blocks = COALESCE($3, log_limit_blocks), request_timeout = COALESCE($4, request_timeout), updated_at = $5
+	WHERE blockchain_id = $6`
+	upsertSyncCheckOptionsScript = `
+	INSERT into sync_check_options (blockchain_id, synccheck, allowance, body, path, result_key)
+	VALUES (:blockchain_id, :synccheck, :allowance, :body, :path, :result_key)
+	ON CONFLICT (blockchain_id)
+	DO UPDATE SET allowance = EXCLUDED.allowance, body = EXCLUDED.body, path = EXCLUDED.path, result_key = EXCLUDED.result_key`
 )
 
 type dbBlockchain struct {
@@ -73,6 +84,8 @@ func (b *dbBlockchain) toBlockchain() *repository.Blockchain {
 			Path:      b.Path.String,
 			Allowance: int(b.Allowance.Int32),
 		},
+		CreatedAt: b.CreatedAt,
+		UpdatedAt: b.UpdatedAt,
 	}
 }
 
@@ -137,9 +150,17 @@ type insertDBBlockchain struct {
 
 // ReadBlockchains returns all blockchains on the database
 func (d *PostgresDriver) ReadBlockchains() ([]*repository.Blockchain, error) {
+	return d.ReadBlockchainsContext(context.Background())
+}
+
+// ReadBlockchainsContext is ReadBlockchains bounded by the context and the driver's statement timeout
+func (d *PostgresDriver) ReadBlockchainsContext(ctx context.Context) ([]*repository.Blockchain, error) {
+	ctx, cancel := d.withStatementTimeout(ctx)
+	defer cancel()
+
 	var dbBlockchains []*dbBlockchain
 
-	err := d.Select(&dbBlockchains, selectBlockchainsScript)
+	err := d.SelectContext(ctx, &dbBlockchains, selectBlockchainsScript)
 	if err != nil {
 		return nil, err
 	}
@@ -153,6 +174,68 @@ func (d *PostgresDriver) ReadBlockchains() ([]*repository.Blockchain, error) {
 	return blockchains, nil
 }
 
+// ReadBlockchainsPage returns a page of the blockchains matching the filter,
+// and the cursor of the next page, empty if it is the last one
+func (d *PostgresDriver) ReadBlockchainsPage(filter BlockchainFilter, page Page) ([]*repository.Blockchain, string, error) {
+	return d.ReadBlockchainsPageContext(context.Background(), filter, page)
+}
+
+// ReadBlockchainsPageContext is ReadBlockchainsPage bounded by the context and the driver's statement timeout
+func (d *PostgresDriver) ReadBlockchainsPageContext(ctx context.Context, filter BlockchainFilter, page Page) ([]*repository.Blockchain, string, error) {
+	ctx, cancel := d.withStatementTimeout(ctx)
+	defer cancel()
+
+	c := filter.conditions()
+	query := c.pageQuery(selectBlockchainsScript, "", "b.blockchain_id", page)
+
+	var dbBlockchains []*dbBlockchain
+
+	err := d.SelectContext(ctx, &dbBlockchains, query, c.args...)
+	if err != nil {
+		return nil, "", err
+	}
+
+	count, cursor := nextCursor(len(dbBlockchains), page, func(i int) string {
+		return dbBlockchains[i].BlockchainID
+	})
+
+	blockchains := []*repository.Blockchain{}
+
+	for _, dbBlockchain := range dbBlockchains[:count] {
+		blockchains = append(blockchains, dbBlockchain.toBlockchain())
+	}
+
+	return blockchains, cursor, nil
+}
+
+// ReadBlockchainsUpdatedSince returns all blockchains updated at or after since
+func (d *PostgresDriver) ReadBlockchainsUpdatedSince(since time.Time) ([]*repository.Blockchain, error) {
+	return d.ReadBlockchainsUpdatedSinceContext(context.Background(), since)
+}
+
+// ReadBlockchainsUpdatedSinceContext is ReadBlockchainsUpdatedSince bounded by the context,
+// each page read is bounded by the driver's statement timeout
+func (d *PostgresDriver) ReadBlockchainsUpdatedSinceContext(ctx context.Context, since time.Time) ([]*repository.Blockchain, error) {
+	filter := BlockchainFilter{UpdatedSince: since}
+	page := Page{Limit: MaxPageSize}
+
+	blockchains := []*repository.Blockchain{}
+
+	for {
+		read, cursor, err := d.ReadBlockchainsPageContext(ctx, filter, page)
+		if err != nil {
+			return nil, err
+		}
+
+		blockchains = append(blockchains, read...)
+
+		if cursor == "" {
+			return blockchains, nil
+		}
+		page.Cursor = cursor
+	}
+}
+
 func extractInsertDBBlockchain(blockchain *repository.Blockchain) *insertDBBlockchain {
 	return &insertDBBlockchain{
 		BlockchainID:      blockchain.ID,
@@ -204,6 +287,24 @@ func (i *insertSyncCheckOptions) isNotNull() bool {
 	return i.SyncCheck.Valid || i.Body.Valid || i.Path.Valid || i.ResultKey.Valid || i.Allowance.Valid
 }
 
+func (i *insertSyncCheckOptions) isUpdatable() bool {
+	return i != nil
+}
+
+func convertRepositoryToDBSyncCheckOptions(id string, options *repository.SyncCheckOptions) *insertSyncCheckOptions {
+	if options == nil {
+		return nil
+	}
+
+	return &insertSyncCheckOptions{
+		BlockchainID: id,
+		Body:         newSQLNullString(options.Body),
+		Path:         newSQLNullString(options.Path),
+		ResultKey:    newSQLNullString(options.ResultKey),
+		Allowance:    newSQLNullInt32(int32(options.Allowance)),
+	}
+}
+
 func extractInsertSyncCheckOptions(blockchain *repository.Blockchain) *insertSyncCheckOptions {
 	return &insertSyncCheckOptions{
 		BlockchainID: blockchain.ID,
@@ -217,6 +318,14 @@ func extractInsertSyncCheckOptions(blockchain *repository.Blockchain) *insertSyn
 
 // WriteBlockchain saves input blockchain in the database
 func (d *PostgresDriver) WriteBlockchain(blockchain *repository.Blockchain) (*repository.Blockchain, error) {
+	return d.WriteBlockchainContext(context.Background(), blockchain)
+}
+
+// WriteBlockchainContext is WriteBlockchain bounded by the context and the driver's statement timeout
+func (d *PostgresDriver) WriteBlockchainContext(ctx context.Context, blockchain *repository.Blockchain) (*repository.Blockchain, error) {
+	ctx, cancel := d.withStatementTimeout(ctx)
+	defer cancel()
+
 	blockchain.CreatedAt = time.Now()
 	blockchain.UpdatedAt = time.Now()
 
@@ -228,26 +337,19 @@ func (d *PostgresDriver) WriteBlockchain(blockchain *repository.Blockchain) (*re
 	nullables = append(nullables, extractInsertSyncCheckOptions(blockchain))
 	nullablesScripts = append(nullablesScripts, insertSyncCheckOptionsScript)
 
-	tx, err := d.Beginx()
-	if err != nil {
-		return nil, err
-	}
+	err := d.withTx(ctx, func(tx *sqlx.Tx) error {
+		_, err := tx.NamedExecContext(ctx, insertBlockchainScript, insertApp)
+		if err != nil {
+			return err
+		}
 
-	_, err = tx.NamedExec(insertBlockchainScript, insertApp)
+		return insertNullables(ctx, tx, nullables, nullablesScripts)
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	for i := 0; i < len(nullables); i++ {
-		if nullables[i].isNotNull() {
-			_, err = tx.NamedExec(nullablesScripts[i], nullables[i])
-			if err != nil {
-				return nil, err
-			}
-		}
-	}
-
-	return blockchain, tx.Commit()
+	return blockchain, nil
 }
 
 type activateDBBlockchain struct {
@@ -256,26 +358,66 @@ type activateDBBlockchain struct {
 	UpdatedAt    time.Time `db:"updated_at"`
 }
 
+// ActivateBlockchain activates or deactivates the blockchain, recording the change in the audit log
 func (d *PostgresDriver) ActivateBlockchain(id string, active bool) error {
+	return d.ActivateBlockchainContext(context.Background(), id, active)
+}
+
+// ActivateBlockchainContext is ActivateBlockchain bounded by the context and the driver's statement timeout
+func (d *PostgresDriver) ActivateBlockchainContext(ctx context.Context, id string, active bool) error {
+	ctx, cancel := d.withStatementTimeout(ctx)
+	defer cancel()
+
 	if id == "" {
 		return ErrMissingID
 	}
 
-	tx, err := d.Beginx()
-	if err != nil {
-		return err
-	}
-
 	update := &activateDBBlockchain{
 		BlockchainID: id,
 		Active:       active,
 		UpdatedAt:    time.Now(),
 	}
 
-	_, err = tx.NamedExec(activateBlockchain, update)
-	if err != nil {
-		return err
+	rows := []auditedRow{blockchainRow("blockchains", id)}
+
+	return d.withTx(ctx, func(tx *sqlx.Tx) error {
+		return audited(ctx, tx, d.secrets, auditActivateBlockchain, id, rows, func() error {
+			return rowsFound(tx.NamedExecContext(ctx, activateBlockchain, update))
+		})
+	})
+}
+
+// UpdateBlockchain updates fields available in options in db
+func (d *PostgresDriver) UpdateBlockchain(id string, fieldsToUpdate *repository.UpdateBlockchain) error {
+	return d.UpdateBlockchainContext(context.Background(), id, fieldsToUpdate)
+}
+
+// UpdateBlockchainContext is UpdateBlockchain bounded by the context and the driver's statement timeout
+func (d *PostgresDriver) UpdateBlockchainContext(ctx context.Context, id string, fieldsToUpdate *repository.UpdateBlockchain) error {
+	ctx, cancel := d.withStatementTimeout(ctx)
+	defer cancel()
+
+	if id == "" {
+		return ErrMissingID
+	}
+
+	invalidUpdate := fieldsToUpdate.Validate()
+	if invalidUpdate != nil {
+		return invalidUpdate
 	}
 
-	return tx.Commit()
+	updates := []*update{{
+		upsertScript: upsertSyncCheckOptionsScript,
+		toUpdate:     convertRepositoryToDBSyncCheckOptions(id, fieldsToUpdate.SyncCheckOptions),
+	}}
+
+	return d.withTx(ctx, func(tx *sqlx.Tx) error {
+		err := rowsFound(tx.ExecContext(ctx, updateBlockchain, pq.StringArray(fieldsToUpdate.BlockchainAliases), newSQLNullString(fieldsToUpdate.ChainIDCheck),
+			newSQLNullInt32(int32(fieldsToUpdate.LogLimitBlocks)), newSQLNullInt32(int32(fieldsToUpdate.RequestTimeout)), time.Now(), id))
+		if err != nil {
+			return err
+		}
+
+		return doUpdates(ctx, tx, updates)
+	})
 }
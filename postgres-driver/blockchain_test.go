@@ -3,7 +3,9 @@ package postgresdriver
 import (
 	"database/sql"
 	"errors"
+	"fmt"
 	"testing"
+	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/lib/pq"
@@ -41,6 +43,75 @@ func TestPostgresDriver_ReadBlockchains(t *testing.T) {
 	c.Empty(blockchains)
 }
 
+func TestPostgresDriver_ReadBlockchainsPage(t *testing.T) {
+	c := require.New(t)
+
+	db, mock, err := sqlmock.New()
+	c.NoError(err)
+
+	defer db.Close()
+
+	since := time.Now()
+
+	rows := sqlmock.NewRows([]string{"blockchain_id", "blockchain", "updated_at"}).
+		AddRow("0021", "eth-mainnet", since).
+		AddRow("0040", "harmony-0", since)
+
+	mock.ExpectQuery("^SELECT (.+) FROM blockchains (.+) WHERE b.updated_at >= (.+) ORDER BY b.blockchain_id LIMIT").
+		WithArgs(since, 2).WillReturnRows(rows)
+
+	driver := NewPostgresDriverFromSQLDBInstance(db, &ListenerMock{})
+
+	blockchains, cursor, err := driver.ReadBlockchainsPage(BlockchainFilter{UpdatedSince: since}, Page{Limit: 1})
+	c.NoError(err)
+	c.Len(blockchains, 1)
+	c.Equal(since, blockchains[0].UpdatedAt)
+	c.Equal("0021", cursor)
+
+	mock.ExpectQuery("^SELECT (.+) FROM blockchains").WillReturnError(errors.New("dummy error"))
+
+	blockchains, _, err = driver.ReadBlockchainsPage(BlockchainFilter{}, Page{})
+	c.EqualError(err, "dummy error")
+	c.Empty(blockchains)
+}
+
+func TestPostgresDriver_ReadBlockchainsUpdatedSince(t *testing.T) {
+	c := require.New(t)
+
+	db, mock, err := sqlmock.New()
+	c.NoError(err)
+
+	defer db.Close()
+
+	since := time.Now()
+
+	firstPage := sqlmock.NewRows([]string{"blockchain_id", "updated_at"})
+	for i := 0; i <= MaxPageSize; i++ {
+		firstPage.AddRow(fmt.Sprintf("%04d", i), since)
+	}
+
+	mock.ExpectQuery("^SELECT (.+) FROM blockchains (.+) WHERE b.updated_at >= (.+) ORDER BY b.blockchain_id LIMIT").
+		WithArgs(since, MaxPageSize+1).WillReturnRows(firstPage)
+	mock.ExpectQuery("^SELECT (.+) FROM blockchains (.+) WHERE b.updated_at >= (.+) AND b.blockchain_id > (.+) ORDER BY b.blockchain_id LIMIT").
+		WithArgs(since, fmt.Sprintf("%04d", MaxPageSize-1), MaxPageSize+1).
+		WillReturnRows(sqlmock.NewRows([]string{"blockchain_id", "updated_at"}).AddRow(fmt.Sprintf("%04d", MaxPageSize), since))
+
+	driver := NewPostgresDriverFromSQLDBInstance(db, &ListenerMock{})
+
+	blockchains, err := driver.ReadBlockchainsUpdatedSince(since)
+	c.NoError(err)
+	c.Len(blockchains, MaxPageSize+1)
+	c.Equal(fmt.Sprintf("%04d", MaxPageSize), blockchains[MaxPageSize].ID)
+
+	mock.ExpectQuery("^SELECT (.+) FROM blockchains").WillReturnError(errors.New("dummy error"))
+
+	blockchains, err = driver.ReadBlockchainsUpdatedSince(since)
+	c.EqualError(err, "dummy error")
+	c.Empty(blockchains)
+
+	c.NoError(mock.ExpectationsWereMet())
+}
+
 func TestPostgresDriver_WriteBlockchain(t *testing.T) {
 	c := require.New(t)
 
@@ -98,6 +169,8 @@ func TestPostgresDriver_WriteBlockchain(t *testing.T) {
 		"0062", true, "https://testaltruist.com", "ethereum-mainnet", pq.StringArray([]string{"ethereum-mainnet-high-gas"}), "34", `{\""method\"":\""eth_chainId\"",\""id\"":1,\""jsonrpc\"":\""2.0\""`, "Ethereum Mainnet", "JSON", 10000, "ETH-34", "/etc/", 10, "ETH", sqlmock.AnyArg(), sqlmock.AnyArg()).
 		WillReturnError(errors.New("error in blockchains"))
 
+	mock.ExpectRollback()
+
 	blockchain, err = driver.WriteBlockchain(blockchainToSend)
 	c.EqualError(err, "error in blockchains")
 	c.Empty(blockchain)
@@ -112,7 +185,100 @@ func TestPostgresDriver_WriteBlockchain(t *testing.T) {
 		"0062", "synccheck", 3, `{\""method\"":\""eth_blockNumber\"",\""id\"":1,\""jsonrpc\"":\""2.0\""}`, "/ext/bc/C/rpc", "result").
 		WillReturnError(errors.New("error in sync_check_options"))
 
+	mock.ExpectRollback()
+
 	blockchain, err = driver.WriteBlockchain(blockchainToSend)
 	c.EqualError(err, "error in sync_check_options")
 	c.Empty(blockchain)
 }
+
+func TestPostgresDriver_UpdateBlockchain(t *testing.T) {
+	c := require.New(t)
+
+	db, mock, err := sqlmock.New()
+	c.NoError(err)
+
+	defer db.Close()
+
+	driver := NewPostgresDriverFromSQLDBInstance(db, &ListenerMock{})
+
+	mock.ExpectBegin()
+
+	mock.ExpectExec("UPDATE blockchains").WithArgs(pq.StringArray([]string{"eth-mainnet", "eth-archival"}),
+		`{"method":"eth_chainId","id":1,"jsonrpc":"2.0"}`, 5000, 20, sqlmock.AnyArg(), "0021").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	mock.ExpectExec("INSERT into sync_check_options (.+) ON CONFLICT").WithArgs("0021", sql.NullString{}, 3,
+		`{"method":"eth_blockNumber","id":1,"jsonrpc":"2.0"}`, sql.NullString{}, "result").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	mock.ExpectCommit()
+
+	err = driver.UpdateBlockchain("0021", &repository.UpdateBlockchain{
+		BlockchainAliases: []string{"eth-mainnet", "eth-archival"},
+		ChainIDCheck:      `{"method":"eth_chainId","id":1,"jsonrpc":"2.0"}`,
+		LogLimitBlocks:    5000,
+		RequestTimeout:    20,
+		SyncCheckOptions: &repository.SyncCheckOptions{
+			Body:      `{"method":"eth_blockNumber","id":1,"jsonrpc":"2.0"}`,
+			ResultKey: "result",
+			Allowance: 3,
+		},
+	})
+	c.NoError(err)
+
+	mock.ExpectBegin()
+
+	mock.ExpectExec("UPDATE blockchains").WithArgs(nil, sql.NullString{}, sql.NullInt32{}, 20, sqlmock.AnyArg(), "0021").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	mock.ExpectCommit()
+
+	err = driver.UpdateBlockchain("0021", &repository.UpdateBlockchain{
+		RequestTimeout: 20,
+	})
+	c.NoError(err)
+
+	mock.ExpectBegin()
+
+	mock.ExpectExec("UPDATE blockchains").WithArgs(nil, sql.NullString{}, sql.NullInt32{}, 20, sqlmock.AnyArg(), "0021").
+		WillReturnError(errors.New("error in blockchains"))
+
+	mock.ExpectRollback()
+
+	err = driver.UpdateBlockchain("0021", &repository.UpdateBlockchain{
+		RequestTimeout: 20,
+	})
+	c.EqualError(err, "error in blockchains")
+
+	mock.ExpectBegin()
+
+	mock.ExpectExec("UPDATE blockchains").WithArgs(nil, sql.NullString{}, sql.NullInt32{}, sql.NullInt32{}, sqlmock.AnyArg(), "0021").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	mock.ExpectExec("INSERT into sync_check_options (.+) ON CONFLICT").WithArgs("0021", sql.NullString{}, 3, sql.NullString{}, sql.NullString{}, sql.NullString{}).
+		WillReturnError(errors.New("error in sync_check_options"))
+
+	mock.ExpectRollback()
+
+	err = driver.UpdateBlockchain("0021", &repository.UpdateBlockchain{
+		SyncCheckOptions: &repository.SyncCheckOptions{Allowance: 3},
+	})
+	c.EqualError(err, "error in sync_check_options")
+
+	err = driver.UpdateBlockchain("0021", &repository.UpdateBlockchain{
+		BlockchainAliases: []string{"eth-mainnet", "ETH-MAINNET"},
+	})
+	c.Equal(repository.ErrInvalidBlockchainAlias, err)
+
+	err = driver.UpdateBlockchain("0021", &repository.UpdateBlockchain{
+		LogLimitBlocks: -1,
+	})
+	c.Equal(repository.ErrNegativeBlockchainSetting, err)
+
+	err = driver.UpdateBlockchain("0021", nil)
+	c.Equal(repository.ErrNoFieldsToUpdate, err)
+
+	err = driver.UpdateBlockchain("", nil)
+	c.Equal(ErrMissingID, err)
+}
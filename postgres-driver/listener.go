@@ -2,14 +2,49 @@ package postgresdriver
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
 
 	"github.com/lib/pq"
 	"github.com/pokt-foundation/portal-api-go/repository"
 )
 
+const (
+	// notificationDeliveryTimeout is the time a notification waits for the consumer before being dropped
+	notificationDeliveryTimeout = time.Second
+	errorChannelSize            = 32
+)
+
+var (
+	// ErrUnknownNotificationTable error when a notification is received for a table the driver does not handle
+	ErrUnknownNotificationTable = errors.New("unknown notification table")
+	// ErrUnknownNotificationAction error when a notification is not an insert, update or delete
+	ErrUnknownNotificationAction = errors.New("unknown notification action")
+)
+
 type Listener interface {
 	NotificationChannel() <-chan *pq.Notification
 	Listen(channel string) error
+	Close() error
+}
+
+// ListenerStats counts the notifications handled by the listener since the driver was created
+type ListenerStats struct {
+	// Received notifications from the database
+	Received uint64
+	// Delivered notifications, resync signals included
+	Delivered uint64
+	// Late notifications, delivered after waiting for the consumer
+	Late uint64
+	// Dropped notifications, not taken by the consumer within the delivery timeout or covered by a pending resync
+	Dropped uint64
+	// ParseErrors notifications that could not be parsed, reported on the error channel
+	ParseErrors uint64
+	// Reconnects of the listener to the database
+	Reconnects uint64
+	// Resyncs signals delivered to the consumer
+	Resyncs uint64
 }
 
 type notification struct {
@@ -18,163 +53,166 @@ type notification struct {
 	Data   any               `json:"data"`
 }
 
-func (n notification) parseLoadBalancerNotification() *repository.Notification {
-	rawData, _ := json.Marshal(n.Data)
-	var dbLoadBalancer dbLoadBalancerJSON
-	_ = json.Unmarshal(rawData, &dbLoadBalancer)
+func (n notification) decodeData(v any) error {
+	rawData, err := json.Marshal(n.Data)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(rawData, v)
+}
 
+func (n notification) output(data repository.SavedOnDB) *repository.Notification {
 	return &repository.Notification{
 		Table:  n.Table,
 		Action: n.Action,
-		Data:   dbLoadBalancer.toOutput(),
+		Data:   data,
 	}
 }
 
-func (n notification) parseStickinessOptionsNotification() *repository.Notification {
-	rawData, _ := json.Marshal(n.Data)
-	var dbStickinessOpts dbStickinessOptionsJSON
-	_ = json.Unmarshal(rawData, &dbStickinessOpts)
+func (n notification) parseLoadBalancerNotification() (*repository.Notification, error) {
+	var dbLoadBalancer dbLoadBalancerJSON
+	if err := n.decodeData(&dbLoadBalancer); err != nil {
+		return nil, err
+	}
 
-	return &repository.Notification{
-		Table:  n.Table,
-		Action: n.Action,
-		Data:   dbStickinessOpts.toOutput(),
+	return n.output(dbLoadBalancer.toOutput()), nil
+}
+
+func (n notification) parseStickinessOptionsNotification() (*repository.Notification, error) {
+	var dbStickinessOpts dbStickinessOptionsJSON
+	if err := n.decodeData(&dbStickinessOpts); err != nil {
+		return nil, err
 	}
+
+	return n.output(dbStickinessOpts.toOutput()), nil
 }
 
-func (n notification) parseLbApps() *repository.Notification {
-	rawData, _ := json.Marshal(n.Data)
+func (n notification) parseLbApps() (*repository.Notification, error) {
 	var lbApp repository.LbApp
-	_ = json.Unmarshal(rawData, &lbApp)
-
-	return &repository.Notification{
-		Table:  n.Table,
-		Action: n.Action,
-		Data:   &lbApp,
+	if err := n.decodeData(&lbApp); err != nil {
+		return nil, err
 	}
+
+	return n.output(&lbApp), nil
 }
 
-func (n notification) parseApplicationNotification() *repository.Notification {
-	rawData, _ := json.Marshal(n.Data)
+func (n notification) parseApplicationNotification() (*repository.Notification, error) {
 	var dbApp dbAppJSON
-	_ = json.Unmarshal(rawData, &dbApp)
-
-	return &repository.Notification{
-		Table:  n.Table,
-		Action: n.Action,
-		Data:   dbApp.toOutput(),
+	if err := n.decodeData(&dbApp); err != nil {
+		return nil, err
 	}
+
+	return n.output(dbApp.toOutput()), nil
 }
 
-func (n notification) parseAppLimitNotification() *repository.Notification {
-	rawData, _ := json.Marshal(n.Data)
+func (n notification) parseAppLimitNotification() (*repository.Notification, error) {
 	var dbAppLimit dbAppLimitJSON
-	_ = json.Unmarshal(rawData, &dbAppLimit)
-
-	return &repository.Notification{
-		Table:  n.Table,
-		Action: n.Action,
-		Data:   dbAppLimit.toOutput(),
+	if err := n.decodeData(&dbAppLimit); err != nil {
+		return nil, err
 	}
+
+	return n.output(dbAppLimit.toOutput()), nil
 }
 
-func (n notification) parseGatewayAATNotification() *repository.Notification {
-	rawData, _ := json.Marshal(n.Data)
+func (n notification) parseGatewayAATNotification(secrets SecretCipher) (*repository.Notification, error) {
 	var dbGatewayAAT dbGatewayAATJSON
-	_ = json.Unmarshal(rawData, &dbGatewayAAT)
+	if err := n.decodeData(&dbGatewayAAT); err != nil {
+		return nil, err
+	}
 
-	return &repository.Notification{
-		Table:  n.Table,
-		Action: n.Action,
-		Data:   dbGatewayAAT.toOutput(),
+	data, err := dbGatewayAAT.toOutput(secrets)
+	if err != nil {
+		return nil, err
 	}
+
+	return n.output(data), nil
 }
 
-func (n notification) parseGatewaySettingsNotification() *repository.Notification {
-	rawData, _ := json.Marshal(n.Data)
+func (n notification) parseGatewaySettingsNotification(secrets SecretCipher) (*repository.Notification, error) {
 	var dbGatewaySettings dbGatewaySettingsJSON
-	_ = json.Unmarshal(rawData, &dbGatewaySettings)
+	if err := n.decodeData(&dbGatewaySettings); err != nil {
+		return nil, err
+	}
 
-	return &repository.Notification{
-		Table:  n.Table,
-		Action: n.Action,
-		Data:   dbGatewaySettings.toOutput(),
+	data, err := dbGatewaySettings.toOutput(secrets)
+	if err != nil {
+		return nil, err
 	}
+
+	return n.output(data), nil
 }
 
-func (n notification) parseWhitelistContractNotification() *repository.Notification {
-	rawData, _ := json.Marshal(n.Data)
+func (n notification) parseWhitelistContractNotification() (*repository.Notification, error) {
 	var dbWhitelistContract dbWhitelistContractJSON
-	_ = json.Unmarshal(rawData, &dbWhitelistContract)
-
-	return &repository.Notification{
-		Table:  n.Table,
-		Action: n.Action,
-		Data:   dbWhitelistContract.toOutput(),
+	if err := n.decodeData(&dbWhitelistContract); err != nil {
+		return nil, err
 	}
+
+	return n.output(dbWhitelistContract.toOutput()), nil
 }
 
-func (n notification) parseWhitelistMethodNotification() *repository.Notification {
-	rawData, _ := json.Marshal(n.Data)
+func (n notification) parseWhitelistMethodNotification() (*repository.Notification, error) {
 	var dbWhitelistMethod dbWhitelistMethodJSON
-	_ = json.Unmarshal(rawData, &dbWhitelistMethod)
-
-	return &repository.Notification{
-		Table:  n.Table,
-		Action: n.Action,
-		Data:   dbWhitelistMethod.toOutput(),
+	if err := n.decodeData(&dbWhitelistMethod); err != nil {
+		return nil, err
 	}
+
+	return n.output(dbWhitelistMethod.toOutput()), nil
 }
 
-func (n notification) parseNotificationSettingsNotification() *repository.Notification {
-	rawData, _ := json.Marshal(n.Data)
+func (n notification) parseNotificationSettingsNotification() (*repository.Notification, error) {
 	var dbNotificationSettings dbNotificationSettingsJSON
-	_ = json.Unmarshal(rawData, &dbNotificationSettings)
-
-	return &repository.Notification{
-		Table:  n.Table,
-		Action: n.Action,
-		Data:   dbNotificationSettings.toOutput(),
+	if err := n.decodeData(&dbNotificationSettings); err != nil {
+		return nil, err
 	}
+
+	return n.output(dbNotificationSettings.toOutput()), nil
 }
 
-func (n notification) parseBlockchainNotification() *repository.Notification {
-	rawData, _ := json.Marshal(n.Data)
+func (n notification) parseBlockchainNotification() (*repository.Notification, error) {
 	var dbBlockchain dbBlockchainJSON
-	_ = json.Unmarshal(rawData, &dbBlockchain)
-
-	return &repository.Notification{
-		Table:  n.Table,
-		Action: n.Action,
-		Data:   dbBlockchain.toOutput(),
+	if err := n.decodeData(&dbBlockchain); err != nil {
+		return nil, err
 	}
+
+	return n.output(dbBlockchain.toOutput()), nil
 }
 
-func (n notification) parseRedirectNotification() *repository.Notification {
-	rawData, _ := json.Marshal(n.Data)
+func (n notification) parseRedirectNotification() (*repository.Notification, error) {
 	var dbRedirect dbRedirectJSON
-	_ = json.Unmarshal(rawData, &dbRedirect)
-
-	return &repository.Notification{
-		Table:  n.Table,
-		Action: n.Action,
-		Data:   dbRedirect.toOutput(),
+	if err := n.decodeData(&dbRedirect); err != nil {
+		return nil, err
 	}
+
+	return n.output(dbRedirect.toOutput()), nil
 }
 
-func (n notification) parseSyncOptionsNotification() *repository.Notification {
-	rawData, _ := json.Marshal(n.Data)
+func (n notification) parseSyncOptionsNotification() (*repository.Notification, error) {
 	var dbSyncOpts dbSyncCheckOptionsJSON
-	_ = json.Unmarshal(rawData, &dbSyncOpts)
+	if err := n.decodeData(&dbSyncOpts); err != nil {
+		return nil, err
+	}
 
-	return &repository.Notification{
-		Table:  n.Table,
-		Action: n.Action,
-		Data:   dbSyncOpts.toOutput(),
+	return n.output(dbSyncOpts.toOutput()), nil
+}
+
+func (n notification) parsePayPlanNotification() (*repository.Notification, error) {
+	var dbPayPlan dbPayPlanJSON
+	if err := n.decodeData(&dbPayPlan); err != nil {
+		return nil, err
 	}
+
+	return n.output(dbPayPlan.toOutput()), nil
 }
 
-func (n notification) parseNotification() *repository.Notification {
+func (n notification) parseNotification(secrets SecretCipher) (*repository.Notification, error) {
+	switch n.Action {
+	case repository.ActionInsert, repository.ActionUpdate, repository.ActionDelete:
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownNotificationAction, n.Action)
+	}
+
 	switch n.Table {
 	case repository.TableLoadBalancers:
 		return n.parseLoadBalancerNotification()
@@ -189,15 +227,17 @@ func (n notification) parseNotification() *repository.Notification {
 	case repository.TableAppLimits:
 		return n.parseAppLimitNotification()
 	case repository.TableGatewayAAT:
-		return n.parseGatewayAATNotification()
+		return n.parseGatewayAATNotification(secrets)
 	case repository.TableGatewaySettings:
-		return n.parseGatewaySettingsNotification()
+		return n.parseGatewaySettingsNotification(secrets)
 	case repository.TableWhitelistContracts:
 		return n.parseWhitelistContractNotification()
 	case repository.TableWhitelistMethods:
 		return n.parseWhitelistMethodNotification()
 	case repository.TableNotificationSettings:
 		return n.parseNotificationSettingsNotification()
+	case repository.TablePayPlans:
+		return n.parsePayPlanNotification()
 
 	case repository.TableBlockchains:
 		return n.parseBlockchainNotification()
@@ -207,24 +247,154 @@ func (n notification) parseNotification() *repository.Notification {
 		return n.parseSyncOptionsNotification()
 	}
 
-	return nil
+	return nil, fmt.Errorf("%w: %q", ErrUnknownNotificationTable, n.Table)
 }
 
-func parsePQNotification(n *pq.Notification, outCh chan *repository.Notification) {
-	if n != nil {
-		var notification notification
-		_ = json.Unmarshal([]byte(n.Extra), &notification)
-		outCh <- notification.parseNotification()
+func parsePQNotification(n *pq.Notification, secrets SecretCipher) (*repository.Notification, error) {
+	var notification notification
+	if err := json.Unmarshal([]byte(n.Extra), &notification); err != nil {
+		return nil, fmt.Errorf("invalid notification on channel %s: %w", n.Channel, err)
+	}
+
+	parsed, err := notification.parseNotification(secrets)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s notification: %w", notification.Table, err)
 	}
+
+	return parsed, nil
 }
 
-func Listen(inCh <-chan *pq.Notification, outCh chan *repository.Notification) {
+// listen parses the notifications received from the database and delivers them in order, until the listener is closed.
+// pq sends a nil notification after reconnecting: notifications sent while disconnected are lost, so a resync
+// signal is delivered instead. A resync is also signaled once a notification is dropped because the consumer stalled.
+func (d *PostgresDriver) listen() {
+	defer close(d.listenerStopped)
+	defer close(d.errors)
+	defer close(d.notification)
+
+	resync := false
+
 	for {
-		n := <-inCh
-		go parsePQNotification(n, outCh)
+		var retry <-chan time.Time
+		if resync {
+			retry = time.After(d.deliveryTimeout)
+		}
+
+		var (
+			n  *pq.Notification
+			ok bool
+		)
+
+		select {
+		case <-d.listenerDone:
+			return
+		case <-retry:
+		case n, ok = <-d.listener.NotificationChannel():
+			if !ok {
+				return
+			}
+			if n == nil {
+				d.updateStats(func(s *ListenerStats) { s.Reconnects++ })
+				resync = true
+			} else {
+				d.updateStats(func(s *ListenerStats) { s.Received++ })
+			}
+		}
+
+		if resync && d.deliver(&repository.Notification{Action: repository.ActionResync}) {
+			d.updateStats(func(s *ListenerStats) { s.Resyncs++ })
+			resync = false
+		}
+
+		if n == nil {
+			continue
+		}
+
+		parsed, err := parsePQNotification(n, d.secrets)
+		if err != nil {
+			d.updateStats(func(s *ListenerStats) { s.ParseErrors++ })
+			d.reportError(err)
+			continue
+		}
+
+		if resync {
+			// The pending resync covers the change
+			d.updateStats(func(s *ListenerStats) { s.Dropped++ })
+			continue
+		}
+
+		if !d.deliver(parsed) {
+			d.updateStats(func(s *ListenerStats) { s.Dropped++ })
+			resync = true
+		}
 	}
 }
 
-func (d *PostgresDriver) CloseListener() {
-	close(d.notification)
+// deliver sends the notification to the consumer, waiting up to the delivery timeout if it is not keeping up
+func (d *PostgresDriver) deliver(n *repository.Notification) bool {
+	select {
+	case d.notification <- n:
+		d.updateStats(func(s *ListenerStats) { s.Delivered++ })
+		return true
+	default:
+	}
+
+	timer := time.NewTimer(d.deliveryTimeout)
+	defer timer.Stop()
+
+	select {
+	case d.notification <- n:
+		d.updateStats(func(s *ListenerStats) {
+			s.Delivered++
+			s.Late++
+		})
+		return true
+	case <-timer.C:
+	case <-d.listenerDone:
+	}
+
+	return false
+}
+
+// reportError sends the error to the error channel, errors are discarded if nobody is reading them
+func (d *PostgresDriver) reportError(err error) {
+	select {
+	case d.errors <- err:
+	default:
+	}
+}
+
+func (d *PostgresDriver) updateStats(update func(*ListenerStats)) {
+	d.statsMu.Lock()
+	defer d.statsMu.Unlock()
+
+	update(&d.stats)
+}
+
+// ListenerStats returns the counters of the notifications handled by the listener
+func (d *PostgresDriver) ListenerStats() ListenerStats {
+	d.statsMu.Lock()
+	defer d.statsMu.Unlock()
+
+	return d.stats
+}
+
+// ErrorChannel returns the channel where notifications that could not be parsed are reported,
+// it is closed together with the notification channel
+func (d *PostgresDriver) ErrorChannel() <-chan error {
+	return d.errors
+}
+
+// CloseListener stops listening for notifications and closes the connection of the listener.
+// It returns once the notification and error channels are closed, pending notifications are discarded.
+func (d *PostgresDriver) CloseListener() error {
+	var err error
+
+	d.closeListener.Do(func() {
+		close(d.listenerDone)
+		err = d.listener.Close()
+		<-d.listenerStopped
+	})
+
+	return err
 }
@@ -2,6 +2,7 @@ package postgresdriver
 
 import (
 	"encoding/json"
+	"sync"
 
 	"github.com/lib/pq"
 	"github.com/pokt-foundation/portal-api-go/repository"
@@ -9,6 +10,7 @@ import (
 
 type ListenerMock struct {
 	Notify chan *pq.Notification
+	closed sync.Once
 }
 
 func NewListenerMock() *ListenerMock {
@@ -25,6 +27,15 @@ func (l *ListenerMock) Listen(channel string) error {
 	return nil
 }
 
+func (l *ListenerMock) Close() error {
+	l.closed.Do(func() {
+		if l.Notify != nil {
+			close(l.Notify)
+		}
+	})
+	return nil
+}
+
 func gatewaySettingsIsNull(settings repository.GatewaySettings) bool {
 	return settings.SecretKey == "" &&
 		len(settings.WhitelistOrigins) == 0 &&
@@ -76,7 +87,7 @@ func applicationInputs(mainTableAction, sideTablesAction repository.Action, cont
 				ApplicationID:   app.ID,
 				Address:         app.GatewayAAT.Address,
 				ClientPublicKey: app.GatewayAAT.ClientPublicKey,
-				PrivateKey:      app.GatewayAAT.PrivateKey,
+				PrivateKey:      app.GatewayAAT.PrivateKey.Reveal(),
 				PublicKey:       app.GatewayAAT.ApplicationPublicKey,
 				Signature:       app.GatewayAAT.ApplicationSignature,
 				Version:         app.GatewayAAT.Version,
@@ -90,7 +101,7 @@ func applicationInputs(mainTableAction, sideTablesAction repository.Action, cont
 			table:  repository.TableGatewaySettings,
 			input: dbGatewaySettingsJSON{
 				ApplicationID:        app.ID,
-				SecretKey:            app.GatewaySettings.SecretKey,
+				SecretKey:            app.GatewaySettings.SecretKey.Reveal(),
 				SecretKeyRequired:    app.GatewaySettings.SecretKeyRequired,
 				WhitelistOrigins:     app.GatewaySettings.WhitelistOrigins,
 				WhitelistUserAgents:  app.GatewaySettings.WhitelistUserAgents,
@@ -285,6 +296,22 @@ func mockInput(inStruct inputStruct) *pq.Notification {
 	}
 }
 
+func payPlanInput(action repository.Action, content repository.SavedOnDB) inputStruct {
+	payPlan := content.(*repository.PayPlan)
+
+	return inputStruct{
+		action: action,
+		table:  repository.TablePayPlans,
+		input: dbPayPlanJSON{
+			Type:          string(payPlan.Type),
+			DailyLimit:    payPlan.Limit,
+			RateLimit:     payPlan.RateLimit,
+			AllowedChains: payPlan.AllowedChains,
+			AatPlan:       string(payPlan.AatPlan),
+		},
+	}
+}
+
 func mockContent(mainTableAction, sideTablesAction repository.Action, content repository.SavedOnDB) []*pq.Notification {
 	var inputs []inputStruct
 
@@ -299,6 +326,10 @@ func mockContent(mainTableAction, sideTablesAction repository.Action, content re
 		inputs = loadBalancerInputs(mainTableAction, sideTablesAction, content)
 	case *repository.Redirect:
 		inputs = []inputStruct{redirectInput(mainTableAction, content)}
+	case *repository.LbApp:
+		inputs = []inputStruct{{action: mainTableAction, table: repository.TableLbApps, input: content}}
+	case *repository.PayPlan:
+		inputs = []inputStruct{payPlanInput(mainTableAction, content)}
 	default:
 		panic("type not supported")
 	}
@@ -5,12 +5,15 @@ import (
 	"time"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/lib/pq"
 	"github.com/pokt-foundation/portal-api-go/repository"
+	"github.com/stretchr/testify/require"
 )
 
 func TestListen(t *testing.T) {
 	testCases := []struct {
 		name                  string
+		action                repository.Action
 		content               repository.SavedOnDB
 		expectedNotifications map[repository.Table]*repository.Notification
 		wantPanic             bool
@@ -175,6 +178,64 @@ func TestListen(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "lb app",
+			content: &repository.LbApp{
+				LbID:  "123",
+				AppID: "a123",
+			},
+			expectedNotifications: map[repository.Table]*repository.Notification{
+				repository.TableLbApps: {
+					Table:  repository.TableLbApps,
+					Action: repository.ActionInsert,
+					Data: &repository.LbApp{
+						LbID:  "123",
+						AppID: "a123",
+					},
+				},
+			},
+		},
+		{
+			name:   "lb app removal",
+			action: repository.ActionDelete,
+			content: &repository.LbApp{
+				LbID:  "123",
+				AppID: "a123",
+			},
+			expectedNotifications: map[repository.Table]*repository.Notification{
+				repository.TableLbApps: {
+					Table:  repository.TableLbApps,
+					Action: repository.ActionDelete,
+					Data: &repository.LbApp{
+						LbID:  "123",
+						AppID: "a123",
+					},
+				},
+			},
+		},
+		{
+			name: "pay plan",
+			content: &repository.PayPlan{
+				Type:          repository.Enterprise,
+				Limit:         1000,
+				RateLimit:     10,
+				AllowedChains: []string{"0021"},
+				AatPlan:       repository.AatPlanPremium,
+			},
+			expectedNotifications: map[repository.Table]*repository.Notification{
+				repository.TablePayPlans: {
+					Table:  repository.TablePayPlans,
+					Action: repository.ActionInsert,
+					Data: &repository.PayPlan{
+						Type:          repository.Enterprise,
+						Limit:         1000,
+						RateLimit:     10,
+						AllowedChains: []string{"0021"},
+						AatPlan:       repository.AatPlanPremium,
+					},
+				},
+			},
+		},
 		{
 			name:      "panic",
 			content:   &repository.GatewayAAT{},
@@ -194,7 +255,11 @@ func TestListen(t *testing.T) {
 			listenerMock := NewListenerMock()
 			driver := NewPostgresDriverFromSQLDBInstance(nil, listenerMock)
 
-			listenerMock.MockEvent(repository.ActionInsert, repository.ActionUpdate, tc.content)
+			action := repository.ActionInsert
+			if tc.action != "" {
+				action = tc.action
+			}
+			listenerMock.MockEvent(action, repository.ActionUpdate, tc.content)
 
 			time.Sleep(1 * time.Second)
 			driver.CloseListener()
@@ -211,3 +276,56 @@ func TestListen(t *testing.T) {
 		})
 	}
 }
+
+func TestListenerResync(t *testing.T) {
+	c := require.New(t)
+
+	listenerMock := NewListenerMock()
+	driver := NewPostgresDriverFromSQLDBInstance(nil, listenerMock, WithNotificationDeliveryTimeout(10*time.Millisecond))
+
+	/* A reconnect signals a resync */
+	listenerMock.Notify <- nil
+	c.Equal(&repository.Notification{Action: repository.ActionResync}, <-driver.NotificationChannel())
+
+	/* Invalid notifications are reported on the error channel */
+	listenerMock.Notify <- &pq.Notification{Channel: "events", Extra: "{"}
+	listenerMock.Notify <- &pq.Notification{Channel: "events", Extra: `{"table":"audit_log","action":"INSERT","data":{}}`}
+	listenerMock.Notify <- &pq.Notification{Channel: "events", Extra: `{"table":"lb_apps","action":"TRUNCATE","data":{}}`}
+	c.Error(<-driver.ErrorChannel())
+	c.ErrorIs(<-driver.ErrorChannel(), ErrUnknownNotificationTable)
+	c.ErrorIs(<-driver.ErrorChannel(), ErrUnknownNotificationAction)
+
+	/* Notifications the consumer does not take in time are dropped and a resync is signaled */
+	lbApp := &repository.LbApp{LbID: "123", AppID: "a123"}
+	for i := 0; i < cap(driver.notification)+1; i++ {
+		listenerMock.MockEvent(repository.ActionInsert, repository.ActionInsert, lbApp)
+	}
+	c.Eventually(func() bool {
+		return driver.ListenerStats().Dropped == 1
+	}, time.Second, 5*time.Millisecond)
+
+	for i := 0; i < cap(driver.notification); i++ {
+		n := <-driver.NotificationChannel()
+		c.Equal(repository.ActionInsert, n.Action)
+	}
+	c.Equal(repository.ActionResync, (<-driver.NotificationChannel()).Action)
+
+	c.NoError(driver.CloseListener())
+	c.NoError(driver.CloseListener())
+
+	_, open := <-driver.NotificationChannel()
+	c.False(open)
+	_, open = <-driver.ErrorChannel()
+	c.False(open)
+
+	stats := driver.ListenerStats()
+	stats.Late = 0
+	c.Equal(ListenerStats{
+		Received:    uint64(cap(driver.notification) + 4),
+		Delivered:   uint64(cap(driver.notification) + 2),
+		Dropped:     1,
+		ParseErrors: 3,
+		Reconnects:  1,
+		Resyncs:     2,
+	}, stats)
+}
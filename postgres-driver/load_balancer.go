@@ -1,24 +1,25 @@
 package postgresdriver
 
 import (
+	"context"
 	"database/sql"
 	"strings"
 	"time"
 
+	"github.com/jmoiron/sqlx"
 	"github.com/lib/pq"
 	"github.com/pokt-foundation/portal-api-go/repository"
 )
 
 const (
-	selectLoadBalancers = `
+	selectLoadBalancers       = selectLoadBalancersScript + groupLoadBalancersScript
+	selectLoadBalancersScript = `
 	SELECT lb.lb_id, lb.name, lb.created_at, lb.updated_at, lb.request_timeout, lb.gigastake, lb.gigastake_redirect, lb.user_id, so.duration, so.sticky_max, so.stickiness, so.origins, STRING_AGG(la.app_id, ',') AS app_ids
 	FROM loadbalancers AS lb
 	LEFT JOIN stickiness_options AS so ON lb.lb_id=so.lb_id
-	LEFT JOIN lb_apps AS la ON lb.lb_id=la.lb_id
+	LEFT JOIN lb_apps AS la ON lb.lb_id=la.lb_id`
+	groupLoadBalancersScript = `
 	GROUP BY lb.lb_id, lb.lb_id, lb.name, lb.created_at, lb.updated_at, lb.request_timeout, lb.gigastake, lb.gigastake_redirect, lb.user_id, so.duration, so.sticky_max, so.stickiness, so.origins`
-	selectStickinessOptions = `
-	SELECT lb_id, duration, sticky_max, stickiness, origins
-	FROM stickiness_options WHERE lb_id = $1`
 	insertLoadBalancerScript = `
 	INSERT into loadbalancers (lb_id, name, user_id, request_timeout, gigastake, gigastake_redirect, created_at, updated_at)
 	VALUES (:lb_id, :name, :user_id, :request_timeout, :gigastake, :gigastake_redirect, :created_at, :updated_at)`
@@ -28,14 +29,22 @@ const (
 	insertLbAppsScript = `
 	INSERT into lb_apps (lb_id, app_id)
 	VALUES (:lb_id, :app_id)`
+	addLbAppsScript = `
+	INSERT into lb_apps (lb_id, app_id)
+	VALUES (:lb_id, :app_id)
+	ON CONFLICT DO NOTHING`
+	removeLbAppsScript = `
+	DELETE FROM lb_apps
+	WHERE lb_id = $1 AND app_id = ANY($2)`
 	updateLoadBalancer = `
 	UPDATE loadbalancers
 	SET name = COALESCE($1, name), updated_at = $2
 	WHERE lb_id = $3`
-	updateStickinessOptions = `
-	UPDATE stickiness_options
-	SET duration = :duration, sticky_max = :sticky_max, stickiness = :stickiness, origins = :origins
-	WHERE lb_id = :lb_id`
+	upsertStickinessOptionsScript = `
+	INSERT into stickiness_options (lb_id, duration, sticky_max, stickiness, origins)
+	VALUES (:lb_id, :duration, :sticky_max, :stickiness, :origins)
+	ON CONFLICT (lb_id)
+	DO UPDATE SET duration = EXCLUDED.duration, sticky_max = EXCLUDED.sticky_max, stickiness = EXCLUDED.stickiness, origins = EXCLUDED.origins`
 	removeLoadBalancer = `
 	UPDATE loadbalancers
 	SET user_id = '', updated_at = $1
@@ -177,17 +186,6 @@ func (i *insertStickinessOptions) isUpdatable() bool {
 	return i != nil
 }
 
-func (i *insertStickinessOptions) read(driver *PostgresDriver, ids []string) (updatable, error) {
-	var options insertStickinessOptions
-
-	err := driver.Get(&options, selectStickinessOptions, ids[0])
-	if err != nil {
-		return nil, err
-	}
-
-	return &options, nil
-}
-
 func convertRepositoryToDBStickinessOptions(id string, options *repository.StickyOptions) *insertStickinessOptions {
 	if options == nil {
 		return nil
@@ -232,9 +230,17 @@ func extractInsertLbApps(loadBalancer *repository.LoadBalancer) []*insertLbApps
 
 // ReadLoadBalancers returns all load balancers in the database
 func (d *PostgresDriver) ReadLoadBalancers() ([]*repository.LoadBalancer, error) {
+	return d.ReadLoadBalancersContext(context.Background())
+}
+
+// ReadLoadBalancersContext is ReadLoadBalancers bounded by the context and the driver's statement timeout
+func (d *PostgresDriver) ReadLoadBalancersContext(ctx context.Context) ([]*repository.LoadBalancer, error) {
+	ctx, cancel := d.withStatementTimeout(ctx)
+	defer cancel()
+
 	var dbLoadBalancers []*dbLoadBalancer
 
-	err := d.Select(&dbLoadBalancers, selectLoadBalancers)
+	err := d.SelectContext(ctx, &dbLoadBalancers, selectLoadBalancers)
 	if err != nil {
 		return nil, err
 	}
@@ -248,9 +254,110 @@ func (d *PostgresDriver) ReadLoadBalancers() ([]*repository.LoadBalancer, error)
 	return loadbalancers, nil
 }
 
+// ReadLoadBalancer returns the load balancer with the given ID, ErrNotFound if it does not exist
+func (d *PostgresDriver) ReadLoadBalancer(id string) (*repository.LoadBalancer, error) {
+	return d.ReadLoadBalancerContext(context.Background(), id)
+}
+
+// ReadLoadBalancerContext is ReadLoadBalancer bounded by the context and the driver's statement timeout
+func (d *PostgresDriver) ReadLoadBalancerContext(ctx context.Context, id string) (*repository.LoadBalancer, error) {
+	ctx, cancel := d.withStatementTimeout(ctx)
+	defer cancel()
+
+	if id == "" {
+		return nil, ErrMissingID
+	}
+
+	c := &conditions{}
+	c.add("lb.lb_id = ?", id)
+
+	var dbLoadBalancers []*dbLoadBalancer
+
+	err := d.SelectContext(ctx, &dbLoadBalancers, c.query(selectLoadBalancersScript, groupLoadBalancersScript), c.args...)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(dbLoadBalancers) == 0 {
+		return nil, ErrNotFound
+	}
+
+	return dbLoadBalancers[0].toLoadBalancer(), nil
+}
+
+// ReadLoadBalancersPage returns a page of the load balancers matching the filter,
+// and the cursor of the next page, empty if it is the last one
+func (d *PostgresDriver) ReadLoadBalancersPage(filter LoadBalancerFilter, page Page) ([]*repository.LoadBalancer, string, error) {
+	return d.ReadLoadBalancersPageContext(context.Background(), filter, page)
+}
+
+// ReadLoadBalancersPageContext is ReadLoadBalancersPage bounded by the context and the driver's statement timeout
+func (d *PostgresDriver) ReadLoadBalancersPageContext(ctx context.Context, filter LoadBalancerFilter, page Page) ([]*repository.LoadBalancer, string, error) {
+	ctx, cancel := d.withStatementTimeout(ctx)
+	defer cancel()
+
+	c := filter.conditions()
+	query := c.pageQuery(selectLoadBalancersScript, groupLoadBalancersScript, "lb.lb_id", page)
+
+	var dbLoadBalancers []*dbLoadBalancer
+
+	err := d.SelectContext(ctx, &dbLoadBalancers, query, c.args...)
+	if err != nil {
+		return nil, "", err
+	}
+
+	count, cursor := nextCursor(len(dbLoadBalancers), page, func(i int) string {
+		return dbLoadBalancers[i].LbID
+	})
+
+	loadBalancers := []*repository.LoadBalancer{}
+
+	for _, dbLoadBalancer := range dbLoadBalancers[:count] {
+		loadBalancers = append(loadBalancers, dbLoadBalancer.toLoadBalancer())
+	}
+
+	return loadBalancers, cursor, nil
+}
+
+// ReadLoadBalancersUpdatedSince returns all load balancers updated at or after since
+func (d *PostgresDriver) ReadLoadBalancersUpdatedSince(since time.Time) ([]*repository.LoadBalancer, error) {
+	return d.ReadLoadBalancersUpdatedSinceContext(context.Background(), since)
+}
+
+// ReadLoadBalancersUpdatedSinceContext is ReadLoadBalancersUpdatedSince bounded by the context,
+// each page read is bounded by the driver's statement timeout
+func (d *PostgresDriver) ReadLoadBalancersUpdatedSinceContext(ctx context.Context, since time.Time) ([]*repository.LoadBalancer, error) {
+	filter := LoadBalancerFilter{UpdatedSince: since}
+	page := Page{Limit: MaxPageSize}
+
+	loadBalancers := []*repository.LoadBalancer{}
+
+	for {
+		read, cursor, err := d.ReadLoadBalancersPageContext(ctx, filter, page)
+		if err != nil {
+			return nil, err
+		}
+
+		loadBalancers = append(loadBalancers, read...)
+
+		if cursor == "" {
+			return loadBalancers, nil
+		}
+		page.Cursor = cursor
+	}
+}
+
 // WriteLoadBalancer saves input load balancer in the database
 // Does not save stickiness configuration
 func (d *PostgresDriver) WriteLoadBalancer(loadBalancer *repository.LoadBalancer) (*repository.LoadBalancer, error) {
+	return d.WriteLoadBalancerContext(context.Background(), loadBalancer)
+}
+
+// WriteLoadBalancerContext is WriteLoadBalancer bounded by the context and the driver's statement timeout
+func (d *PostgresDriver) WriteLoadBalancerContext(ctx context.Context, loadBalancer *repository.LoadBalancer) (*repository.LoadBalancer, error) {
+	ctx, cancel := d.withStatementTimeout(ctx)
+	defer cancel()
+
 	id, err := generateRandomID()
 	if err != nil {
 		return nil, err
@@ -270,37 +377,43 @@ func (d *PostgresDriver) WriteLoadBalancer(loadBalancer *repository.LoadBalancer
 
 	insertsLbApps := extractInsertLbApps(loadBalancer)
 
-	tx, err := d.Beginx()
-	if err != nil {
-		return nil, err
-	}
+	err = d.withTx(ctx, func(tx *sqlx.Tx) error {
+		_, err := tx.NamedExecContext(ctx, insertLoadBalancerScript, insertLoadBalancer)
+		if err != nil {
+			return err
+		}
 
-	_, err = tx.NamedExec(insertLoadBalancerScript, insertLoadBalancer)
-	if err != nil {
-		return nil, err
-	}
+		err = insertNullables(ctx, tx, nullables, nullablesScripts)
+		if err != nil {
+			return err
+		}
 
-	for i := 0; i < len(nullables); i++ {
-		if nullables[i].isNotNull() {
-			_, err = tx.NamedExec(nullablesScripts[i], nullables[i])
+		for _, insert := range insertsLbApps {
+			_, err = tx.NamedExecContext(ctx, insertLbAppsScript, insert)
 			if err != nil {
-				return nil, err
+				return err
 			}
 		}
-	}
 
-	for _, insert := range insertsLbApps {
-		_, err = tx.NamedExec(insertLbAppsScript, insert)
-		if err != nil {
-			return nil, err
-		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	return loadBalancer, tx.Commit()
+	return loadBalancer, nil
 }
 
-// UpdateLoadBalancer updates fields available in options in db
+// UpdateLoadBalancer updates fields available in options in db, recording the changes in the audit log
 func (d *PostgresDriver) UpdateLoadBalancer(id string, fieldsToUpdate *repository.UpdateLoadBalancer) error {
+	return d.UpdateLoadBalancerContext(context.Background(), id, fieldsToUpdate)
+}
+
+// UpdateLoadBalancerContext is UpdateLoadBalancer bounded by the context and the driver's statement timeout
+func (d *PostgresDriver) UpdateLoadBalancerContext(ctx context.Context, id string, fieldsToUpdate *repository.UpdateLoadBalancer) error {
+	ctx, cancel := d.withStatementTimeout(ctx)
+	defer cancel()
+
 	if id == "" {
 		return ErrMissingID
 	}
@@ -309,44 +422,105 @@ func (d *PostgresDriver) UpdateLoadBalancer(id string, fieldsToUpdate *repositor
 		return ErrNoFieldsToUpdate
 	}
 
-	tx, err := d.Beginx()
-	if err != nil {
-		return err
-	}
-
-	_, err = tx.Exec(updateLoadBalancer, newSQLNullString(fieldsToUpdate.Name), time.Now(), id)
-	if err != nil {
-		return err
-	}
-
 	updates := []*update{}
 
 	updates = append(updates, &update{
-		insertScript: insertStickinessOptionsScript,
-		updateScript: updateStickinessOptions,
+		upsertScript: upsertStickinessOptionsScript,
 		toUpdate:     convertRepositoryToDBStickinessOptions(id, fieldsToUpdate.StickyOptions),
+		audited:      loadBalancerRow("stickiness_options", id),
 	})
 
-	for _, update := range updates {
-		err = d.doUpdate(id, update, tx)
+	rows := append([]auditedRow{loadBalancerRow("loadbalancers", id)}, auditedRows(updates)...)
+
+	return d.withTx(ctx, func(tx *sqlx.Tx) error {
+		return audited(ctx, tx, d.secrets, auditUpdateLoadBalancer, id, rows, func() error {
+			err := rowsFound(tx.ExecContext(ctx, updateLoadBalancer, newSQLNullString(fieldsToUpdate.Name), time.Now(), id))
+			if err != nil {
+				return err
+			}
+
+			return doUpdates(ctx, tx, updates)
+		})
+	})
+}
+
+// UpdateLoadBalancer updates fields available in options in db
+func (d *PostgresDriver) RemoveLoadBalancer(id string) error {
+	return d.RemoveLoadBalancerContext(context.Background(), id)
+}
+
+// RemoveLoadBalancerContext is RemoveLoadBalancer bounded by the context and the driver's statement timeout
+func (d *PostgresDriver) RemoveLoadBalancerContext(ctx context.Context, id string) error {
+	ctx, cancel := d.withStatementTimeout(ctx)
+	defer cancel()
+
+	if id == "" {
+		return ErrMissingID
+	}
+
+	return rowsFound(d.ExecContext(ctx, removeLoadBalancer, time.Now(), id))
+}
+
+// AddLbApps adds the applications to the load balancer, applications already in the load balancer are ignored
+func (d *PostgresDriver) AddLbApps(id string, appIDs []string) error {
+	return d.AddLbAppsContext(context.Background(), id, appIDs)
+}
+
+// AddLbAppsContext is AddLbApps bounded by the context and the driver's statement timeout
+func (d *PostgresDriver) AddLbAppsContext(ctx context.Context, id string, appIDs []string) error {
+	ctx, cancel := d.withStatementTimeout(ctx)
+	defer cancel()
+
+	if id == "" {
+		return ErrMissingID
+	}
+
+	if len(appIDs) == 0 {
+		return ErrMissingApplicationIDs
+	}
+
+	return d.withTx(ctx, func(tx *sqlx.Tx) error {
+		err := rowsFound(tx.ExecContext(ctx, updateLoadBalancer, sql.NullString{}, time.Now(), id))
 		if err != nil {
 			return err
 		}
-	}
 
-	return tx.Commit()
+		for _, insert := range extractInsertLbApps(&repository.LoadBalancer{ID: id, ApplicationIDs: appIDs}) {
+			_, err = tx.NamedExecContext(ctx, addLbAppsScript, insert)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
 }
 
-// UpdateLoadBalancer updates fields available in options in db
-func (d *PostgresDriver) RemoveLoadBalancer(id string) error {
+// RemoveLbApps removes the applications from the load balancer
+func (d *PostgresDriver) RemoveLbApps(id string, appIDs []string) error {
+	return d.RemoveLbAppsContext(context.Background(), id, appIDs)
+}
+
+// RemoveLbAppsContext is RemoveLbApps bounded by the context and the driver's statement timeout
+func (d *PostgresDriver) RemoveLbAppsContext(ctx context.Context, id string, appIDs []string) error {
+	ctx, cancel := d.withStatementTimeout(ctx)
+	defer cancel()
+
 	if id == "" {
 		return ErrMissingID
 	}
 
-	_, err := d.Exec(removeLoadBalancer, time.Now(), id)
-	if err != nil {
-		return err
+	if len(appIDs) == 0 {
+		return ErrMissingApplicationIDs
 	}
 
-	return nil
+	return d.withTx(ctx, func(tx *sqlx.Tx) error {
+		err := rowsFound(tx.ExecContext(ctx, updateLoadBalancer, sql.NullString{}, time.Now(), id))
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, removeLbAppsScript, id, pq.StringArray(appIDs))
+		return err
+	})
 }
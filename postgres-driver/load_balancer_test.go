@@ -40,6 +40,65 @@ func TestPostgresDriver_ReadLoadbalancers(t *testing.T) {
 	c.Empty(loadbalancer)
 }
 
+func TestPostgresDriver_ReadLoadBalancer(t *testing.T) {
+	c := require.New(t)
+
+	db, mock, err := sqlmock.New()
+	c.NoError(err)
+
+	defer db.Close()
+
+	rows := sqlmock.NewRows([]string{"lb_id", "name", "app_ids"}).
+		AddRow("60e517ea76cfec00352bcdad", "wawawa", "6107ef92825e090034dce25f,6107ef92825e090034dce260")
+
+	mock.ExpectQuery("^SELECT (.+) FROM loadbalancers (.+) WHERE lb.lb_id = (.+) GROUP BY").
+		WithArgs("60e517ea76cfec00352bcdad").WillReturnRows(rows)
+
+	driver := NewPostgresDriverFromSQLDBInstance(db, &ListenerMock{})
+
+	loadBalancer, err := driver.ReadLoadBalancer("60e517ea76cfec00352bcdad")
+	c.NoError(err)
+	c.Equal("wawawa", loadBalancer.Name)
+	c.Equal([]string{"6107ef92825e090034dce25f", "6107ef92825e090034dce260"}, loadBalancer.ApplicationIDs)
+
+	mock.ExpectQuery("^SELECT (.+) FROM loadbalancers").WithArgs("not-an-id").
+		WillReturnRows(sqlmock.NewRows([]string{"lb_id"}))
+
+	_, err = driver.ReadLoadBalancer("not-an-id")
+	c.Equal(ErrNotFound, err)
+
+	_, err = driver.ReadLoadBalancer("")
+	c.Equal(ErrMissingID, err)
+}
+
+func TestPostgresDriver_ReadLoadBalancersPage(t *testing.T) {
+	c := require.New(t)
+
+	db, mock, err := sqlmock.New()
+	c.NoError(err)
+
+	defer db.Close()
+
+	rows := sqlmock.NewRows([]string{"lb_id", "name"}).
+		AddRow("1", "wawawa")
+
+	mock.ExpectQuery("^SELECT (.+) WHERE lb.lb_id IN \\(SELECT lb_id FROM lb_apps WHERE app_id = (.+)\\) GROUP BY (.+) ORDER BY lb.lb_id LIMIT").
+		WithArgs("6107ef92825e090034dce25f", DefaultPageSize+1).WillReturnRows(rows)
+
+	driver := NewPostgresDriverFromSQLDBInstance(db, &ListenerMock{})
+
+	loadBalancers, cursor, err := driver.ReadLoadBalancersPage(LoadBalancerFilter{ApplicationID: "6107ef92825e090034dce25f"}, Page{})
+	c.NoError(err)
+	c.Len(loadBalancers, 1)
+	c.Empty(cursor)
+
+	mock.ExpectQuery("^SELECT (.+) FROM loadbalancers").WillReturnError(errors.New("dummy error"))
+
+	loadBalancers, _, err = driver.ReadLoadBalancersPage(LoadBalancerFilter{}, Page{})
+	c.EqualError(err, "dummy error")
+	c.Empty(loadBalancers)
+}
+
 func TestPostgresDriver_WriteLoadBalancer(t *testing.T) {
 	c := require.New(t)
 
@@ -89,6 +148,8 @@ func TestPostgresDriver_WriteLoadBalancer(t *testing.T) {
 		"yes", "60e85042bf95f5003559b791", sql.NullInt32{}, false, false, sqlmock.AnyArg(), sqlmock.AnyArg()).
 		WillReturnError(errors.New("error in loadbalancers"))
 
+	mock.ExpectRollback()
+
 	loadBalancer, err = driver.WriteLoadBalancer(&repository.LoadBalancer{
 		ID:             "60ddc61b6e29c3003378361D",
 		Name:           "yes",
@@ -108,6 +169,8 @@ func TestPostgresDriver_WriteLoadBalancer(t *testing.T) {
 		"21", 21, true, pq.StringArray([]string{"pjog"})).
 		WillReturnError(errors.New("error in stickiness options"))
 
+	mock.ExpectRollback()
+
 	loadBalancer, err = driver.WriteLoadBalancer(&repository.LoadBalancer{
 		ID:             "60ddc61b6e29c3003378361D",
 		Name:           "yes",
@@ -132,6 +195,8 @@ func TestPostgresDriver_WriteLoadBalancer(t *testing.T) {
 	mock.ExpectExec("INSERT into lb_apps").WithArgs(sqlmock.AnyArg(), "61eae7640ae317bbc6c36dbb").
 		WillReturnError(errors.New("error in lb_apps"))
 
+	mock.ExpectRollback()
+
 	loadBalancer, err = driver.WriteLoadBalancer(&repository.LoadBalancer{
 		ID:             "60ddc61b6e29c3003378361D",
 		Name:           "yes",
@@ -153,19 +218,18 @@ func TestPostgresDriver_UpdateLoadBalancer(t *testing.T) {
 	driver := NewPostgresDriverFromSQLDBInstance(db, &ListenerMock{})
 
 	mock.ExpectBegin()
+	expectAuditSnapshots(mock, "loadbalancers", "stickiness_options")
 
 	mock.ExpectExec("UPDATE loadbalancers").WithArgs("rochy", sqlmock.AnyArg(),
 		"60ddc61b6e29c3003378361D").
 		WillReturnResult(sqlmock.NewResult(1, 1))
 
-	mock.ExpectQuery("^SELECT (.+) FROM stickiness_options (.+)").WillReturnRows(sqlmock.NewRows([]string{"lb_id", "duration", "sticky_max", "stickiness", "origins"}).
-		AddRow("60ddc61b6e29c3003378361D",
-			"22", 22, true, pq.StringArray([]string{"rvn"})))
-
-	mock.ExpectExec("UPDATE stickiness_options").WithArgs("21", 21, true,
-		pq.StringArray([]string{"pjog"}), "60ddc61b6e29c3003378361D").
+	mock.ExpectExec("INSERT into stickiness_options (.+) ON CONFLICT").WithArgs("60ddc61b6e29c3003378361D",
+		"21", 21, true, pq.StringArray([]string{"pjog"})).
 		WillReturnResult(sqlmock.NewResult(1, 1))
 
+	expectAuditSnapshots(mock, "loadbalancers", "stickiness_options")
+
 	mock.ExpectCommit()
 
 	err = driver.UpdateLoadBalancer("60ddc61b6e29c3003378361D", &repository.UpdateLoadBalancer{
@@ -180,48 +244,61 @@ func TestPostgresDriver_UpdateLoadBalancer(t *testing.T) {
 	c.NoError(err)
 
 	mock.ExpectBegin()
+	expectAuditSnapshots(mock, "loadbalancers")
 
 	mock.ExpectExec("UPDATE loadbalancers").WithArgs("rochy", sqlmock.AnyArg(),
 		"60ddc61b6e29c3003378361D").
 		WillReturnResult(sqlmock.NewResult(1, 1))
 
-	mock.ExpectQuery("^SELECT (.+) FROM stickiness_options (.+)").WillReturnRows(sqlmock.NewRows(nil))
-
-	mock.ExpectExec("INSERT into stickiness_options").WithArgs("60ddc61b6e29c3003378361D",
-		"21", 21, true, pq.StringArray([]string{"pjog"})).
-		WillReturnResult(sqlmock.NewResult(1, 1))
+	expectAuditSnapshots(mock, "loadbalancers")
 
 	mock.ExpectCommit()
 
 	err = driver.UpdateLoadBalancer("60ddc61b6e29c3003378361D", &repository.UpdateLoadBalancer{
 		Name: "rochy",
-		StickyOptions: &repository.StickyOptions{
-			Duration:      "21",
-			StickyOrigins: []string{"pjog"},
-			StickyMax:     21,
-			Stickiness:    true,
-		},
 	})
 	c.NoError(err)
 
 	mock.ExpectBegin()
+	expectAuditSnapshots(mock, "loadbalancers")
 
 	mock.ExpectExec("UPDATE loadbalancers").WithArgs("rochy", sqlmock.AnyArg(),
 		"60ddc61b6e29c3003378361D").
 		WillReturnError(errors.New("error load balancers"))
 
+	mock.ExpectRollback()
+
 	err = driver.UpdateLoadBalancer("60ddc61b6e29c3003378361D", &repository.UpdateLoadBalancer{
 		Name: "rochy",
 	})
 	c.EqualError(err, "error load balancers")
 
+	/* Updating a missing load balancer records no change */
+	mock.ExpectBegin()
+	expectAuditSnapshots(mock, "loadbalancers")
+
+	mock.ExpectExec("UPDATE loadbalancers").WithArgs("rochy", sqlmock.AnyArg(), "missing").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	mock.ExpectRollback()
+
+	err = driver.UpdateLoadBalancer("missing", &repository.UpdateLoadBalancer{
+		Name: "rochy",
+	})
+	c.ErrorIs(err, ErrNotFound)
+
 	mock.ExpectBegin()
+	expectAuditSnapshots(mock, "loadbalancers", "stickiness_options")
 
 	mock.ExpectExec("UPDATE loadbalancers").WithArgs("rochy", sqlmock.AnyArg(),
 		"60ddc61b6e29c3003378361D").
 		WillReturnResult(sqlmock.NewResult(1, 1))
 
-	mock.ExpectQuery("^SELECT (.+) FROM stickiness_options (.+)").WillReturnError(errors.New("error reading options"))
+	mock.ExpectExec("INSERT into stickiness_options (.+) ON CONFLICT").WithArgs("60ddc61b6e29c3003378361D",
+		"21", 21, true, pq.StringArray([]string{"pjog"})).
+		WillReturnError(errors.New("error in stickiness options"))
+
+	mock.ExpectRollback()
 
 	err = driver.UpdateLoadBalancer("60ddc61b6e29c3003378361D", &repository.UpdateLoadBalancer{
 		Name: "rochy",
@@ -232,7 +309,7 @@ func TestPostgresDriver_UpdateLoadBalancer(t *testing.T) {
 			Stickiness:    true,
 		},
 	})
-	c.EqualError(err, "error reading options")
+	c.EqualError(err, "error in stickiness options")
 
 	err = driver.UpdateLoadBalancer("60ddc61b6e29c3003378361D", nil)
 	c.Equal(ErrNoFieldsToUpdate, err)
@@ -263,6 +340,103 @@ func TestPostgresDriver_RemoveLoadBalancer(t *testing.T) {
 	err = driver.RemoveLoadBalancer("not-an-id")
 	c.EqualError(err, "dummy error")
 
+	mock.ExpectExec("UPDATE loadbalancers").WithArgs(sqlmock.AnyArg(), "missing").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	err = driver.RemoveLoadBalancer("missing")
+	c.ErrorIs(err, ErrNotFound)
+
 	err = driver.RemoveLoadBalancer("")
 	c.Equal(ErrMissingID, err)
 }
+
+func TestPostgresDriver_AddLbApps(t *testing.T) {
+	c := require.New(t)
+
+	db, mock, err := sqlmock.New()
+	c.NoError(err)
+
+	defer db.Close()
+
+	driver := NewPostgresDriverFromSQLDBInstance(db, &ListenerMock{})
+
+	mock.ExpectBegin()
+
+	mock.ExpectExec("UPDATE loadbalancers").WithArgs(sql.NullString{}, sqlmock.AnyArg(),
+		"60ddc61b6e29c3003378361D").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	mock.ExpectExec("INSERT into lb_apps (.+) ON CONFLICT DO NOTHING").WithArgs("60ddc61b6e29c3003378361D", "61eae7640ae317bbc6c36dbb").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	mock.ExpectExec("INSERT into lb_apps (.+) ON CONFLICT DO NOTHING").WithArgs("60ddc61b6e29c3003378361D", "61eae7640ae317bbc6c36dba").
+		WillReturnResult(sqlmock.NewResult(1, 0))
+
+	mock.ExpectCommit()
+
+	err = driver.AddLbApps("60ddc61b6e29c3003378361D", []string{"61eae7640ae317bbc6c36dbb", "61eae7640ae317bbc6c36dba"})
+	c.NoError(err)
+
+	mock.ExpectBegin()
+
+	mock.ExpectExec("UPDATE loadbalancers").WithArgs(sql.NullString{}, sqlmock.AnyArg(),
+		"60ddc61b6e29c3003378361D").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	mock.ExpectExec("INSERT into lb_apps").WithArgs("60ddc61b6e29c3003378361D", "not-an-app").
+		WillReturnError(errors.New("error in lb_apps"))
+
+	mock.ExpectRollback()
+
+	err = driver.AddLbApps("60ddc61b6e29c3003378361D", []string{"not-an-app"})
+	c.EqualError(err, "error in lb_apps")
+
+	err = driver.AddLbApps("60ddc61b6e29c3003378361D", nil)
+	c.Equal(ErrMissingApplicationIDs, err)
+
+	err = driver.AddLbApps("", []string{"61eae7640ae317bbc6c36dbb"})
+	c.Equal(ErrMissingID, err)
+}
+
+func TestPostgresDriver_RemoveLbApps(t *testing.T) {
+	c := require.New(t)
+
+	db, mock, err := sqlmock.New()
+	c.NoError(err)
+
+	defer db.Close()
+
+	driver := NewPostgresDriverFromSQLDBInstance(db, &ListenerMock{})
+
+	mock.ExpectBegin()
+
+	mock.ExpectExec("UPDATE loadbalancers").WithArgs(sql.NullString{}, sqlmock.AnyArg(),
+		"60ddc61b6e29c3003378361D").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	mock.ExpectExec("DELETE FROM lb_apps").WithArgs("60ddc61b6e29c3003378361D",
+		pq.StringArray([]string{"61eae7640ae317bbc6c36dbb", "61eae7640ae317bbc6c36dba"})).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+
+	mock.ExpectCommit()
+
+	err = driver.RemoveLbApps("60ddc61b6e29c3003378361D", []string{"61eae7640ae317bbc6c36dbb", "61eae7640ae317bbc6c36dba"})
+	c.NoError(err)
+
+	mock.ExpectBegin()
+
+	mock.ExpectExec("UPDATE loadbalancers").WithArgs(sql.NullString{}, sqlmock.AnyArg(),
+		"60ddc61b6e29c3003378361D").
+		WillReturnError(errors.New("error load balancers"))
+
+	mock.ExpectRollback()
+
+	err = driver.RemoveLbApps("60ddc61b6e29c3003378361D", []string{"61eae7640ae317bbc6c36dbb"})
+	c.EqualError(err, "error load balancers")
+
+	err = driver.RemoveLbApps("60ddc61b6e29c3003378361D", []string{})
+	c.Equal(ErrMissingApplicationIDs, err)
+
+	err = driver.RemoveLbApps("", []string{"61eae7640ae317bbc6c36dbb"})
+	c.Equal(ErrMissingID, err)
+}
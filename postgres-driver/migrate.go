@@ -0,0 +1,261 @@
+package postgresdriver
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	// migrationsLockID identifies the advisory lock held while migrating, so concurrent runners apply migrations once
+	migrationsLockID = 7238301
+
+	createMigrationsTableScript = `
+	CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INT PRIMARY KEY,
+		name VARCHAR NOT NULL,
+		applied_at TIMESTAMP NOT NULL DEFAULT NOW()
+	)`
+	lockMigrationsScript         = `SELECT pg_advisory_xact_lock($1)`
+	selectMigrationVersionScript = `SELECT COALESCE(MAX(version), 0) FROM schema_migrations`
+	insertMigrationScript        = `INSERT INTO schema_migrations (version, name) VALUES ($1, $2)`
+	removeMigrationScript        = `DELETE FROM schema_migrations WHERE version = $1`
+)
+
+var (
+	//go:embed migrations/*.sql
+	migrationFiles embed.FS
+
+	// migrationFileName matches files named as: <version>_<name>.<up|down>.sql
+	migrationFileName = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+	// ErrInvalidMigration error when the embedded migrations are not a valid sequence of up and down scripts
+	ErrInvalidMigration = errors.New("invalid migration")
+	// ErrUnknownMigrationVersion error when the database is at a version without a matching migration
+	ErrUnknownMigrationVersion = errors.New("unknown migration version")
+)
+
+// Migration is a versioned change of the database schema
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Migrations returns the migrations embedded in the driver, sorted by version
+func Migrations() ([]Migration, error) {
+	return loadMigrations(migrationFiles, "migrations")
+}
+
+func loadMigrations(files fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(files, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int]*Migration)
+
+	for _, entry := range entries {
+		match := migrationFileName.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("%w: unexpected file %s", ErrInvalidMigration, entry.Name())
+		}
+
+		version, err := strconv.Atoi(match[1])
+		if err != nil || version == 0 {
+			return nil, fmt.Errorf("%w: invalid version in %s", ErrInvalidMigration, entry.Name())
+		}
+
+		script, err := fs.ReadFile(files, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		}
+		if m.Name != match[2] {
+			return nil, fmt.Errorf("%w: version %d is used by %s and %s", ErrInvalidMigration, version, m.Name, match[2])
+		}
+
+		if match[3] == "up" {
+			m.Up = string(script)
+		} else {
+			m.Down = string(script)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" || m.Down == "" {
+			return nil, fmt.Errorf("%w: version %d needs both an up and a down script", ErrInvalidMigration, m.Version)
+		}
+		migrations = append(migrations, *m)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+// Migrator applies and reverts the embedded migrations, recording the applied versions in the schema_migrations table
+type Migrator struct {
+	db         *sqlx.DB
+	migrations []Migration
+}
+
+// NewMigrator returns a Migrator of the embedded migrations for the database
+func NewMigrator(db *sql.DB) (*Migrator, error) {
+	migrations, err := Migrations()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Migrator{
+		db:         sqlx.NewDb(db, "postgres"),
+		migrations: migrations,
+	}, nil
+}
+
+// Version returns the version of the last migration applied, 0 if none was applied
+func (m *Migrator) Version(ctx context.Context) (int, error) {
+	var version int
+
+	err := runInTx(ctx, m.db, func(tx *sqlx.Tx) error {
+		var err error
+		version, err = lockMigrations(ctx, tx)
+		return err
+	})
+
+	return version, err
+}
+
+// Up applies all pending migrations, each in its own transaction, and returns how many were applied
+func (m *Migrator) Up(ctx context.Context) (int, error) {
+	applied := 0
+
+	for {
+		done := false
+
+		err := runInTx(ctx, m.db, func(tx *sqlx.Tx) error {
+			version, err := lockMigrations(ctx, tx)
+			if err != nil {
+				return err
+			}
+
+			next, ok := m.next(version)
+			if !ok {
+				done = true
+				return nil
+			}
+
+			if _, err := tx.ExecContext(ctx, next.Up); err != nil {
+				return fmt.Errorf("applying migration %d_%s: %w", next.Version, next.Name, err)
+			}
+
+			_, err = tx.ExecContext(ctx, insertMigrationScript, next.Version, next.Name)
+			return err
+		})
+		if err != nil {
+			return applied, err
+		}
+		if done {
+			return applied, nil
+		}
+
+		applied++
+	}
+}
+
+// Down reverts up to the given number of migrations, latest first, and returns how many were reverted
+func (m *Migrator) Down(ctx context.Context, steps int) (int, error) {
+	reverted := 0
+
+	for reverted < steps {
+		done := false
+
+		err := runInTx(ctx, m.db, func(tx *sqlx.Tx) error {
+			version, err := lockMigrations(ctx, tx)
+			if err != nil {
+				return err
+			}
+
+			if version == 0 {
+				done = true
+				return nil
+			}
+
+			current, ok := m.find(version)
+			if !ok {
+				return fmt.Errorf("%w: %d", ErrUnknownMigrationVersion, version)
+			}
+
+			if _, err := tx.ExecContext(ctx, current.Down); err != nil {
+				return fmt.Errorf("reverting migration %d_%s: %w", current.Version, current.Name, err)
+			}
+
+			_, err = tx.ExecContext(ctx, removeMigrationScript, current.Version)
+			return err
+		})
+		if err != nil {
+			return reverted, err
+		}
+		if done {
+			break
+		}
+
+		reverted++
+	}
+
+	return reverted, nil
+}
+
+// lockMigrations takes the migrations lock for the transaction and returns the current version
+func lockMigrations(ctx context.Context, tx *sqlx.Tx) (int, error) {
+	if _, err := tx.ExecContext(ctx, lockMigrationsScript, migrationsLockID); err != nil {
+		return 0, err
+	}
+
+	if _, err := tx.ExecContext(ctx, createMigrationsTableScript); err != nil {
+		return 0, err
+	}
+
+	var version int
+	err := tx.GetContext(ctx, &version, selectMigrationVersionScript)
+
+	return version, err
+}
+
+func (m *Migrator) next(version int) (Migration, bool) {
+	for _, migration := range m.migrations {
+		if migration.Version > version {
+			return migration, true
+		}
+	}
+
+	return Migration{}, false
+}
+
+func (m *Migrator) find(version int) (Migration, bool) {
+	for _, migration := range m.migrations {
+		if migration.Version == version {
+			return migration, true
+		}
+	}
+
+	return Migration{}, false
+}
@@ -0,0 +1,201 @@
+package postgresdriver
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"testing/fstest"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigrations(t *testing.T) {
+	c := require.New(t)
+
+	migrations, err := Migrations()
+	c.NoError(err)
+	c.Len(migrations, 6)
+
+	c.Equal(1, migrations[0].Version)
+	c.Equal("create_tables", migrations[0].Name)
+	c.Contains(migrations[0].Up, "CREATE TABLE applications")
+	c.Contains(migrations[0].Down, "DROP TABLE")
+
+	c.Equal(2, migrations[1].Version)
+	c.Equal("notify_events", migrations[1].Name)
+	c.Contains(migrations[1].Up, "pg_notify")
+
+	c.Equal(3, migrations[2].Version)
+	c.Equal("audit_log", migrations[2].Name)
+	c.Contains(migrations[2].Up, "CREATE TABLE audit_log")
+
+	c.Equal(4, migrations[3].Version)
+	c.Equal("pay_plan_policies", migrations[3].Name)
+	c.Contains(migrations[3].Up, "ALTER TABLE pay_plans")
+
+	c.Equal(5, migrations[4].Version)
+	c.Equal("nullable_app_pay_plan", migrations[4].Name)
+	c.Contains(migrations[4].Up, "DROP NOT NULL")
+
+	c.Equal(6, migrations[5].Version)
+	c.Equal("notify_pay_plans", migrations[5].Name)
+	c.Contains(migrations[5].Up, "CREATE TRIGGER pay_plans_notify_event")
+}
+
+func TestLoadMigrations(t *testing.T) {
+	testCases := []struct {
+		name          string
+		files         fstest.MapFS
+		expectedCount int
+		expectedErr   error
+	}{
+		{
+			name: "Migrations are sorted by version",
+			files: fstest.MapFS{
+				"m/0010_second.up.sql":   {Data: []byte("up")},
+				"m/0010_second.down.sql": {Data: []byte("down")},
+				"m/0002_first.up.sql":    {Data: []byte("up")},
+				"m/0002_first.down.sql":  {Data: []byte("down")},
+			},
+			expectedCount: 2,
+		},
+		{
+			name: "Missing down script",
+			files: fstest.MapFS{
+				"m/0001_first.up.sql": {Data: []byte("up")},
+			},
+			expectedErr: ErrInvalidMigration,
+		},
+		{
+			name: "Duplicated version",
+			files: fstest.MapFS{
+				"m/0001_first.up.sql":    {Data: []byte("up")},
+				"m/0001_first.down.sql":  {Data: []byte("down")},
+				"m/0001_second.up.sql":   {Data: []byte("up")},
+				"m/0001_second.down.sql": {Data: []byte("down")},
+			},
+			expectedErr: ErrInvalidMigration,
+		},
+		{
+			name: "Unexpected file name",
+			files: fstest.MapFS{
+				"m/first.sql": {Data: []byte("up")},
+			},
+			expectedErr: ErrInvalidMigration,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := require.New(t)
+
+			migrations, err := loadMigrations(tc.files, "m")
+			if tc.expectedErr != nil {
+				c.True(errors.Is(err, tc.expectedErr))
+				return
+			}
+
+			c.NoError(err)
+			c.Len(migrations, tc.expectedCount)
+			c.Equal(2, migrations[0].Version)
+			c.Equal(10, migrations[1].Version)
+		})
+	}
+}
+
+func expectMigrationVersion(mock sqlmock.Sqlmock, version int) {
+	mock.ExpectBegin()
+	mock.ExpectExec("SELECT pg_advisory_xact_lock").WithArgs(migrationsLockID).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("CREATE TABLE IF NOT EXISTS schema_migrations").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT COALESCE").
+		WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(version))
+}
+
+func TestMigrator(t *testing.T) {
+	c := require.New(t)
+
+	db, mock, err := sqlmock.New()
+	c.NoError(err)
+
+	defer db.Close()
+
+	migrator, err := NewMigrator(db)
+	c.NoError(err)
+
+	ctx := context.Background()
+
+	/* Up applies every pending migration */
+	expectMigrationVersion(mock, 1)
+	mock.ExpectExec("CREATE FUNCTION notify_event").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("INSERT INTO schema_migrations").WithArgs(2, "notify_events").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+	expectMigrationVersion(mock, 2)
+	mock.ExpectExec("CREATE TABLE audit_log").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("INSERT INTO schema_migrations").WithArgs(3, "audit_log").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+	expectMigrationVersion(mock, 3)
+	mock.ExpectExec("ALTER TABLE pay_plans").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("INSERT INTO schema_migrations").WithArgs(4, "pay_plan_policies").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+	expectMigrationVersion(mock, 4)
+	mock.ExpectExec("ALTER TABLE app_limits").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("INSERT INTO schema_migrations").WithArgs(5, "nullable_app_pay_plan").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+	expectMigrationVersion(mock, 5)
+	mock.ExpectExec("CREATE TRIGGER pay_plans_notify_event").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("INSERT INTO schema_migrations").WithArgs(6, "notify_pay_plans").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+	expectMigrationVersion(mock, 6)
+	mock.ExpectCommit()
+
+	applied, err := migrator.Up(ctx)
+	c.NoError(err)
+	c.Equal(5, applied)
+
+	/* Version reads the last applied migration */
+	expectMigrationVersion(mock, 6)
+	mock.ExpectCommit()
+
+	version, err := migrator.Version(ctx)
+	c.NoError(err)
+	c.Equal(6, version)
+
+	/* Down reverts the latest migrations, stopping at the first one */
+	expectMigrationVersion(mock, 1)
+	mock.ExpectExec("DROP TABLE").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("DELETE FROM schema_migrations").WithArgs(1).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+	expectMigrationVersion(mock, 0)
+	mock.ExpectCommit()
+
+	reverted, err := migrator.Down(ctx, 5)
+	c.NoError(err)
+	c.Equal(1, reverted)
+
+	/* Failed migrations are rolled back */
+	expectMigrationVersion(mock, 0)
+	mock.ExpectExec("CREATE TABLE").WillReturnError(errors.New("error"))
+	mock.ExpectRollback()
+
+	applied, err = migrator.Up(ctx)
+	c.EqualError(err, "applying migration 1_create_tables: error")
+	c.Zero(applied)
+
+	/* Unknown versions can not be reverted */
+	expectMigrationVersion(mock, 9)
+	mock.ExpectRollback()
+
+	_, err = migrator.Down(ctx, 1)
+	c.True(errors.Is(err, ErrUnknownMigrationVersion))
+
+	c.NoError(mock.ExpectationsWereMet())
+}
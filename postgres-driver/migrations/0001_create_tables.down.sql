@@ -0,0 +1,14 @@
+DROP TABLE redirects;
+DROP TABLE sync_check_options;
+DROP TABLE blockchains;
+DROP TABLE lb_apps;
+DROP TABLE stickiness_options;
+DROP TABLE loadbalancers;
+DROP TABLE notification_settings;
+DROP TABLE whitelist_methods;
+DROP TABLE whitelist_contracts;
+DROP TABLE gateway_settings;
+DROP TABLE gateway_aat;
+DROP TABLE app_limits;
+DROP TABLE applications;
+DROP TABLE pay_plans;
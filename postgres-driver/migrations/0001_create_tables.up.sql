@@ -0,0 +1,138 @@
+CREATE TABLE pay_plans (
+	plan_type VARCHAR PRIMARY KEY,
+	daily_limit INT NOT NULL
+);
+
+INSERT INTO pay_plans (plan_type, daily_limit)
+VALUES ('TEST_PLAN_V0', 100),
+	('TEST_PLAN_10K', 10000),
+	('TEST_PLAN_90K', 90000),
+	('FREETIER_V0', 250000),
+	('PAY_AS_YOU_GO_V0', 0),
+	('ENTERPRISE', 0);
+
+CREATE TABLE applications (
+	application_id VARCHAR PRIMARY KEY,
+	user_id VARCHAR,
+	name VARCHAR,
+	contact_email VARCHAR,
+	description VARCHAR,
+	owner VARCHAR,
+	url VARCHAR,
+	status VARCHAR,
+	dummy BOOLEAN NOT NULL DEFAULT FALSE,
+	first_date_surpassed TIMESTAMP,
+	created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+	updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE app_limits (
+	application_id VARCHAR PRIMARY KEY REFERENCES applications(application_id),
+	pay_plan VARCHAR NOT NULL REFERENCES pay_plans(plan_type),
+	custom_limit INT
+);
+
+CREATE TABLE gateway_aat (
+	application_id VARCHAR PRIMARY KEY REFERENCES applications(application_id),
+	address VARCHAR,
+	client_public_key VARCHAR,
+	private_key VARCHAR,
+	public_key VARCHAR,
+	signature VARCHAR,
+	version VARCHAR
+);
+
+CREATE TABLE gateway_settings (
+	application_id VARCHAR PRIMARY KEY REFERENCES applications(application_id),
+	secret_key VARCHAR,
+	secret_key_required BOOLEAN NOT NULL DEFAULT FALSE,
+	whitelist_origins VARCHAR[],
+	whitelist_user_agents VARCHAR[],
+	whitelist_blockchains VARCHAR[]
+);
+
+CREATE TABLE whitelist_contracts (
+	application_id VARCHAR NOT NULL REFERENCES applications(application_id),
+	blockchain_id VARCHAR NOT NULL,
+	contracts VARCHAR[],
+	PRIMARY KEY (application_id, blockchain_id)
+);
+
+CREATE TABLE whitelist_methods (
+	application_id VARCHAR NOT NULL REFERENCES applications(application_id),
+	blockchain_id VARCHAR NOT NULL,
+	methods VARCHAR[],
+	PRIMARY KEY (application_id, blockchain_id)
+);
+
+CREATE TABLE notification_settings (
+	application_id VARCHAR PRIMARY KEY REFERENCES applications(application_id),
+	signed_up BOOLEAN NOT NULL DEFAULT FALSE,
+	on_quarter BOOLEAN NOT NULL DEFAULT FALSE,
+	on_half BOOLEAN NOT NULL DEFAULT FALSE,
+	on_three_quarters BOOLEAN NOT NULL DEFAULT FALSE,
+	on_full BOOLEAN NOT NULL DEFAULT FALSE
+);
+
+CREATE TABLE loadbalancers (
+	lb_id VARCHAR PRIMARY KEY,
+	name VARCHAR,
+	user_id VARCHAR,
+	request_timeout INT,
+	gigastake BOOLEAN NOT NULL DEFAULT FALSE,
+	gigastake_redirect BOOLEAN NOT NULL DEFAULT FALSE,
+	created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+	updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE stickiness_options (
+	lb_id VARCHAR PRIMARY KEY REFERENCES loadbalancers(lb_id),
+	duration VARCHAR,
+	sticky_max INT,
+	stickiness BOOLEAN NOT NULL DEFAULT FALSE,
+	origins VARCHAR[]
+);
+
+CREATE TABLE lb_apps (
+	lb_id VARCHAR NOT NULL REFERENCES loadbalancers(lb_id),
+	app_id VARCHAR NOT NULL REFERENCES applications(application_id),
+	PRIMARY KEY (lb_id, app_id)
+);
+
+CREATE TABLE blockchains (
+	blockchain_id VARCHAR PRIMARY KEY,
+	active BOOLEAN NOT NULL DEFAULT FALSE,
+	altruist VARCHAR,
+	blockchain VARCHAR,
+	blockchain_aliases VARCHAR[],
+	chain_id VARCHAR,
+	chain_id_check VARCHAR,
+	description VARCHAR,
+	enforce_result VARCHAR,
+	log_limit_blocks INT,
+	network VARCHAR,
+	path VARCHAR,
+	request_timeout INT,
+	ticker VARCHAR,
+	created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+	updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE sync_check_options (
+	blockchain_id VARCHAR PRIMARY KEY REFERENCES blockchains(blockchain_id),
+	synccheck VARCHAR,
+	allowance INT,
+	body VARCHAR,
+	path VARCHAR,
+	result_key VARCHAR
+);
+
+CREATE TABLE redirects (
+	blockchain_id VARCHAR NOT NULL REFERENCES blockchains(blockchain_id),
+	alias VARCHAR NOT NULL,
+	loadbalancer VARCHAR REFERENCES loadbalancers(lb_id),
+	domain VARCHAR NOT NULL,
+	created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+	updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+	PRIMARY KEY (blockchain_id, domain)
+);
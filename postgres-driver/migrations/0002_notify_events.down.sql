@@ -0,0 +1,15 @@
+DROP TRIGGER loadbalancers_notify_event ON loadbalancers;
+DROP TRIGGER stickiness_options_notify_event ON stickiness_options;
+DROP TRIGGER lb_apps_notify_event ON lb_apps;
+DROP TRIGGER applications_notify_event ON applications;
+DROP TRIGGER app_limits_notify_event ON app_limits;
+DROP TRIGGER gateway_aat_notify_event ON gateway_aat;
+DROP TRIGGER gateway_settings_notify_event ON gateway_settings;
+DROP TRIGGER whitelist_contracts_notify_event ON whitelist_contracts;
+DROP TRIGGER whitelist_methods_notify_event ON whitelist_methods;
+DROP TRIGGER notification_settings_notify_event ON notification_settings;
+DROP TRIGGER blockchains_notify_event ON blockchains;
+DROP TRIGGER sync_check_options_notify_event ON sync_check_options;
+DROP TRIGGER redirects_notify_event ON redirects;
+
+DROP FUNCTION notify_event();
@@ -0,0 +1,56 @@
+-- Every change is notified on the events channel as: {"table": ..., "action": ..., "data": row}
+-- where row is the inserted or updated row, or the deleted one
+CREATE FUNCTION notify_event() RETURNS TRIGGER AS $$
+DECLARE
+	data JSON;
+BEGIN
+	IF (TG_OP = 'DELETE') THEN
+		data = row_to_json(OLD);
+	ELSE
+		data = row_to_json(NEW);
+	END IF;
+
+	PERFORM pg_notify('events', json_build_object('table', TG_TABLE_NAME, 'action', TG_OP, 'data', data)::TEXT);
+
+	RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER loadbalancers_notify_event AFTER INSERT OR UPDATE OR DELETE ON loadbalancers
+FOR EACH ROW EXECUTE PROCEDURE notify_event();
+
+CREATE TRIGGER stickiness_options_notify_event AFTER INSERT OR UPDATE OR DELETE ON stickiness_options
+FOR EACH ROW EXECUTE PROCEDURE notify_event();
+
+CREATE TRIGGER lb_apps_notify_event AFTER INSERT OR UPDATE OR DELETE ON lb_apps
+FOR EACH ROW EXECUTE PROCEDURE notify_event();
+
+CREATE TRIGGER applications_notify_event AFTER INSERT OR UPDATE OR DELETE ON applications
+FOR EACH ROW EXECUTE PROCEDURE notify_event();
+
+CREATE TRIGGER app_limits_notify_event AFTER INSERT OR UPDATE OR DELETE ON app_limits
+FOR EACH ROW EXECUTE PROCEDURE notify_event();
+
+CREATE TRIGGER gateway_aat_notify_event AFTER INSERT OR UPDATE OR DELETE ON gateway_aat
+FOR EACH ROW EXECUTE PROCEDURE notify_event();
+
+CREATE TRIGGER gateway_settings_notify_event AFTER INSERT OR UPDATE OR DELETE ON gateway_settings
+FOR EACH ROW EXECUTE PROCEDURE notify_event();
+
+CREATE TRIGGER whitelist_contracts_notify_event AFTER INSERT OR UPDATE OR DELETE ON whitelist_contracts
+FOR EACH ROW EXECUTE PROCEDURE notify_event();
+
+CREATE TRIGGER whitelist_methods_notify_event AFTER INSERT OR UPDATE OR DELETE ON whitelist_methods
+FOR EACH ROW EXECUTE PROCEDURE notify_event();
+
+CREATE TRIGGER notification_settings_notify_event AFTER INSERT OR UPDATE OR DELETE ON notification_settings
+FOR EACH ROW EXECUTE PROCEDURE notify_event();
+
+CREATE TRIGGER blockchains_notify_event AFTER INSERT OR UPDATE OR DELETE ON blockchains
+FOR EACH ROW EXECUTE PROCEDURE notify_event();
+
+CREATE TRIGGER sync_check_options_notify_event AFTER INSERT OR UPDATE OR DELETE ON sync_check_options
+FOR EACH ROW EXECUTE PROCEDURE notify_event();
+
+CREATE TRIGGER redirects_notify_event AFTER INSERT OR UPDATE OR DELETE ON redirects
+FOR EACH ROW EXECUTE PROCEDURE notify_event();
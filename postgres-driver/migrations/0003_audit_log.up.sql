@@ -0,0 +1,23 @@
+-- Changes made through the driver are recorded with the columns that changed, before and after the change
+CREATE TABLE audit_log (
+	id BIGSERIAL PRIMARY KEY,
+	actor VARCHAR NOT NULL,
+	operation VARCHAR NOT NULL,
+	entity_id VARCHAR NOT NULL,
+	table_name VARCHAR NOT NULL,
+	before JSONB,
+	after JSONB,
+	created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX audit_log_entity_id_idx ON audit_log (entity_id, created_at);
+
+-- The audit log is append-only: updates, deletes and truncates are rejected
+CREATE FUNCTION reject_audit_log_change() RETURNS TRIGGER AS $$
+BEGIN
+	RAISE EXCEPTION 'audit_log is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_log_append_only BEFORE UPDATE OR DELETE ON audit_log FOR EACH ROW EXECUTE PROCEDURE reject_audit_log_change();
+CREATE TRIGGER audit_log_no_truncate BEFORE TRUNCATE ON audit_log FOR EACH STATEMENT EXECUTE PROCEDURE reject_audit_log_change();
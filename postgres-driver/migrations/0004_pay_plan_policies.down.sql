@@ -0,0 +1,4 @@
+ALTER TABLE pay_plans
+	DROP COLUMN rate_limit,
+	DROP COLUMN allowed_chains,
+	DROP COLUMN aat_plan;
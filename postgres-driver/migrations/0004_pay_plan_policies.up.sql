@@ -0,0 +1,5 @@
+-- Pay plans carry the relay policies of the applications on them: empty or zero values apply no policy
+ALTER TABLE pay_plans
+	ADD COLUMN rate_limit INT NOT NULL DEFAULT 0,
+	ADD COLUMN allowed_chains VARCHAR[] NOT NULL DEFAULT '{}',
+	ADD COLUMN aat_plan VARCHAR NOT NULL DEFAULT '';
@@ -0,0 +1,2 @@
+ALTER TABLE app_limits
+	ALTER COLUMN pay_plan SET NOT NULL;
@@ -0,0 +1,3 @@
+-- Applications may have no pay plan while all of them are moved to one: the reference applies to set plans only
+ALTER TABLE app_limits
+	ALTER COLUMN pay_plan DROP NOT NULL;
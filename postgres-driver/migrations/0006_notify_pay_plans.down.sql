@@ -0,0 +1 @@
+DROP TRIGGER pay_plans_notify_event ON pay_plans;
@@ -0,0 +1,3 @@
+-- Changes of the pay plan catalogue apply to the relays of the applications on the plans
+CREATE TRIGGER pay_plans_notify_event AFTER INSERT OR UPDATE OR DELETE ON pay_plans
+FOR EACH ROW EXECUTE PROCEDURE notify_event();
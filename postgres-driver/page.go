@@ -0,0 +1,154 @@
+package postgresdriver
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pokt-foundation/portal-api-go/repository"
+)
+
+const (
+	// DefaultPageSize is the number of entities of a page without limit
+	DefaultPageSize = 100
+	// MaxPageSize is the largest number of entities returned in a single page
+	MaxPageSize = 1000
+)
+
+// ErrNotFound error when the entity read does not exist
+var ErrNotFound = errors.New("not found")
+
+// Page selects the entities, ordered by ID, following the cursor: the ID of the last
+// entity of the previous page, or empty for the first page
+type Page struct {
+	Cursor string
+	Limit  int
+}
+
+func (p Page) size() int {
+	switch {
+	case p.Limit <= 0:
+		return DefaultPageSize
+	case p.Limit > MaxPageSize:
+		return MaxPageSize
+	default:
+		return p.Limit
+	}
+}
+
+// ApplicationFilter selects the applications matching all the fields set
+type ApplicationFilter struct {
+	UserID         string
+	Status         repository.AppStatus
+	PayPlan        repository.PayPlanType
+	LoadBalancerID string
+	UpdatedSince   time.Time
+}
+
+func (f ApplicationFilter) conditions() *conditions {
+	c := &conditions{}
+
+	if f.UserID != "" {
+		c.add("a.user_id = ?", f.UserID)
+	}
+	if f.Status != "" {
+		c.add("a.status = ?", f.Status)
+	}
+	if f.PayPlan != "" {
+		c.add("al.pay_plan = ?", f.PayPlan)
+	}
+	if f.LoadBalancerID != "" {
+		c.add("a.application_id IN (SELECT app_id FROM lb_apps WHERE lb_id = ?)", f.LoadBalancerID)
+	}
+	if !f.UpdatedSince.IsZero() {
+		c.add("a.updated_at >= ?", f.UpdatedSince)
+	}
+
+	return c
+}
+
+// LoadBalancerFilter selects the load balancers matching all the fields set
+type LoadBalancerFilter struct {
+	UserID        string
+	ApplicationID string
+	UpdatedSince  time.Time
+}
+
+func (f LoadBalancerFilter) conditions() *conditions {
+	c := &conditions{}
+
+	if f.UserID != "" {
+		c.add("lb.user_id = ?", f.UserID)
+	}
+	if f.ApplicationID != "" {
+		c.add("lb.lb_id IN (SELECT lb_id FROM lb_apps WHERE app_id = ?)", f.ApplicationID)
+	}
+	if !f.UpdatedSince.IsZero() {
+		c.add("lb.updated_at >= ?", f.UpdatedSince)
+	}
+
+	return c
+}
+
+// BlockchainFilter selects the blockchains matching all the fields set
+type BlockchainFilter struct {
+	UpdatedSince time.Time
+}
+
+func (f BlockchainFilter) conditions() *conditions {
+	c := &conditions{}
+
+	if !f.UpdatedSince.IsZero() {
+		c.add("b.updated_at >= ?", f.UpdatedSince)
+	}
+
+	return c
+}
+
+// conditions builds the WHERE clause of a filtered read, numbering its arguments in order
+type conditions struct {
+	clauses []string
+	args    []interface{}
+}
+
+// add appends the clause, with its single argument marked as ?
+func (c *conditions) add(clause string, arg interface{}) {
+	c.args = append(c.args, arg)
+	c.clauses = append(c.clauses, strings.Replace(clause, "?", fmt.Sprintf("$%d", len(c.args)), 1))
+}
+
+func (c *conditions) where() string {
+	if len(c.clauses) == 0 {
+		return ""
+	}
+
+	return "\n\tWHERE " + strings.Join(c.clauses, " AND ")
+}
+
+// query returns the filtered read of selectScript grouped by groupBy, without pagination
+func (c *conditions) query(selectScript, groupBy string) string {
+	return selectScript + c.where() + groupBy
+}
+
+// pageQuery returns the filtered read of the page, fetching one entity more than the
+// page size to know if there is a next page
+func (c *conditions) pageQuery(selectScript, groupBy, idColumn string, page Page) string {
+	if page.Cursor != "" {
+		c.add(idColumn+" > ?", page.Cursor)
+	}
+
+	c.args = append(c.args, page.size()+1)
+
+	return fmt.Sprintf("%s\n\tORDER BY %s\n\tLIMIT $%d", c.query(selectScript, groupBy), idColumn, len(c.args))
+}
+
+// nextCursor returns the cursor of the page following the one read, empty if it is the last one
+func nextCursor(read int, page Page, lastID func(i int) string) (int, string) {
+	size := page.size()
+	if read <= size {
+		return read, ""
+	}
+
+	return size, lastID(size - 1)
+}
@@ -0,0 +1,74 @@
+package postgresdriver
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pokt-foundation/portal-api-go/repository"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPageQuery(t *testing.T) {
+	since := time.Date(2022, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name          string
+		conditions    *conditions
+		page          Page
+		expectedQuery string
+		expectedArgs  []interface{}
+	}{
+		{
+			name:          "No filter on the first page",
+			conditions:    ApplicationFilter{}.conditions(),
+			expectedQuery: "SELECT a FROM applications GROUP BY a\n\tORDER BY id\n\tLIMIT $1",
+			expectedArgs:  []interface{}{DefaultPageSize + 1},
+		},
+		{
+			name: "All application filters after the cursor",
+			conditions: ApplicationFilter{
+				UserID:         "user-1",
+				Status:         repository.InService,
+				PayPlan:        repository.FreetierV0,
+				LoadBalancerID: "lb-1",
+				UpdatedSince:   since,
+			}.conditions(),
+			page: Page{Cursor: "app-1", Limit: 10},
+			expectedQuery: "SELECT a FROM applications\n\tWHERE a.user_id = $1 AND a.status = $2 AND al.pay_plan = $3 AND " +
+				"a.application_id IN (SELECT app_id FROM lb_apps WHERE lb_id = $4) AND a.updated_at >= $5 AND id > $6 GROUP BY a\n\tORDER BY id\n\tLIMIT $7",
+			expectedArgs: []interface{}{"user-1", repository.InService, repository.FreetierV0, "lb-1", since, "app-1", 11},
+		},
+		{
+			name:          "Load balancer filters",
+			conditions:    LoadBalancerFilter{ApplicationID: "app-1"}.conditions(),
+			page:          Page{Limit: MaxPageSize + 1},
+			expectedQuery: "SELECT a FROM applications\n\tWHERE lb.lb_id IN (SELECT lb_id FROM lb_apps WHERE app_id = $1) GROUP BY a\n\tORDER BY id\n\tLIMIT $2",
+			expectedArgs:  []interface{}{"app-1", MaxPageSize + 1},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := require.New(t)
+
+			query := tc.conditions.pageQuery("SELECT a FROM applications", " GROUP BY a", "id", tc.page)
+			c.Equal(tc.expectedQuery, query)
+			c.Equal(tc.expectedArgs, tc.conditions.args)
+		})
+	}
+}
+
+func TestNextCursor(t *testing.T) {
+	c := require.New(t)
+
+	ids := []string{"1", "2", "3"}
+	lastID := func(i int) string { return ids[i] }
+
+	count, cursor := nextCursor(3, Page{Limit: 2}, lastID)
+	c.Equal(2, count)
+	c.Equal("2", cursor)
+
+	count, cursor = nextCursor(2, Page{Limit: 2}, lastID)
+	c.Equal(2, count)
+	c.Empty(cursor)
+}
@@ -1,28 +1,72 @@
 package postgresdriver
 
-import "github.com/pokt-foundation/portal-api-go/repository"
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/lib/pq"
+	"github.com/pokt-foundation/portal-api-go/repository"
+)
 
 const (
-	selectPayPlans = "SELECT plan_type, daily_limit FROM pay_plans"
+	selectPayPlans = "SELECT plan_type, daily_limit, rate_limit, allowed_chains, aat_plan FROM pay_plans"
+
+	// foreignKeyViolation is the postgres error code of a reference to a missing row
+	foreignKeyViolation = "23503"
+	// payPlanConstraint references the pay plans catalogue from app_limits
+	payPlanConstraint = "app_limits_pay_plan_fkey"
 )
 
 type dbPayPlan struct {
-	Type       string `db:"plan_type"`
-	DailyLimit int    `db:"daily_limit"`
+	Type          string         `db:"plan_type"`
+	DailyLimit    int            `db:"daily_limit"`
+	RateLimit     int            `db:"rate_limit"`
+	AllowedChains pq.StringArray `db:"allowed_chains"`
+	AatPlan       string         `db:"aat_plan"`
 }
 
 func (d *dbPayPlan) toPayPlan() *repository.PayPlan {
 	return &repository.PayPlan{
-		Type:  repository.PayPlanType(d.Type),
-		Limit: d.DailyLimit,
+		Type:          repository.PayPlanType(d.Type),
+		Limit:         d.DailyLimit,
+		RateLimit:     d.RateLimit,
+		AllowedChains: d.AllowedChains,
+		AatPlan:       repository.AatPlan(d.AatPlan),
+	}
+}
+
+type dbPayPlanJSON struct {
+	Type          string   `json:"plan_type"`
+	DailyLimit    int      `json:"daily_limit"`
+	RateLimit     int      `json:"rate_limit"`
+	AllowedChains []string `json:"allowed_chains"`
+	AatPlan       string   `json:"aat_plan"`
+}
+
+func (j dbPayPlanJSON) toOutput() *repository.PayPlan {
+	return &repository.PayPlan{
+		Type:          repository.PayPlanType(j.Type),
+		Limit:         j.DailyLimit,
+		RateLimit:     j.RateLimit,
+		AllowedChains: j.AllowedChains,
+		AatPlan:       repository.AatPlan(j.AatPlan),
 	}
 }
 
 // ReadPayPlans returns all pay plans on the database
 func (d *PostgresDriver) ReadPayPlans() ([]*repository.PayPlan, error) {
+	return d.ReadPayPlansContext(context.Background())
+}
+
+// ReadPayPlansContext is ReadPayPlans bounded by the context and the driver's statement timeout
+func (d *PostgresDriver) ReadPayPlansContext(ctx context.Context) ([]*repository.PayPlan, error) {
+	ctx, cancel := d.withStatementTimeout(ctx)
+	defer cancel()
+
 	var dbPayPlans []*dbPayPlan
 
-	err := d.Select(&dbPayPlans, selectPayPlans)
+	err := d.SelectContext(ctx, &dbPayPlans, selectPayPlans)
 	if err != nil {
 		return nil, err
 	}
@@ -35,3 +79,13 @@ func (d *PostgresDriver) ReadPayPlans() ([]*repository.PayPlan, error) {
 
 	return payPlans, nil
 }
+
+// payPlanError returns repository.ErrInvalidPayPlanType if err is caused by a pay plan missing from the catalogue
+func payPlanError(err error, planType repository.PayPlanType) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == foreignKeyViolation && pqErr.Constraint == payPlanConstraint {
+		return fmt.Errorf("%w: %s", repository.ErrInvalidPayPlanType, planType)
+	}
+
+	return err
+}
@@ -5,6 +5,8 @@ import (
 	"testing"
 
 	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/pokt-foundation/portal-api-go/repository"
 	"github.com/stretchr/testify/require"
 )
 
@@ -16,9 +18,9 @@ func TestPostgresDriver_ReadPayPlans(t *testing.T) {
 
 	defer db.Close()
 
-	rows := sqlmock.NewRows([]string{"plan_type", "daily_limit"}).
-		AddRow("FREETIER_V0", 250000).
-		AddRow("PAY_AS_YOU_GO_V0", 0)
+	rows := sqlmock.NewRows([]string{"plan_type", "daily_limit", "rate_limit", "allowed_chains", "aat_plan"}).
+		AddRow("FREETIER_V0", 250000, 10, "{0021,0040}", "").
+		AddRow("PAY_AS_YOU_GO_V0", 0, 0, "{}", "Premium")
 
 	mock.ExpectQuery("^SELECT (.+) FROM pay_plans$").WillReturnRows(rows)
 
@@ -26,7 +28,19 @@ func TestPostgresDriver_ReadPayPlans(t *testing.T) {
 
 	payPlans, err := driver.ReadPayPlans()
 	c.NoError(err)
-	c.Len(payPlans, 2)
+	c.Equal([]*repository.PayPlan{
+		{
+			Type:          repository.FreetierV0,
+			Limit:         250000,
+			RateLimit:     10,
+			AllowedChains: []string{"0021", "0040"},
+		},
+		{
+			Type:          repository.PayAsYouGoV0,
+			AllowedChains: []string{},
+			AatPlan:       repository.AatPlanPremium,
+		},
+	}, payPlans)
 
 	mock.ExpectQuery("^SELECT (.+) FROM pay_plans$").WillReturnError(errors.New("dummy error"))
 
@@ -34,3 +48,14 @@ func TestPostgresDriver_ReadPayPlans(t *testing.T) {
 	c.EqualError(err, "dummy error")
 	c.Empty(payPlans)
 }
+
+func TestPayPlanError(t *testing.T) {
+	c := require.New(t)
+
+	err := payPlanError(&pq.Error{Code: foreignKeyViolation, Constraint: payPlanConstraint}, "wrong")
+	c.True(errors.Is(err, repository.ErrInvalidPayPlanType))
+	c.EqualError(err, "invalid pay plan type: wrong")
+
+	otherErr := &pq.Error{Code: foreignKeyViolation, Constraint: "app_limits_application_id_fkey"}
+	c.Equal(otherErr, payPlanError(otherErr, "wrong"))
+}
@@ -1,10 +1,12 @@
 package postgresdriver
 
 import (
+	"context"
 	"crypto/rand"
 	"database/sql"
 	"encoding/hex"
 	"errors"
+	"sync"
 	"time"
 
 	"github.com/jmoiron/sqlx"
@@ -13,6 +15,9 @@ import (
 
 const (
 	psqlDateLayout = "2006-01-02T15:04:05.999999"
+
+	// DefaultStatementTimeout is the time every driver call is allowed to take unless changed with WithStatementTimeout
+	DefaultStatementTimeout = 30 * time.Second
 )
 
 var (
@@ -23,54 +28,129 @@ var (
 	// ErrMissingID error when ID is missing
 	ErrMissingID = errors.New("missing id")
 
+	// ErrMissingApplicationIDs error when no application IDs are given to add to or remove from a load balancer
+	ErrMissingApplicationIDs = errors.New("missing application ids")
+
+	// ErrMissingDomain error when the domain of a redirect is missing
+	ErrMissingDomain = errors.New("missing domain")
+
 	idLength = 24
 )
 
 // PostgresDriver struct handler for PostgresDB related functions
 type PostgresDriver struct {
-	notification chan *repository.Notification
-	listener     Listener
+	notification    chan *repository.Notification
+	errors          chan error
+	listener        Listener
+	listenerDone    chan struct{}
+	listenerStopped chan struct{}
+	closeListener   sync.Once
+	deliveryTimeout time.Duration
+	stats           ListenerStats
+	statsMu         sync.Mutex
+	secrets         SecretCipher
+	timeout         time.Duration
 	*sqlx.DB
 }
 
+// Option configures optional settings of the PostgresDriver
+type Option func(*PostgresDriver)
+
+// WithSecretCipher sets the cipher used to store application secrets encrypted,
+// secrets are stored in plain text if no cipher is set
+func WithSecretCipher(secrets SecretCipher) Option {
+	return func(d *PostgresDriver) {
+		d.secrets = secrets
+	}
+}
+
+// WithStatementTimeout sets the time every driver call is allowed to take, a zero timeout disables it.
+// Calls taking a context are also bounded by the context deadline.
+func WithStatementTimeout(timeout time.Duration) Option {
+	return func(d *PostgresDriver) {
+		d.timeout = timeout
+	}
+}
+
+// WithNotificationDeliveryTimeout sets the time a notification waits for the consumer of the notification
+// channel before being dropped, a resync is signaled to the consumer once a notification is dropped
+func WithNotificationDeliveryTimeout(timeout time.Duration) Option {
+	return func(d *PostgresDriver) {
+		d.deliveryTimeout = timeout
+	}
+}
+
+// WithMaxOpenConns sets the maximum number of open connections to the database
+func WithMaxOpenConns(n int) Option {
+	return func(d *PostgresDriver) {
+		d.SetMaxOpenConns(n)
+	}
+}
+
+// WithMaxIdleConns sets the maximum number of idle connections kept in the pool
+func WithMaxIdleConns(n int) Option {
+	return func(d *PostgresDriver) {
+		d.SetMaxIdleConns(n)
+	}
+}
+
+// WithConnMaxLifetime sets the maximum amount of time a connection may be reused
+func WithConnMaxLifetime(lifetime time.Duration) Option {
+	return func(d *PostgresDriver) {
+		d.SetConnMaxLifetime(lifetime)
+	}
+}
+
+func newPostgresDriver(db *sqlx.DB, listener Listener, options ...Option) *PostgresDriver {
+	driver := &PostgresDriver{
+		notification:    make(chan *repository.Notification, 32),
+		errors:          make(chan error, errorChannelSize),
+		listener:        listener,
+		listenerDone:    make(chan struct{}),
+		listenerStopped: make(chan struct{}),
+		deliveryTimeout: notificationDeliveryTimeout,
+		secrets:         plaintextCipher{},
+		timeout:         DefaultStatementTimeout,
+		DB:              db,
+	}
+
+	for _, option := range options {
+		option(driver)
+	}
+
+	return driver
+}
+
 // NewPostgresDriverFromConnectionString returns PostgresDriver instance from connection string
-func NewPostgresDriverFromConnectionString(connectionString string, listener Listener) (*PostgresDriver, error) {
+func NewPostgresDriverFromConnectionString(connectionString string, listener Listener, options ...Option) (*PostgresDriver, error) {
 	db, err := sqlx.Open("postgres", connectionString)
 	if err != nil {
 		return nil, err
 	}
 
-	driver := &PostgresDriver{
-		notification: make(chan *repository.Notification, 32),
-		listener:     listener,
-		DB:           db,
-	}
+	driver := newPostgresDriver(db, listener, options...)
 
 	err = driver.listener.Listen("events")
 	if err != nil {
 		return nil, err
 	}
 
-	go Listen(driver.listener.NotificationChannel(), driver.notification)
+	go driver.listen()
 
 	return driver, nil
 }
 
 // NewPostgresDriverFromSQLDBInstance returns PostgresDriver instance from sdl.DB instance
 // mostly used for mocking tests
-func NewPostgresDriverFromSQLDBInstance(db *sql.DB, listener Listener) *PostgresDriver {
-	driver := &PostgresDriver{
-		notification: make(chan *repository.Notification, 32),
-		listener:     listener,
-		DB:           sqlx.NewDb(db, "postgres"),
-	}
+func NewPostgresDriverFromSQLDBInstance(db *sql.DB, listener Listener, options ...Option) *PostgresDriver {
+	driver := newPostgresDriver(sqlx.NewDb(db, "postgres"), listener, options...)
 
 	err := driver.listener.Listen("events")
 	if err != nil {
 		panic(err)
 	}
 
-	go Listen(driver.listener.NotificationChannel(), driver.notification)
+	go driver.listen()
 
 	return driver
 }
@@ -137,51 +217,120 @@ type nullable interface {
 	isNotNull() bool
 }
 
+// insertNullables inserts the side table rows that hold any value
+func insertNullables(ctx context.Context, tx *sqlx.Tx, nullables []nullable, scripts []string) error {
+	for i := range nullables {
+		if !nullables[i].isNotNull() {
+			continue
+		}
+
+		_, err := tx.NamedExecContext(ctx, scripts[i], nullables[i])
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 type updatable interface {
 	isUpdatable() bool
-	read(driver *PostgresDriver, args []string) (updatable, error)
 }
 
+// update upserts a side table row, skipping it when there is nothing to update
 type update struct {
-	insertScript string
-	updateScript string
+	upsertScript string
 	toUpdate     updatable
-	secondaryID  string
+	audited      auditedRow
 }
 
-func (d *PostgresDriver) doUpdate(id string, update *update, tx *sqlx.Tx) error {
-	if !update.toUpdate.isUpdatable() {
-		return nil
-	}
+// auditedRows returns the rows changed by the updates
+func auditedRows(updates []*update) []auditedRow {
+	rows := []auditedRow{}
 
-	ids := []string{id}
-	if update.secondaryID != "" {
-		ids = append(ids, update.secondaryID)
+	for _, update := range updates {
+		if update.toUpdate.isUpdatable() {
+			rows = append(rows, update.audited)
+		}
 	}
 
-	_, err := update.toUpdate.read(d, ids)
-	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			_, err = tx.NamedExec(update.insertScript, update.toUpdate)
-			if err != nil {
-				return err
-			}
+	return rows
+}
 
-			return nil
+func doUpdates(ctx context.Context, tx *sqlx.Tx, updates []*update) error {
+	for _, update := range updates {
+		if !update.toUpdate.isUpdatable() {
+			continue
 		}
 
+		_, err := tx.NamedExecContext(ctx, update.upsertScript, update.toUpdate)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// rowsFound returns ErrNotFound if the statement of the result affected no rows, e.g. the updated entity does not exist
+func rowsFound(result sql.Result, err error) error {
+	if err != nil {
 		return err
 	}
 
-	_, err = tx.NamedExec(update.updateScript, update.toUpdate)
+	affected, err := result.RowsAffected()
 	if err != nil {
 		return err
 	}
+	if affected == 0 {
+		return ErrNotFound
+	}
 
 	return nil
 }
 
+// withStatementTimeout bounds the context by the driver's statement timeout
+func (d *PostgresDriver) withStatementTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if d.timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+
+	return context.WithTimeout(ctx, d.timeout)
+}
+
+// withTx runs fn inside a single transaction of the driver's database
+func (d *PostgresDriver) withTx(ctx context.Context, fn func(tx *sqlx.Tx) error) error {
+	return runInTx(ctx, d.DB, fn)
+}
+
+// runInTx runs fn inside a single transaction, committing when it succeeds
+// and rolling back when it returns an error or panics
+func runInTx(ctx context.Context, db *sqlx.DB, fn func(tx *sqlx.Tx) error) (err error) {
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			_ = tx.Rollback()
+			panic(p)
+		}
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	if err = fn(tx); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
 func psqlDateToTime(rawDate string) time.Time {
 	date, _ := time.Parse(psqlDateLayout, rawDate)
 	return date
 }
+
+var _ repository.ChangeSource = &PostgresDriver{}
@@ -0,0 +1,52 @@
+package postgresdriver
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPostgresDriver_StatementTimeout(t *testing.T) {
+	c := require.New(t)
+
+	db, mock, err := sqlmock.New()
+	c.NoError(err)
+
+	defer db.Close()
+
+	driver := NewPostgresDriverFromSQLDBInstance(db, &ListenerMock{}, WithStatementTimeout(10*time.Millisecond),
+		WithMaxOpenConns(5), WithMaxIdleConns(2), WithConnMaxLifetime(time.Minute))
+	c.Equal(5, driver.Stats().MaxOpenConnections)
+
+	/* Calls taking longer than the statement timeout are cancelled */
+	mock.ExpectQuery("^SELECT (.+) FROM pay_plans$").WillDelayFor(time.Second).
+		WillReturnRows(sqlmock.NewRows([]string{"plan_type", "daily_limit"}))
+
+	payPlans, err := driver.ReadPayPlans()
+	c.Equal(sqlmock.ErrCancelled, err)
+	c.Empty(payPlans)
+
+	/* Transactions are rolled back when the context is done */
+	mock.ExpectBegin()
+	expectAuditSnapshots(mock, "blockchains")
+	mock.ExpectExec("UPDATE blockchains").WillDelayFor(time.Second).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectRollback()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+	defer cancel()
+
+	err = driver.ActivateBlockchainContext(ctx, "0021", true)
+	c.Equal(sqlmock.ErrCancelled, err)
+
+	/* A cancelled context fails before reaching the database */
+	ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+
+	err = driver.ActivateBlockchainContext(ctx, "0021", true)
+	c.True(errors.Is(err, context.Canceled))
+}
@@ -1,9 +1,11 @@
 package postgresdriver
 
 import (
+	"context"
 	"database/sql"
 	"time"
 
+	"github.com/jmoiron/sqlx"
 	"github.com/pokt-foundation/portal-api-go/repository"
 )
 
@@ -14,6 +16,9 @@ const (
 	insertRedirectScript = `
 	INSERT into redirects (blockchain_id, alias, loadbalancer, domain, created_at, updated_at)
 	VALUES (:blockchain_id, :alias, :loadbalancer, :domain, :created_at, :updated_at)`
+	removeRedirectScript = `
+	DELETE FROM redirects
+	WHERE blockchain_id = $1 AND domain = $2`
 )
 
 type dbRedirectJSON struct {
@@ -56,9 +61,17 @@ func (r *dbRedirect) toRedirect() *repository.Redirect {
 
 // ReadRedirects returns all redirects on the database
 func (d *PostgresDriver) ReadRedirects() ([]*repository.Redirect, error) {
+	return d.ReadRedirectsContext(context.Background())
+}
+
+// ReadRedirectsContext is ReadRedirects bounded by the context and the driver's statement timeout
+func (d *PostgresDriver) ReadRedirectsContext(ctx context.Context) ([]*repository.Redirect, error) {
+	ctx, cancel := d.withStatementTimeout(ctx)
+	defer cancel()
+
 	var dbRedirects []*dbRedirect
 
-	err := d.Select(&dbRedirects, selectRedirectsScript)
+	err := d.SelectContext(ctx, &dbRedirects, selectRedirectsScript)
 	if err != nil {
 		return nil, err
 	}
@@ -85,6 +98,14 @@ func extractDBRedirect(redirect *repository.Redirect) *dbRedirect {
 
 // WriteRedirect saves input redirect in the database
 func (d *PostgresDriver) WriteRedirect(redirect *repository.Redirect) (*repository.Redirect, error) {
+	return d.WriteRedirectContext(context.Background(), redirect)
+}
+
+// WriteRedirectContext is WriteRedirect bounded by the context and the driver's statement timeout
+func (d *PostgresDriver) WriteRedirectContext(ctx context.Context, redirect *repository.Redirect) (*repository.Redirect, error) {
+	ctx, cancel := d.withStatementTimeout(ctx)
+	defer cancel()
+
 	id, err := generateRandomID()
 	if err != nil {
 		return nil, err
@@ -96,15 +117,34 @@ func (d *PostgresDriver) WriteRedirect(redirect *repository.Redirect) (*reposito
 
 	insertApp := extractDBRedirect(redirect)
 
-	tx, err := d.Beginx()
+	err = d.withTx(ctx, func(tx *sqlx.Tx) error {
+		_, err := tx.NamedExecContext(ctx, insertRedirectScript, insertApp)
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	_, err = tx.NamedExec(insertRedirectScript, insertApp)
-	if err != nil {
-		return nil, err
+	return redirect, nil
+}
+
+// RemoveRedirect deletes the redirect of the blockchain to the domain
+func (d *PostgresDriver) RemoveRedirect(blockchainID, domain string) error {
+	return d.RemoveRedirectContext(context.Background(), blockchainID, domain)
+}
+
+// RemoveRedirectContext is RemoveRedirect bounded by the context and the driver's statement timeout
+func (d *PostgresDriver) RemoveRedirectContext(ctx context.Context, blockchainID, domain string) error {
+	ctx, cancel := d.withStatementTimeout(ctx)
+	defer cancel()
+
+	if blockchainID == "" {
+		return ErrMissingID
+	}
+
+	if domain == "" {
+		return ErrMissingDomain
 	}
 
-	return redirect, tx.Commit()
+	return rowsFound(d.ExecContext(ctx, removeRedirectScript, blockchainID, domain))
 }
@@ -72,7 +72,44 @@ func TestPostgresDriver_WriteRedirect(t *testing.T) {
 		"pokt-mainnet.gateway.network", sqlmock.AnyArg(), sqlmock.AnyArg()).
 		WillReturnError(errors.New("error in redirects"))
 
+	mock.ExpectRollback()
+
 	app, err = driver.WriteRedirect(redirectToSend)
 	c.EqualError(err, "error in redirects")
 	c.Empty(app)
 }
+
+func TestPostgresDriver_RemoveRedirect(t *testing.T) {
+	c := require.New(t)
+
+	db, mock, err := sqlmock.New()
+	c.NoError(err)
+
+	defer db.Close()
+
+	driver := NewPostgresDriverFromSQLDBInstance(db, &ListenerMock{})
+
+	mock.ExpectExec("DELETE FROM redirects").WithArgs("0021", "pokt.network").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err = driver.RemoveRedirect("0021", "pokt.network")
+	c.NoError(err)
+
+	mock.ExpectExec("DELETE FROM redirects").WithArgs("0021", "pokt.network").
+		WillReturnError(errors.New("dummy error"))
+
+	err = driver.RemoveRedirect("0021", "pokt.network")
+	c.EqualError(err, "dummy error")
+
+	mock.ExpectExec("DELETE FROM redirects").WithArgs("0021", "missing.network").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	err = driver.RemoveRedirect("0021", "missing.network")
+	c.ErrorIs(err, ErrNotFound)
+
+	err = driver.RemoveRedirect("0021", "")
+	c.Equal(ErrMissingDomain, err)
+
+	err = driver.RemoveRedirect("", "pokt.network")
+	c.Equal(ErrMissingID, err)
+}
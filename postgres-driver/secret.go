@@ -0,0 +1,180 @@
+package postgresdriver
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"strings"
+
+	"github.com/pokt-foundation/portal-api-go/repository"
+)
+
+const (
+	// encryptedSecretPrefix marks a value stored using envelope encryption
+	encryptedSecretPrefix = "enc:v1:"
+
+	// SecretKeyLength is the length in bytes of the key used to encrypt secrets (AES-256)
+	SecretKeyLength = 32
+)
+
+var (
+	// ErrInvalidSecretKey error when the key supplied to encrypt secrets has the wrong length
+	ErrInvalidSecretKey = errors.New("invalid secret key length")
+	// ErrMissingSecretKey error when an encrypted secret is read but no key was supplied
+	ErrMissingSecretKey = errors.New("secret is encrypted but no secret key was supplied")
+	// ErrMalformedSecret error when an encrypted secret can not be decoded
+	ErrMalformedSecret = errors.New("malformed encrypted secret")
+)
+
+// SecretCipher encrypts secrets before they are written to the database and decrypts them when read back
+type SecretCipher interface {
+	Encrypt(plaintext string) (string, error)
+	Decrypt(ciphertext string) (string, error)
+}
+
+// plaintextCipher stores secrets as they are, used when no secret key is supplied
+type plaintextCipher struct{}
+
+func (plaintextCipher) Encrypt(plaintext string) (string, error) {
+	return plaintext, nil
+}
+
+func (plaintextCipher) Decrypt(ciphertext string) (string, error) {
+	if strings.HasPrefix(ciphertext, encryptedSecretPrefix) {
+		return "", ErrMissingSecretKey
+	}
+
+	return ciphertext, nil
+}
+
+// envelopeCipher encrypts every secret with its own random data key, which is
+// in turn encrypted (wrapped) with the locally supplied key encryption key.
+type envelopeCipher struct {
+	kek cipher.AEAD
+}
+
+// NewEnvelopeCipher returns a SecretCipher using envelope encryption with the supplied AES-256 key.
+// Values stored in plain text, i.e. written before encryption was enabled, are returned as they are.
+func NewEnvelopeCipher(key []byte) (SecretCipher, error) {
+	if len(key) != SecretKeyLength {
+		return nil, ErrInvalidSecretKey
+	}
+
+	kek, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return &envelopeCipher{kek: kek}, nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+func seal(aead cipher.AEAD, plaintext []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	return aead.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+func open(aead cipher.AEAD, sealed []byte) ([]byte, error) {
+	if len(sealed) < aead.NonceSize()+aead.Overhead() {
+		return nil, ErrMalformedSecret
+	}
+
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+
+	return aead.Open(nil, nonce, ciphertext, nil)
+}
+
+// Encrypt returns the secret encoded as: prefix + base64(wrapped data key + encrypted secret)
+func (e *envelopeCipher) Encrypt(plaintext string) (string, error) {
+	if plaintext == "" {
+		return "", nil
+	}
+
+	dataKey := make([]byte, SecretKeyLength)
+	if _, err := rand.Read(dataKey); err != nil {
+		return "", err
+	}
+
+	dek, err := newAEAD(dataKey)
+	if err != nil {
+		return "", err
+	}
+
+	wrappedKey, err := seal(e.kek, dataKey)
+	if err != nil {
+		return "", err
+	}
+
+	encrypted, err := seal(dek, []byte(plaintext))
+	if err != nil {
+		return "", err
+	}
+
+	return encryptedSecretPrefix + base64.StdEncoding.EncodeToString(append(wrappedKey, encrypted...)), nil
+}
+
+func (e *envelopeCipher) Decrypt(ciphertext string) (string, error) {
+	if !strings.HasPrefix(ciphertext, encryptedSecretPrefix) {
+		return ciphertext, nil
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(ciphertext, encryptedSecretPrefix))
+	if err != nil {
+		return "", ErrMalformedSecret
+	}
+
+	wrappedKeyLength := e.kek.NonceSize() + SecretKeyLength + e.kek.Overhead()
+	if len(raw) < wrappedKeyLength {
+		return "", ErrMalformedSecret
+	}
+
+	dataKey, err := open(e.kek, raw[:wrappedKeyLength])
+	if err != nil {
+		return "", err
+	}
+
+	dek, err := newAEAD(dataKey)
+	if err != nil {
+		return "", err
+	}
+
+	plaintext, err := open(dek, raw[wrappedKeyLength:])
+	if err != nil {
+		return "", err
+	}
+
+	return string(plaintext), nil
+}
+
+func encryptSecret(secrets SecretCipher, secret repository.Secret) (sql.NullString, error) {
+	encrypted, err := secrets.Encrypt(secret.Reveal())
+	if err != nil {
+		return sql.NullString{}, err
+	}
+
+	return newSQLNullString(encrypted), nil
+}
+
+func decryptSecret(secrets SecretCipher, value string) (repository.Secret, error) {
+	decrypted, err := secrets.Decrypt(value)
+	if err != nil {
+		return "", err
+	}
+
+	return repository.Secret(decrypted), nil
+}
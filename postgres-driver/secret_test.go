@@ -0,0 +1,150 @@
+package postgresdriver
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/pokt-foundation/portal-api-go/repository"
+	"github.com/stretchr/testify/require"
+)
+
+var testSecretKey = bytes.Repeat([]byte{0x2a}, SecretKeyLength)
+
+func TestEnvelopeCipher(t *testing.T) {
+	c := require.New(t)
+
+	_, err := NewEnvelopeCipher([]byte("short"))
+	c.Equal(ErrInvalidSecretKey, err)
+
+	secrets, err := NewEnvelopeCipher(testSecretKey)
+	c.NoError(err)
+
+	encrypted, err := secrets.Encrypt("54y4p93body6qco2nrhonz6bltn1k5e8")
+	c.NoError(err)
+	c.True(strings.HasPrefix(encrypted, encryptedSecretPrefix))
+	c.NotContains(encrypted, "54y4p93body6qco2nrhonz6bltn1k5e8")
+
+	otherEncrypted, err := secrets.Encrypt("54y4p93body6qco2nrhonz6bltn1k5e8")
+	c.NoError(err)
+	c.NotEqual(encrypted, otherEncrypted)
+
+	decrypted, err := secrets.Decrypt(encrypted)
+	c.NoError(err)
+	c.Equal("54y4p93body6qco2nrhonz6bltn1k5e8", decrypted)
+
+	decrypted, err = secrets.Decrypt("plain-secret")
+	c.NoError(err)
+	c.Equal("plain-secret", decrypted)
+
+	empty, err := secrets.Encrypt("")
+	c.NoError(err)
+	c.Empty(empty)
+
+	_, err = secrets.Decrypt(encryptedSecretPrefix + "not-base64!")
+	c.Equal(ErrMalformedSecret, err)
+
+	_, err = secrets.Decrypt(encryptedSecretPrefix + "c2hvcnQ=")
+	c.Equal(ErrMalformedSecret, err)
+
+	otherSecrets, err := NewEnvelopeCipher(bytes.Repeat([]byte{0x01}, SecretKeyLength))
+	c.NoError(err)
+
+	_, err = otherSecrets.Decrypt(encrypted)
+	c.Error(err)
+
+	_, err = plaintextCipher{}.Decrypt(encrypted)
+	c.Equal(ErrMissingSecretKey, err)
+}
+
+type encryptedSecretArg struct {
+	secrets   SecretCipher
+	plaintext string
+}
+
+func (a encryptedSecretArg) Match(value driver.Value) bool {
+	encrypted, ok := value.(string)
+	if !ok || !strings.HasPrefix(encrypted, encryptedSecretPrefix) {
+		return false
+	}
+
+	decrypted, err := a.secrets.Decrypt(encrypted)
+
+	return err == nil && decrypted == a.plaintext
+}
+
+func TestPostgresDriver_EncryptedSecrets(t *testing.T) {
+	c := require.New(t)
+
+	db, mock, err := sqlmock.New()
+	c.NoError(err)
+
+	defer db.Close()
+
+	secrets, err := NewEnvelopeCipher(testSecretKey)
+	c.NoError(err)
+
+	driver := NewPostgresDriverFromSQLDBInstance(db, &ListenerMock{}, WithSecretCipher(secrets))
+
+	mock.ExpectBegin()
+
+	mock.ExpectExec("INSERT into applications").WillReturnResult(sqlmock.NewResult(1, 1))
+
+	mock.ExpectExec("INSERT into app_limits").WillReturnResult(sqlmock.NewResult(1, 1))
+
+	mock.ExpectExec("INSERT into gateway_aat").WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(),
+		encryptedSecretArg{secrets: secrets, plaintext: "private-key"}, sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	mock.ExpectExec("INSERT into gateway_settings").WithArgs(sqlmock.AnyArg(),
+		encryptedSecretArg{secrets: secrets, plaintext: "secret-key"}, true, sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	mock.ExpectExec("INSERT into notification_settings").WillReturnResult(sqlmock.NewResult(1, 1))
+
+	mock.ExpectCommit()
+
+	_, err = driver.WriteApplication(&repository.Application{
+		Status: repository.InService,
+		Limit: repository.AppLimit{
+			PayPlan: repository.PayPlan{Type: repository.FreetierV0},
+		},
+		GatewayAAT: repository.GatewayAAT{
+			Address:    "f463b4dd88d865c22acbf38981b6c505bcc46c64",
+			PrivateKey: "private-key",
+		},
+		GatewaySettings: repository.GatewaySettings{
+			SecretKey:         "secret-key",
+			SecretKeyRequired: true,
+		},
+	})
+	c.NoError(err)
+
+	encryptedPrivateKey, err := secrets.Encrypt("private-key")
+	c.NoError(err)
+
+	rows := sqlmock.NewRows([]string{"application_id", "created_at", "updated_at", "ga_private_key", "secret_key"}).
+		AddRow("5f62b7d8be3591c4dea85661", time.Now(), time.Now(), encryptedPrivateKey, "legacy-secret-key")
+
+	mock.ExpectQuery("^WITH (.+) SELECT (.+) FROM applications(.+)").WillReturnRows(rows)
+
+	applications, err := driver.ReadApplications()
+	c.NoError(err)
+	c.Len(applications, 1)
+	c.Equal(repository.Secret("private-key"), applications[0].GatewayAAT.PrivateKey)
+	c.Equal(repository.Secret("legacy-secret-key"), applications[0].GatewaySettings.SecretKey)
+
+	rows = sqlmock.NewRows([]string{"application_id", "ga_private_key"}).
+		AddRow("5f62b7d8be3591c4dea85661", encryptedPrivateKey)
+
+	mock.ExpectQuery("^WITH (.+) SELECT (.+) FROM applications(.+)").WillReturnRows(rows)
+
+	plaintextDriver := NewPostgresDriverFromSQLDBInstance(db, &ListenerMock{})
+
+	applications, err = plaintextDriver.ReadApplications()
+	c.Equal(ErrMissingSecretKey, err)
+	c.Empty(applications)
+}
@@ -40,6 +40,7 @@ type nodeChecker struct {
 
 // NodesSupportingApp verifies the nodes supporting each of the chains of an application, and returns the results
 //	The results is a map of session keys to list of supporting nodes' addresses (public keys)
+//	It returns the context's error if the context is done, e.g. on shutdown, before all the chains are checked.
 func (c nodeChecker) NodesSupportingApp(ctx context.Context, app *repository.Application, chains []*repository.Blockchain) (map[string][]*provider.Node, error) {
 	pocketAAT := &provider.PocketAAT{
 		AppPubKey:    app.GatewayAAT.ApplicationPublicKey,
@@ -74,7 +75,13 @@ func (c nodeChecker) NodesSupportingApp(ctx context.Context, app *repository.App
 
 	results := make(map[string][]*provider.Node)
 	for running > 0 {
-		r := <-ch
+		var r *chainCheckResult
+		select {
+		case <-ctx.Done():
+			// Pending checks send their results to the buffered channel, so they do not block
+			return nil, ctx.Err()
+		case r = <-ch:
+		}
 		running--
 		if r == nil {
 			continue
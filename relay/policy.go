@@ -0,0 +1,110 @@
+package relay
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/pokt-foundation/portal-api-go/repository"
+)
+
+var (
+	ErrDailyLimitExceeded = &repository.CodedError{Code: -32059, Message: "application has exceeded its daily relay limit"}
+	ErrRateLimitExceeded  = &repository.CodedError{Code: -32062, Message: "application has exceeded its relay rate limit"}
+	ErrChainNotAllowed    = &repository.CodedError{Code: -32063, Message: "blockchain is not allowed by the application's pay plan"}
+)
+
+// payPlan returns the application's pay plan from the catalogue, with the application's daily limit.
+// Applications without a pay plan have no policies.
+func (r *relayServer) payPlan(app *repository.Application) (repository.PayPlan, error) {
+	if app.Limit.PayPlan.Type == "" {
+		return app.Limit.PayPlan, nil
+	}
+
+	plan, err := r.repository.GetPayPlan(app.Limit.PayPlan.Type)
+	if err != nil {
+		return repository.PayPlan{}, err
+	}
+	if app.Limit.CustomLimit != 0 {
+		plan.Limit = app.Limit.CustomLimit
+	}
+
+	return plan, nil
+}
+
+// applyPayPlan returns an error if the pay plan does not allow the relay, otherwise the relay is counted
+// against the plan's limits. Relays that are not served must be refunded with refundPayPlan.
+func (r *relayServer) applyPayPlan(details *RelayDetails, plan repository.PayPlan, now time.Time) error {
+	if !plan.AllowsChain(details.Blockchain.ID) {
+		return fmt.Errorf("%w: application %s blockchain %s", ErrChainNotAllowed, details.Application.ID, details.Blockchain.ID)
+	}
+
+	return r.usage.reserve(details.Application.ID, plan, now)
+}
+
+// refundPayPlan removes a relay counted by applyPayPlan at the given time, e.g. because no node served it
+func (r *relayServer) refundPayPlan(details *RelayDetails, reservedAt time.Time) {
+	r.usage.refund(details.Application.ID, reservedAt)
+}
+
+// planUsage counts the relays of each application in the current UTC day and second.
+// Counts are kept in memory and are not shared: each gateway instance enforces the full limits on the traffic
+// it serves, so an application served by several instances may relay up to the limits on each of them.
+type planUsage struct {
+	mu        sync.Mutex
+	day       time.Time
+	daily     map[string]int
+	second    time.Time
+	perSecond map[string]int
+}
+
+// reserve counts a relay of the application, returning an error without counting it if it exceeds
+// the daily or rate limit of the plan. Limits set to 0 are not enforced.
+// The relay is counted before it is sent, for concurrent relays not to exceed the limits: relays
+// that fail are refunded.
+func (u *planUsage) reserve(appID string, plan repository.PayPlan, now time.Time) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	u.advance(now)
+
+	if plan.Limit > 0 && u.daily[appID] >= plan.Limit {
+		return fmt.Errorf("%w: application %s limit %d", ErrDailyLimitExceeded, appID, plan.Limit)
+	}
+	if plan.RateLimit > 0 && u.perSecond[appID] >= plan.RateLimit {
+		return fmt.Errorf("%w: application %s limit %d per second", ErrRateLimitExceeded, appID, plan.RateLimit)
+	}
+
+	u.daily[appID]++
+	u.perSecond[appID]++
+
+	return nil
+}
+
+// refund removes a relay of the application reserved at the given time, from the counts of the day and
+// second it was reserved in if they are still the current ones
+func (u *planUsage) refund(appID string, reservedAt time.Time) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	reservedAt = reservedAt.UTC()
+	if reservedAt.Truncate(24*time.Hour).Equal(u.day) && u.daily[appID] > 0 {
+		u.daily[appID]--
+	}
+	if reservedAt.Truncate(time.Second).Equal(u.second) && u.perSecond[appID] > 0 {
+		u.perSecond[appID]--
+	}
+}
+
+// advance resets the counts of past days and seconds
+func (u *planUsage) advance(now time.Time) {
+	now = now.UTC()
+	if day := now.Truncate(24 * time.Hour); !day.Equal(u.day) || u.daily == nil {
+		u.day = day
+		u.daily = make(map[string]int)
+	}
+	if second := now.Truncate(time.Second); !second.Equal(u.second) || u.perSecond == nil {
+		u.second = second
+		u.perSecond = make(map[string]int)
+	}
+}
@@ -0,0 +1,206 @@
+package relay
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/pokt-foundation/pocket-go/signer"
+	logger "github.com/sirupsen/logrus"
+
+	"github.com/pokt-foundation/portal-api-go/repository"
+)
+
+func TestRelayWithAppPayPlan(t *testing.T) {
+	appSigner, err := signer.NewRandomSigner()
+	if err != nil {
+		t.Fatalf("Error creating signer: %v", err)
+	}
+
+	payPlans := repository.PayPlans{
+		repository.FreetierV0:   {Type: repository.FreetierV0, Limit: 2, AllowedChains: []string{"0021"}},
+		repository.PayAsYouGoV0: {Type: repository.PayAsYouGoV0, RateLimit: 1, AatPlan: repository.AatPlanFreemium},
+		repository.Enterprise:   {Type: repository.Enterprise},
+		repository.TestPlanV0:   {Type: repository.TestPlanV0, AatPlan: repository.AatPlanPremium},
+	}
+
+	testCases := []struct {
+		name           string
+		limit          repository.AppLimit
+		blockchainID   string
+		relays         int
+		expectedRelays int
+		expectedErr    error
+		expectedAppKey string
+	}{
+		{
+			name:           "Relays up to the daily limit of the plan are served",
+			limit:          repository.AppLimit{PayPlan: repository.PayPlan{Type: repository.FreetierV0}},
+			blockchainID:   "0021",
+			relays:         3,
+			expectedRelays: 2,
+			expectedErr:    ErrDailyLimitExceeded,
+		},
+		{
+			name:           "Custom limit replaces the daily limit of the plan",
+			limit:          repository.AppLimit{PayPlan: repository.PayPlan{Type: repository.Enterprise}, CustomLimit: 1},
+			blockchainID:   "0040",
+			relays:         2,
+			expectedRelays: 1,
+			expectedErr:    ErrDailyLimitExceeded,
+		},
+		{
+			name:         "Blockchain not allowed by the plan is rejected",
+			limit:        repository.AppLimit{PayPlan: repository.PayPlan{Type: repository.FreetierV0}},
+			blockchainID: "0040",
+			relays:       1,
+			expectedErr:  ErrChainNotAllowed,
+		},
+		{
+			name:           "Relays over the rate limit of the plan are rejected",
+			limit:          repository.AppLimit{PayPlan: repository.PayPlan{Type: repository.PayAsYouGoV0}},
+			blockchainID:   "0040",
+			relays:         2,
+			expectedRelays: 1,
+			expectedErr:    ErrRateLimitExceeded,
+			expectedAppKey: "gwaat_app_public_key",
+		},
+		{
+			name:           "AAT plan of the pay plan overrides the settings",
+			limit:          repository.AppLimit{PayPlan: repository.PayPlan{Type: repository.TestPlanV0}},
+			blockchainID:   "0040",
+			relays:         1,
+			expectedRelays: 1,
+			expectedAppKey: appSigner.GetPublicKey(),
+		},
+		{
+			name:         "Plan missing from the catalogue is rejected",
+			limit:        repository.AppLimit{PayPlan: repository.PayPlan{Type: repository.TestPlan10K}},
+			blockchainID: "0021",
+			relays:       1,
+			expectedErr:  repository.ErrInvalidPayPlanType,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			pocketRelayer := &fakePocketRelayer{}
+			rs := relayServer{
+				log:            logger.New(),
+				settings:       DefaultSettings(),
+				sessionManager: fakeSessionManager{},
+				relayer:        pocketRelayer,
+				nodeSticker:    &fakeNodeSticker{},
+				repository: fakeRepository{
+					apps: map[string]repository.Application{
+						"app-1": {
+							ID: "app-1",
+							GatewayAAT: repository.GatewayAAT{
+								ApplicationPublicKey: "gwaat_app_public_key",
+								PrivateKey:           repository.Secret(appSigner.GetPrivateKey()),
+							},
+							Limit: tc.limit,
+						},
+					},
+					blockchains: map[string]repository.Blockchain{
+						"0021": {ID: "0021", Active: true},
+						"0040": {ID: "0040", Active: true},
+					},
+					payPlans: payPlans,
+				},
+			}
+
+			var err error
+			for i := 0; i < tc.relays; i++ {
+				_, err = rs.RelayWithApp(RelayOptions{ApplicationID: "app-1", BlockchainID: tc.blockchainID})
+			}
+			if !errors.Is(err, tc.expectedErr) {
+				t.Errorf("Expected error: %v, got: %v", tc.expectedErr, err)
+			}
+			if len(pocketRelayer.relays) != tc.expectedRelays {
+				t.Fatalf("Expected %d relays to be sent, got: %d", tc.expectedRelays, len(pocketRelayer.relays))
+			}
+			if tc.expectedAppKey != "" && pocketRelayer.relays[0].PocketAAT.AppPubKey != tc.expectedAppKey {
+				t.Errorf("Expected AAT of application key %s, got: %s", tc.expectedAppKey, pocketRelayer.relays[0].PocketAAT.AppPubKey)
+			}
+		})
+	}
+}
+
+func TestRelayWithAppPayPlanFailedRelay(t *testing.T) {
+	errRelay := errors.New("node timed out")
+	pocketRelayer := &fakePocketRelayer{relayError: errRelay}
+	rs := relayServer{
+		log:            logger.New(),
+		settings:       DefaultSettings(),
+		sessionManager: fakeSessionManager{},
+		relayer:        pocketRelayer,
+		nodeSticker:    &fakeNodeSticker{},
+		repository: fakeRepository{
+			apps: map[string]repository.Application{
+				"app-1": {ID: "app-1", Limit: repository.AppLimit{PayPlan: repository.PayPlan{Type: repository.FreetierV0}}},
+			},
+			blockchains: map[string]repository.Blockchain{"0021": {ID: "0021", Active: true}},
+			payPlans: repository.PayPlans{
+				repository.FreetierV0: {Type: repository.FreetierV0, Limit: 1},
+			},
+		},
+	}
+
+	if _, err := rs.RelayWithApp(RelayOptions{ApplicationID: "app-1", BlockchainID: "0021"}); !errors.Is(err, errRelay) {
+		t.Fatalf("Expected error: %v, got: %v", errRelay, err)
+	}
+
+	// The failed relay is refunded, leaving the limits of the plan to the next relay
+	pocketRelayer.relayError = nil
+	if _, err := rs.RelayWithApp(RelayOptions{ApplicationID: "app-1", BlockchainID: "0021"}); err != nil {
+		t.Fatalf("Expected failed relay not to be counted, got: %v", err)
+	}
+	if _, err := rs.RelayWithApp(RelayOptions{ApplicationID: "app-1", BlockchainID: "0021"}); !errors.Is(err, ErrDailyLimitExceeded) {
+		t.Errorf("Expected served relay to be counted, got: %v", err)
+	}
+}
+
+func TestPlanUsage(t *testing.T) {
+	var usage planUsage
+	plan := repository.PayPlan{Limit: 2, RateLimit: 1}
+	now := time.Date(2022, time.January, 1, 23, 59, 58, 0, time.UTC)
+
+	if err := usage.reserve("app-1", plan, now); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := usage.reserve("app-1", plan, now); !errors.Is(err, ErrRateLimitExceeded) {
+		t.Errorf("Expected error: %v, got: %v", ErrRateLimitExceeded, err)
+	}
+	if err := usage.reserve("app-2", plan, now); err != nil {
+		t.Errorf("Expected applications to be counted separately, got: %v", err)
+	}
+
+	// Rejected relays are not counted against the daily limit
+	if err := usage.reserve("app-1", plan, now.Add(time.Second)); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := usage.reserve("app-1", plan, now.Add(1500*time.Millisecond)); !errors.Is(err, ErrDailyLimitExceeded) {
+		t.Errorf("Expected error: %v, got: %v", ErrDailyLimitExceeded, err)
+	}
+
+	// Daily counts are reset on the next UTC day
+	if err := usage.reserve("app-1", plan, now.Add(2*time.Second)); err != nil {
+		t.Errorf("Expected daily count to be reset, got: %v", err)
+	}
+
+	// Refunded relays are removed from the counts of the current day and second
+	usage.refund("app-1", now.Add(2*time.Second))
+	if err := usage.reserve("app-1", plan, now.Add(2*time.Second)); err != nil {
+		t.Errorf("Expected refunded relay not to be counted, got: %v", err)
+	}
+
+	// Relays reserved in a past second are only refunded from the daily count
+	if err := usage.reserve("app-1", plan, now.Add(3*time.Second)); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	usage.refund("app-1", now.Add(2*time.Second))
+	if err := usage.reserve("app-1", plan, now.Add(3*time.Second)); !errors.Is(err, ErrRateLimitExceeded) {
+		t.Errorf("Expected error: %v, got: %v", ErrRateLimitExceeded, err)
+	}
+}
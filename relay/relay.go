@@ -1,8 +1,11 @@
 package relay
 
 import (
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"math/rand"
+	"net/http"
 	"strings"
 	"time"
 
@@ -19,7 +22,14 @@ import (
 )
 
 type RelayResponse struct {
-	Err error
+	// Warning, when set, is returned to the user along with a successful relay
+	Warning string
+	// Data is the response of the node to the relay
+	Data string
+	// ApplicationID and NodeAddress identify the application and node that served the relay: setting them
+	// in the options of following relays pins those to the same node, e.g. for the messages of a WebSocket connection
+	ApplicationID string
+	NodeAddress   string
 }
 
 // TODO: this is needed because pocket-go does not provide an interface yet, which is needed for unit-testing.
@@ -35,19 +45,25 @@ type RelayOptions struct {
 	RawData string
 	Host    string
 	// TODO: may need special handling if request are coming from an ALB (application load balancer)
-	IP             string
-	Path           string
-	RequestID      uuid.UUID
-	BlockchainID   string
-	RPCID          int
+	IP   string
+	Path string
+	// Query string of the request, without the leading '?'
+	Query        string
+	RequestID    uuid.UUID
+	BlockchainID string
+	RPCID        int
+	// ApplicationID of load balancer relays, if set, pins the relay to the application of the load balancer
 	ApplicationID  string
 	LoadBalancerID string
+	// NodeAddress, if set, pins the relay to the node of the session: the relay fails with ErrNodeUnavailable
+	// if the node is no longer part of the session, e.g. once the session has rotated
+	NodeAddress string
 }
 
 //TODO: define custom user-errors: e.g. invalid applicationID + error codes should match portal-ai
 type Relayer interface {
-	RelayWithApp(RelayOptions) error
-	RelayWithLb(RelayOptions) error
+	RelayWithApp(RelayOptions) (RelayResponse, error)
+	RelayWithLb(RelayOptions) (RelayResponse, error)
 }
 
 type relayServer struct {
@@ -57,7 +73,11 @@ type relayServer struct {
 
 	settings RelayerSettings
 	relayer  pocketRelayer
-	log      *logger.Logger
+	// clientPublicKey is the public key of the gateway's client, i.e. the key signing the relays
+	clientPublicKey string
+	// usage counts the relays of each application against the limits of its pay plan
+	usage planUsage
+	log   *logger.Logger
 }
 
 func NewRelayServer(rpcUrls []string, privateKey string, settings RelayerSettings, r repository.Repository, sessionManager session.SessionManager, log *logger.Logger) (Relayer, error) {
@@ -73,11 +93,13 @@ func NewRelayServer(rpcUrls []string, privateKey string, settings RelayerSetting
 	p := relayer.NewRelayer(reqSigner, rpcProvider)
 
 	return &relayServer{
-		repository:     r,
-		sessionManager: sessionManager,
-		relayer:        p,
-		settings:       settings,
-		log:            log,
+		repository:      r,
+		sessionManager:  sessionManager,
+		nodeSticker:     sticky.NewStickyNodes(settings.Sticky, log),
+		relayer:         p,
+		settings:        settings,
+		clientPublicKey: reqSigner.GetPublicKey(),
+		log:             log,
 	}, nil
 }
 
@@ -100,6 +122,9 @@ func chainDetails(r repository.Repository, o RelayOptions) func(*RelayDetails) e
 		if err != nil {
 			return err
 		}
+		if !blockchain.Active {
+			return fmt.Errorf("%w: %s", ErrBlockchainInactive, blockchain.ID)
+		}
 		d.Blockchain = blockchain
 		return nil
 	}
@@ -129,7 +154,7 @@ func detailsBuilder(r repository.Repository, o RelayOptions, builders map[string
 	return &d, nil
 }
 
-func (r *relayServer) RelayWithApp(relayOptions RelayOptions) error {
+func (r *relayServer) RelayWithApp(relayOptions RelayOptions) (RelayResponse, error) {
 	// TODO: metrics recorder
 
 	log := r.log.WithFields(logger.Fields{"relayOptions": relayOptions})
@@ -141,9 +166,20 @@ func (r *relayServer) RelayWithApp(relayOptions RelayOptions) error {
 	d, err := detailsBuilder(r.repository, relayOptions, builders)
 	if err != nil {
 		log.WithFields(logger.Fields{"error": err}).Warn("Error running builder")
-		return err
+		return RelayResponse{}, err
 	}
-	return r.sendRelay(d)
+
+	var response RelayResponse
+	switch r.settings.statusPolicy(d.Application.Status) {
+	case StatusPolicyReject:
+		log.WithFields(logger.Fields{"status": d.Application.Status}).Warn("Application status does not allow relays")
+		return RelayResponse{}, fmt.Errorf("%w: application %s status %s", ErrApplicationStatus, d.Application.ID, d.Application.Status)
+	case StatusPolicyWarn:
+		response.Warning = fmt.Sprintf("application status is %s", d.Application.Status)
+	}
+
+	err = r.sendRelay(d, &response)
+	return response, err
 }
 
 type RelayDetails struct {
@@ -154,7 +190,7 @@ type RelayDetails struct {
 	StickyDetails sticky.StickyDetails
 }
 
-func (r *relayServer) RelayWithLb(relayOptions RelayOptions) error {
+func (r *relayServer) RelayWithLb(relayOptions RelayOptions) (RelayResponse, error) {
 	log := r.log.WithFields(logger.Fields{"relayOptions": relayOptions})
 
 	// TODO: verify if order matters here: using maps means no guaranteed order in calling detail builders
@@ -165,14 +201,14 @@ func (r *relayServer) RelayWithLb(relayOptions RelayOptions) error {
 	details, err := detailsBuilder(r.repository, relayOptions, builders)
 	if err != nil {
 		log.WithFields(logger.Fields{"error": err}).Warn("Error running builder")
-		return err
+		return RelayResponse{}, err
 	}
 	log = log.WithFields(logger.Fields{"RelayDetails": details})
 
 	// TODO: Gigastake Redirect
 	if details.LoadBalancer.GigastakeRedirect {
 		log.Warn("Gigastake redirect not implemented yet")
-		return err
+		return RelayResponse{}, err
 	}
 
 	sd := r.nodeSticker.GetStickyDetails(
@@ -182,11 +218,15 @@ func (r *relayServer) RelayWithLb(relayOptions RelayOptions) error {
 	)
 	log = log.WithFields(logger.Fields{"stickyDetails": sd})
 
-	selectedApp, err := r.fetchLoadBalancerApplication(details.LoadBalancer, sd.StickyClient.PreferredApplicationID)
+	preferredApplicationID := sd.StickyClient.PreferredApplicationID
+	if relayOptions.ApplicationID != "" {
+		preferredApplicationID = relayOptions.ApplicationID
+	}
+	selectedApp, err := r.fetchLoadBalancerApplication(details.LoadBalancer, preferredApplicationID)
 	if err != nil {
 		// TODO: error code: -32055))
 		log.WithFields(logger.Fields{"error": err}).Warn("Error selecting an application for load balancer")
-		return err
+		return RelayResponse{}, err
 	}
 
 	details.Application = selectedApp
@@ -196,69 +236,188 @@ func (r *relayServer) RelayWithLb(relayOptions RelayOptions) error {
 	details.StickyDetails = sd
 	log.WithFields(logger.Fields{"RelayDetails": details}).Info("Sending relay")
 
-	return r.sendRelay(details)
+	var response RelayResponse
+	err = r.sendRelay(details, &response)
+	return response, err
 }
 
 type RelayerSettings struct {
+	// AatPlan is used for applications whose pay plan has no AAT plan set nor an entry in AatPlans
 	AatPlan
-	DefaultLogLimitBlocks      int
-	DefaultStickyOptions       repository.StickyOptions
-	DefaultClientStickyOptions sticky.StickyClient
+	AatPlans              map[repository.PayPlanType]AatPlan
+	DefaultLogLimitBlocks int
+	// Sticky configures the stickiness of relays to nodes, its default options apply to load balancers without any
+	Sticky sticky.StickyNodeSettings
+	// AppStatusPolicies determines how relays are handled based on the application's status.
+	// Statuses not present in the map are rejected.
+	AppStatusPolicies map[repository.AppStatus]StatusPolicy
+}
+
+func (s RelayerSettings) statusPolicy(status repository.AppStatus) StatusPolicy {
+	if policy, ok := s.AppStatusPolicies[status]; ok {
+		return policy
+	}
+	return StatusPolicyReject
+}
+
+type StatusPolicy string
+
+const (
+	StatusPolicyServe  StatusPolicy = "serve"
+	StatusPolicyWarn   StatusPolicy = "warn"
+	StatusPolicyReject StatusPolicy = "reject"
+)
+
+var (
+	ErrBlockchainInactive = &repository.CodedError{Code: -32057, Message: "blockchain is not active"}
+	ErrApplicationStatus  = &repository.CodedError{Code: -32056, Message: "application status does not allow relays"}
+	// ErrNodeUnavailable error when the node a relay is pinned to is not part of the session
+	ErrNodeUnavailable = errors.New("pinned node is not in the session")
+)
+
+// DefaultAppStatusPolicies serves staked applications, warns on applications being removed,
+// and rejects applications which are not (yet or anymore) staked.
+func DefaultAppStatusPolicies() map[repository.AppStatus]StatusPolicy {
+	return map[repository.AppStatus]StatusPolicy{
+		"":                                 StatusPolicyServe, // legacy applications may have no status
+		repository.InService:               StatusPolicyServe,
+		repository.Ready:                   StatusPolicyServe,
+		repository.Swappable:               StatusPolicyServe,
+		repository.AwaitingGracePeriod:     StatusPolicyWarn,
+		repository.AwaitingUnstaking:       StatusPolicyWarn,
+		repository.AwaitingFundsRemoval:    StatusPolicyWarn,
+		repository.Orphaned:                StatusPolicyWarn,
+		repository.AwaitingFreetierFunds:   StatusPolicyReject,
+		repository.AwaitingFreetierStaking: StatusPolicyReject,
+		repository.AwaitingFunds:           StatusPolicyReject,
+		repository.AwaitingSlotFunds:       StatusPolicyReject,
+		repository.AwaitingSlotStaking:     StatusPolicyReject,
+		repository.AwaitingStaking:         StatusPolicyReject,
+		repository.Decomissioned:           StatusPolicyReject,
+	}
 }
 
-// TODO: move to repository package?
-type AatPlan string
+type AatPlan = repository.AatPlan
 
 const (
-	AatPlanPremium  AatPlan = "Premium"
-	AatPlanFreemium AatPlan = "Freemium"
+	AatPlanPremium  = repository.AatPlanPremium
+	AatPlanFreemium = repository.AatPlanFreemium
 )
 
-func aatFromApp(app *repository.Application, aatPlan AatPlan) provider.PocketAAT {
-	if aatPlan == AatPlanFreemium {
+const defaultAATVersion = "0.0.1"
+
+var (
+	ErrMissingAppPrivateKey = errors.New("application has no private key to sign a premium AAT")
+)
+
+// aatPlan returns the AAT plan of the pay plan if set, falling back to the settings
+func (s RelayerSettings) aatPlan(payPlan repository.PayPlan) AatPlan {
+	if payPlan.AatPlan != "" {
+		return payPlan.AatPlan
+	}
+	if plan, ok := s.AatPlans[payPlan.Type]; ok {
+		return plan
+	}
+	return s.AatPlan
+}
+
+// aatFromApp returns the AAT to relay with: Freemium applications use the gateway AAT as stored in the repository,
+// while for Premium applications a new AAT, for the gateway's client key, is signed with the application's private key.
+func aatFromApp(app *repository.Application, aatPlan AatPlan, clientPublicKey string) (provider.PocketAAT, error) {
+	if aatPlan != AatPlanPremium {
 		return provider.PocketAAT{
 			Version:      app.GatewayAAT.Version,
 			ClientPubKey: app.GatewayAAT.ClientPublicKey,
 			AppPubKey:    app.GatewayAAT.ApplicationPublicKey,
 			Signature:    app.GatewayAAT.ApplicationSignature,
-		}
+		}, nil
+	}
+
+	if app.GatewayAAT.PrivateKey == "" {
+		return provider.PocketAAT{}, fmt.Errorf("%w: %s", ErrMissingAppPrivateKey, app.ID)
+	}
+	appSigner, err := signer.NewSignerFromPrivateKey(app.GatewayAAT.PrivateKey.Reveal())
+	if err != nil {
+		return provider.PocketAAT{}, fmt.Errorf("Error creating application signer: %w", err)
+	}
+
+	version := app.GatewayAAT.Version
+	if version == "" {
+		version = defaultAATVersion
+	}
+	aat := provider.PocketAAT{
+		Version:      version,
+		AppPubKey:    appSigner.GetPublicKey(),
+		ClientPubKey: clientPublicKey,
 	}
 
-	return provider.PocketAAT{
-		Version:      app.GatewayAAT.Version,
-		ClientPubKey: app.GatewayAAT.ClientPublicKey,
-		AppPubKey:    app.GatewayAAT.ApplicationPublicKey,
-		Signature:    app.GatewayAAT.ApplicationSignature,
+	hash, err := relayer.HashAAT(&aat)
+	if err != nil {
+		return provider.PocketAAT{}, fmt.Errorf("Error hashing AAT: %w", err)
+	}
+	rawHash, err := hex.DecodeString(hash)
+	if err != nil {
+		return provider.PocketAAT{}, fmt.Errorf("Error decoding AAT hash: %w", err)
+	}
+	signature, err := appSigner.SignBytes(rawHash)
+	if err != nil {
+		return provider.PocketAAT{}, fmt.Errorf("Error signing AAT: %w", err)
 	}
+	aat.Signature = hex.EncodeToString(signature)
+
+	return aat, nil
 }
 
 func FreemiumSettings() RelayerSettings {
 	return RelayerSettings{
-		AatPlan: AatPlanFreemium,
+		AatPlan:           AatPlanFreemium,
+		AppStatusPolicies: DefaultAppStatusPolicies(),
+	}
+}
+
+// DefaultSettings relays with the Premium AAT plan for paying applications, and the Freemium plan for the rest
+func DefaultSettings() RelayerSettings {
+	s := FreemiumSettings()
+	s.AatPlans = map[repository.PayPlanType]AatPlan{
+		repository.PayAsYouGoV0: AatPlanPremium,
+		repository.Enterprise:   AatPlanPremium,
 	}
+	return s
 }
 
-// TODO: return code -32058 in repository package when building LB list (for LBs with no valid app)
 func (r *relayServer) fetchLoadBalancerApplication(lb repository.LoadBalancer, preferredApplicationID string) (*repository.Application, error) {
 	// TODO: add a service that maintains verified Application IDs for a LB: it invalidates and reloads every set interval
+	if err := lb.Validate(); err != nil {
+		return &repository.Application{}, err
+	}
 
-	apps := lb.Applications
-	if len(apps) < 1 {
-		// TODO: return error code -32058
-		return &repository.Application{}, fmt.Errorf("Load Balancer %s configuration invalid: no valid applications", lb.ID)
+	// Only applications with a status that is fully served are selected: e.g. removed applications are excluded
+	selectable := func(app *repository.Application) bool {
+		return r.settings.statusPolicy(app.Status) == StatusPolicyServe
 	}
 
-	// TODO: remove once LBs applications are returned in a map[AppID]*Application
-	for _, app := range apps {
-		if app.ID == preferredApplicationID {
-			return app, nil
+	if app, ok := lb.Applications[preferredApplicationID]; ok && selectable(app) {
+		return app, nil
+	}
+
+	// Iterate over ApplicationIDs, rather than the map, to keep the selection order stable
+	var apps []*repository.Application
+	for _, id := range lb.ApplicationIDs {
+		if app, ok := lb.Applications[id]; ok && selectable(app) {
+			apps = append(apps, app)
 		}
 	}
+	if len(apps) < 1 {
+		return &repository.Application{}, fmt.Errorf("%w: load balancer %s", repository.ErrNoValidApplications, lb.ID)
+	}
 
 	return apps[rand.New(rand.NewSource(time.Now().UnixNano())).Intn(len(apps))], nil
 }
 
-func (r *relayServer) sendRelay(details *RelayDetails) error {
+// sendRelay relays to a node of the application's session, and sets the node's response and the application
+// and node that served the relay on the response. Relays that are not served are not counted against the
+// application's pay plan.
+func (r *relayServer) sendRelay(details *RelayDetails, response *RelayResponse) (err error) {
 
 	log := r.log.WithFields(logger.Fields{"relayDetails": details})
 
@@ -266,7 +425,27 @@ func (r *relayServer) sendRelay(details *RelayDetails) error {
 	// secretKeyValidator, // checkSecretKey(application, secretKeyDetails)
 	// whilelistValidator, // whitelistValidator: 1.origins (err code: -32060), 2.userAgents: (err code: -32061)
 
-	pocketAat := aatFromApp(details.Application, r.settings.AatPlan)
+	payPlan, err := r.payPlan(details.Application)
+	if err != nil {
+		log.WithFields(logger.Fields{"error": err}).Warn("Error getting application pay plan")
+		return err
+	}
+	reservedAt := time.Now()
+	if err := r.applyPayPlan(details, payPlan, reservedAt); err != nil {
+		log.WithFields(logger.Fields{"error": err, "payPlan": payPlan.Type}).Warn("Pay plan does not allow relay")
+		return err
+	}
+	defer func() {
+		if err != nil {
+			r.refundPayPlan(details, reservedAt)
+		}
+	}()
+
+	pocketAat, err := aatFromApp(details.Application, r.settings.aatPlan(payPlan), r.clientPublicKey)
+	if err != nil {
+		log.WithFields(logger.Fields{"error": err}).Warn("Error building AAT")
+		return err
+	}
 	log = log.WithFields(logger.Fields{"pocketAAT": pocketAat})
 
 	session, err := r.sessionManager.GetSession(session.Key{PublicKey: pocketAat.AppPubKey, BlockchainID: details.Blockchain.ID})
@@ -287,7 +466,16 @@ func (r *relayServer) sendRelay(details *RelayDetails) error {
 	// EVM/non-EVM restrictions
 	// -------------------
 	var node *provider.Node
-	node = nodeFromAddress(details.StickyDetails.StickyClient.PreferredNodeAddress, session)
+	if address := details.RelayOptions.NodeAddress; address != "" {
+		node = nodeFromAddress(address, session)
+		if node == nil {
+			log.WithFields(logger.Fields{"nodeAddress": address}).Warn("Pinned node is not in the session")
+			return fmt.Errorf("%w: %s", ErrNodeUnavailable, address)
+		}
+	}
+	if node == nil {
+		node = nodeFromAddress(details.StickyDetails.StickyClient.PreferredNodeAddress, session)
+	}
 	if node == nil {
 		node = firstNode(session)
 	}
@@ -308,20 +496,22 @@ func (r *relayServer) sendRelay(details *RelayDetails) error {
 	log.Info("Sending relay")
 
 	relay := relayer.Input{
-		Method:     "POST",
+		Method:     relayMethod(details.RelayOptions),
 		PocketAAT:  &pocketAat,
 		Session:    session,
 		Node:       node,
 		Blockchain: details.Blockchain.ID,
 		Data:       details.RelayOptions.RawData,
-		Path:       details.RelayOptions.Path,
+		Path:       relayPath(details.RelayOptions),
 	}
 
 	relayOutput, err := r.relayer.Relay(&relay, nil)
 	if err != nil {
 		log.WithFields(logger.Fields{"error": err}).Info("Error relaying")
 		// TODO: differentiate user errors from node errors
-		err := r.nodeSticker.Failure(&details.StickyDetails)
+		if err := r.nodeSticker.Failure(&details.StickyDetails); err != nil {
+			log.WithFields(logger.Fields{"error": err}).Info("Error setting failure")
+		}
 		return err
 	}
 
@@ -333,7 +523,14 @@ func (r *relayServer) sendRelay(details *RelayDetails) error {
 
 	log.WithFields(logger.Fields{"relayOutput": relayOutput}).Info("Received relay response")
 
-	return parseRelayResponse(relayOutput)
+	data, err := parseRelayResponse(relayOutput)
+	if err != nil {
+		return err
+	}
+	response.Data = data
+	response.ApplicationID = details.Application.ID
+	response.NodeAddress = node.Address
+	return nil
 }
 
 func nodeFromAddress(address string, session *provider.Session) *provider.Node {
@@ -352,9 +549,28 @@ func firstNode(session *provider.Session) *provider.Node {
 	return session.Nodes[0]
 }
 
+// relayMethod returns the HTTP method of the relay, POST unless set, e.g. GET for REST chains
+func relayMethod(o RelayOptions) string {
+	if o.Method == "" {
+		return http.MethodPost
+	}
+	return o.Method
+}
+
+// relayPath returns the path of the relay, including its query string if any
+func relayPath(o RelayOptions) string {
+	if o.Query == "" {
+		return o.Path
+	}
+	return o.Path + "?" + o.Query
+}
+
 // TODO: This likely belongs in pocket-go: a function that can process the Output struct returned by pocket-go/relayer
-func parseRelayResponse(r *relayer.Output) error {
-	return nil
+func parseRelayResponse(r *relayer.Output) (string, error) {
+	if r == nil || r.RelayOutput == nil {
+		return "", nil
+	}
+	return r.RelayOutput.Response, nil
 }
 
 func stickyKeyBuilder(d *RelayDetails) sticky.KeyBuilder {
@@ -364,7 +580,10 @@ func stickyKeyBuilder(d *RelayDetails) sticky.KeyBuilder {
 			BlockchainID: d.Blockchain.ID,
 		}
 
-		k.ApplicationID = d.Application.ID
+		// Load balancer relays are keyed before their application is selected, from the key's sticky client
+		if d.Application != nil {
+			k.ApplicationID = d.Application.ID
+		}
 		k.LoadBalancerID = d.LoadBalancer.ID
 		return k
 	}
@@ -1,5 +1,475 @@
 package relay
 
+import (
+	"crypto/ed25519"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/pokt-foundation/pocket-go/provider"
+	"github.com/pokt-foundation/pocket-go/relayer"
+	"github.com/pokt-foundation/pocket-go/signer"
+	logger "github.com/sirupsen/logrus"
+
+	"github.com/pokt-foundation/portal-api-go/repository"
+	"github.com/pokt-foundation/portal-api-go/session"
+	"github.com/pokt-foundation/portal-api-go/sticky"
+)
+
+func TestRelayWithAppStatusPolicy(t *testing.T) {
+	testCases := []struct {
+		name            string
+		status          repository.AppStatus
+		chainActive     bool
+		expectedWarning string
+		expectedErr     error
+	}{
+		{
+			name:        "Application in service is served",
+			status:      repository.InService,
+			chainActive: true,
+		},
+		{
+			name:            "Application in grace period is served with a warning",
+			status:          repository.AwaitingGracePeriod,
+			chainActive:     true,
+			expectedWarning: "application status is AWAITING_GRACE_PERIOD",
+		},
+		{
+			name:        "Decomissioned application is rejected",
+			status:      repository.Decomissioned,
+			chainActive: true,
+			expectedErr: ErrApplicationStatus,
+		},
+		{
+			name:        "Relay to an inactive blockchain is rejected",
+			status:      repository.InService,
+			expectedErr: ErrBlockchainInactive,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			pocketRelayer := &fakePocketRelayer{}
+			rs := relayServer{
+				log:            logger.New(),
+				settings:       FreemiumSettings(),
+				sessionManager: fakeSessionManager{},
+				relayer:        pocketRelayer,
+				nodeSticker:    &fakeNodeSticker{},
+				repository: fakeRepository{
+					apps: map[string]repository.Application{
+						"app-1": {ID: "app-1", Status: tc.status},
+					},
+					blockchains: map[string]repository.Blockchain{
+						"0021": {ID: "0021", Active: tc.chainActive},
+					},
+				},
+			}
+
+			response, err := rs.RelayWithApp(RelayOptions{ApplicationID: "app-1", BlockchainID: "0021"})
+			if tc.expectedErr != nil {
+				if !errors.Is(err, tc.expectedErr) {
+					t.Fatalf("Expected error: %v, got: %v", tc.expectedErr, err)
+				}
+				if len(pocketRelayer.relays) != 0 {
+					t.Errorf("Expected no relays to be sent, got: %d", len(pocketRelayer.relays))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if response.Warning != tc.expectedWarning {
+				t.Errorf("Expected warning: %q, got: %q", tc.expectedWarning, response.Warning)
+			}
+			if len(pocketRelayer.relays) != 1 {
+				t.Errorf("Expected 1 relay to be sent, got: %d", len(pocketRelayer.relays))
+			}
+		})
+	}
+}
+
+func TestRelayPinnedNode(t *testing.T) {
+	testCases := []struct {
+		name        string
+		nodeAddress string
+		expected    RelayResponse
+		expectedErr error
+	}{
+		{
+			name:     "Relay returns the response and the node that served it",
+			expected: RelayResponse{Data: `{"result":"0x1"}`, ApplicationID: "app-1", NodeAddress: "node-1"},
+		},
+		{
+			name:        "Relay pinned to a node of the session is sent to the node",
+			nodeAddress: "node-1",
+			expected:    RelayResponse{Data: `{"result":"0x1"}`, ApplicationID: "app-1", NodeAddress: "node-1"},
+		},
+		{
+			name:        "Relay pinned to a node no longer in the session returns error",
+			nodeAddress: "node-2",
+			expectedErr: ErrNodeUnavailable,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			pocketRelayer := &fakePocketRelayer{response: `{"result":"0x1"}`}
+			rs := relayServer{
+				log:            logger.New(),
+				settings:       FreemiumSettings(),
+				sessionManager: fakeSessionManager{},
+				relayer:        pocketRelayer,
+				nodeSticker:    &fakeNodeSticker{},
+				repository: fakeRepository{
+					apps: map[string]repository.Application{
+						"app-1": {ID: "app-1"},
+					},
+					blockchains: map[string]repository.Blockchain{
+						"0021": {ID: "0021", Active: true},
+					},
+				},
+			}
+
+			response, err := rs.RelayWithApp(RelayOptions{ApplicationID: "app-1", BlockchainID: "0021", NodeAddress: tc.nodeAddress})
+			if tc.expectedErr != nil {
+				if !errors.Is(err, tc.expectedErr) {
+					t.Fatalf("Expected error: %v, got: %v", tc.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tc.expected, response); diff != "" {
+				t.Errorf("unexpected response (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestRelayError(t *testing.T) {
+	errRelay := errors.New("node timed out")
+	nodeSticker := &fakeNodeSticker{}
+	rs := relayServer{
+		log:            logger.New(),
+		settings:       FreemiumSettings(),
+		sessionManager: fakeSessionManager{},
+		relayer:        &fakePocketRelayer{relayError: errRelay},
+		nodeSticker:    nodeSticker,
+		repository: fakeRepository{
+			apps:        map[string]repository.Application{"app-1": {ID: "app-1"}},
+			blockchains: map[string]repository.Blockchain{"0021": {ID: "0021", Active: true}},
+		},
+	}
+
+	// The error of the relay is returned, rather than the result of recording the failure of the node
+	if _, err := rs.RelayWithApp(RelayOptions{ApplicationID: "app-1", BlockchainID: "0021"}); !errors.Is(err, errRelay) {
+		t.Errorf("Expected error: %v, got: %v", errRelay, err)
+	}
+	if len(nodeSticker.failure) != 1 {
+		t.Errorf("Expected relay failure to be recorded, got: %d", len(nodeSticker.failure))
+	}
+}
+
+func TestNewRelayServer(t *testing.T) {
+	reqSigner, err := signer.NewRandomSigner()
+	if err != nil {
+		t.Fatalf("Error creating signer: %v", err)
+	}
+	repo := fakeRepository{
+		apps:        map[string]repository.Application{"app-1": {ID: "app-1"}},
+		blockchains: map[string]repository.Blockchain{"0021": {ID: "0021", Active: true}},
+	}
+	relayer, err := NewRelayServer([]string{"https://dispatcher"}, reqSigner.GetPrivateKey(), FreemiumSettings(), repo, fakeSessionManager{}, logger.New())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	rs := relayer.(*relayServer)
+	rs.relayer = &fakePocketRelayer{}
+
+	// Relays record the success of their nodes with the relayer's node sticker
+	if _, err := rs.RelayWithApp(RelayOptions{ApplicationID: "app-1", BlockchainID: "0021"}); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+func TestRelayWithStickyLb(t *testing.T) {
+	apps := map[string]*repository.Application{}
+	var appIDs []string
+	for i := 1; i <= 5; i++ {
+		id := fmt.Sprintf("app-%d", i)
+		apps[id] = &repository.Application{ID: id, Status: repository.InService}
+		appIDs = append(appIDs, id)
+	}
+	rs := relayServer{
+		log:            logger.New(),
+		settings:       FreemiumSettings(),
+		sessionManager: fakeSessionManager{},
+		relayer:        &fakePocketRelayer{},
+		nodeSticker:    sticky.NewStickyNodes(sticky.StickyNodeSettings{Duration: time.Minute, RelayLimit: 10, MaxErrors: 10}, logger.New()),
+		repository: fakeRepository{
+			blockchains: map[string]repository.Blockchain{"0021": {ID: "0021", Active: true}},
+			lbs: []repository.LoadBalancer{{
+				ID:             "lb-1",
+				ApplicationIDs: appIDs,
+				Applications:   apps,
+				StickyOptions:  repository.StickyOptions{Stickiness: true, StickyOrigins: []string{"example.com"}},
+			}},
+		},
+	}
+
+	options := RelayOptions{LoadBalancerID: "lb-1", BlockchainID: "0021", IP: "10.0.0.1", Origin: "https://example.com"}
+	first, err := rs.RelayWithLb(options)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// Later relays of the same client stick to the application of the first one
+	for i := 0; i < 5; i++ {
+		response, err := rs.RelayWithLb(options)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if response.ApplicationID != first.ApplicationID {
+			t.Errorf("Expected sticky application %s, got: %s", first.ApplicationID, response.ApplicationID)
+		}
+	}
+}
+
+func TestRelayMethodAndPath(t *testing.T) {
+	testCases := []struct {
+		name           string
+		options        RelayOptions
+		expectedMethod string
+		expectedPath   string
+	}{
+		{
+			name:           "Relay without method is sent as POST",
+			options:        RelayOptions{Path: "/relay/path"},
+			expectedMethod: "POST",
+			expectedPath:   "/relay/path",
+		},
+		{
+			name:           "Relay method and query string are sent to the node",
+			options:        RelayOptions{Method: "GET", Path: "/v1/blocks/latest", Query: "height=10&full=true"},
+			expectedMethod: "GET",
+			expectedPath:   "/v1/blocks/latest?height=10&full=true",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			pocketRelayer := &fakePocketRelayer{}
+			rs := relayServer{
+				log:            logger.New(),
+				settings:       FreemiumSettings(),
+				sessionManager: fakeSessionManager{},
+				relayer:        pocketRelayer,
+				nodeSticker:    &fakeNodeSticker{},
+				repository: fakeRepository{
+					apps:        map[string]repository.Application{"app-1": {ID: "app-1"}},
+					blockchains: map[string]repository.Blockchain{"0021": {ID: "0021", Active: true}},
+				},
+			}
+
+			options := tc.options
+			options.ApplicationID = "app-1"
+			options.BlockchainID = "0021"
+			if _, err := rs.RelayWithApp(options); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if len(pocketRelayer.relays) != 1 {
+				t.Fatalf("Expected 1 relay, got: %d", len(pocketRelayer.relays))
+			}
+			if input := pocketRelayer.relays[0]; input.Method != tc.expectedMethod || input.Path != tc.expectedPath {
+				t.Errorf("Expected relay %s %s, got: %s %s", tc.expectedMethod, tc.expectedPath, input.Method, input.Path)
+			}
+		})
+	}
+}
+
+func TestFetchLoadBalancerApplication(t *testing.T) {
+	inService := &repository.Application{ID: "app-1", Status: repository.InService}
+	removed := &repository.Application{ID: "app-2", Status: repository.AwaitingGracePeriod}
+
+	testCases := []struct {
+		name        string
+		lb          repository.LoadBalancer
+		preferredID string
+		expectedID  string
+		expectedErr error
+	}{
+		{
+			name: "Preferred application is selected",
+			lb: repository.LoadBalancer{
+				ApplicationIDs: []string{"app-1", "app-3"},
+				Applications: map[string]*repository.Application{
+					"app-1": inService,
+					"app-3": {ID: "app-3", Status: repository.InService},
+				},
+			},
+			preferredID: "app-3",
+			expectedID:  "app-3",
+		},
+		{
+			name: "Removed applications are not selected, even if preferred",
+			lb: repository.LoadBalancer{
+				ApplicationIDs: []string{"app-1", "app-2"},
+				Applications:   map[string]*repository.Application{"app-1": inService, "app-2": removed},
+			},
+			preferredID: "app-2",
+			expectedID:  "app-1",
+		},
+		{
+			name: "Load balancer with only removed applications returns error",
+			lb: repository.LoadBalancer{
+				ApplicationIDs: []string{"app-2"},
+				Applications:   map[string]*repository.Application{"app-2": removed},
+			},
+			expectedErr: repository.ErrNoValidApplications,
+		},
+		{
+			name:        "Load balancer with no applications returns error",
+			lb:          repository.LoadBalancer{},
+			expectedErr: repository.ErrNoValidApplications,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rs := relayServer{settings: FreemiumSettings()}
+			app, err := rs.fetchLoadBalancerApplication(tc.lb, tc.preferredID)
+			if tc.expectedErr != nil {
+				if !errors.Is(err, tc.expectedErr) {
+					t.Fatalf("Expected error: %v, got: %v", tc.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if app.ID != tc.expectedID {
+				t.Errorf("Expected application %s, got: %s", tc.expectedID, app.ID)
+			}
+		})
+	}
+}
+
+func TestSendRelayAAT(t *testing.T) {
+	appSigner, err := signer.NewRandomSigner()
+	if err != nil {
+		t.Fatalf("Error creating signer: %v", err)
+	}
+
+	freemiumAAT := repository.GatewayAAT{
+		Version:              "0.0.1",
+		ClientPublicKey:      "gwaat_client_public_key",
+		ApplicationPublicKey: "gwaat_app_public_key",
+		ApplicationSignature: "gwaat_app_signature",
+	}
+	premiumAAT := freemiumAAT
+	premiumAAT.PrivateKey = repository.Secret(appSigner.GetPrivateKey())
+
+	testCases := []struct {
+		name        string
+		app         repository.Application
+		expected    *provider.PocketAAT
+		expectedErr error
+	}{
+		{
+			name: "Freemium plan relays with the gateway AAT",
+			app: repository.Application{
+				GatewayAAT: premiumAAT,
+				Limit:      repository.AppLimit{PayPlan: repository.PayPlan{Type: repository.FreetierV0}},
+			},
+			expected: &provider.PocketAAT{
+				Version:      "0.0.1",
+				ClientPubKey: "gwaat_client_public_key",
+				AppPubKey:    "gwaat_app_public_key",
+				Signature:    "gwaat_app_signature",
+			},
+		},
+		{
+			name: "Premium plan relays with an AAT signed by the application",
+			app: repository.Application{
+				GatewayAAT: premiumAAT,
+				Limit:      repository.AppLimit{PayPlan: repository.PayPlan{Type: repository.PayAsYouGoV0}},
+			},
+			expected: &provider.PocketAAT{
+				Version:      "0.0.1",
+				ClientPubKey: "relayer_client_public_key",
+				AppPubKey:    appSigner.GetPublicKey(),
+			},
+		},
+		{
+			name: "Premium plan without application private key returns error",
+			app: repository.Application{
+				GatewayAAT: freemiumAAT,
+				Limit:      repository.AppLimit{PayPlan: repository.PayPlan{Type: repository.Enterprise}},
+			},
+			expectedErr: ErrMissingAppPrivateKey,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			pocketRelayer := &fakePocketRelayer{}
+			rs := relayServer{
+				log:             logger.New(),
+				settings:        DefaultSettings(),
+				sessionManager:  fakeSessionManager{},
+				relayer:         pocketRelayer,
+				nodeSticker:     &fakeNodeSticker{},
+				repository:      fakeRepository{payPlans: repository.DefaultPayPlans()},
+				clientPublicKey: "relayer_client_public_key",
+			}
+
+			err := rs.sendRelay(&RelayDetails{Application: &tc.app}, &RelayResponse{})
+			if tc.expectedErr != nil {
+				if !errors.Is(err, tc.expectedErr) {
+					t.Fatalf("Expected error: %v, got: %v", tc.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if len(pocketRelayer.relays) != 1 {
+				t.Fatalf("Expected 1 relay to be sent, got: %d", len(pocketRelayer.relays))
+			}
+
+			actual := pocketRelayer.relays[0].PocketAAT
+			if tc.expected.Signature == "" {
+				verifyAATSignature(t, actual)
+				tc.expected.Signature = actual.Signature
+			}
+			if diff := cmp.Diff(tc.expected, actual); diff != "" {
+				t.Errorf("unexpected value (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func verifyAATSignature(t *testing.T, aat *provider.PocketAAT) {
+	t.Helper()
+
+	hash, err := relayer.HashAAT(aat)
+	if err != nil {
+		t.Fatalf("Error hashing AAT: %v", err)
+	}
+	rawHash, _ := hex.DecodeString(hash)
+	publicKey, _ := hex.DecodeString(aat.AppPubKey)
+	signature, _ := hex.DecodeString(aat.Signature)
+	if !ed25519.Verify(publicKey, rawHash, signature) {
+		t.Errorf("Invalid AAT signature: %s", aat.Signature)
+	}
+}
+
 // TODO: uncomment when test passes
 
 // var (
@@ -137,56 +607,81 @@ package relay
 
 // }
 
-// type fakeSessionManager struct{}
+type fakeSessionManager struct{}
 
-// func (f fakeSessionManager) GetSession(k session.Key) (*provider.Session, error) {
-// 	return &provider.Session{
-// 		Nodes: []*provider.Node{
-// 			{
-// 				Address: "node-1",
-// 			},
-// 		},
-// 	}, nil
-// }
+func (f fakeSessionManager) GetSession(k session.Key) (*provider.Session, error) {
+	return &provider.Session{
+		Nodes: []*provider.Node{
+			{
+				Address: "node-1",
+			},
+		},
+	}, nil
+}
 
-// type fakePocketRelayer struct {
-// 	relays     []*relayer.Input
-// 	relayError error
-// }
+type fakePocketRelayer struct {
+	relays     []*relayer.Input
+	response   string
+	relayError error
+}
 
-// func (f *fakePocketRelayer) Relay(input *relayer.Input, options *provider.RelayRequestOptions) (*relayer.Output, error) {
-// 	f.relays = append(f.relays, input)
-// 	return &relayer.Output{}, f.relayError
-// }
+func (f *fakePocketRelayer) Relay(input *relayer.Input, options *provider.RelayRequestOptions) (*relayer.Output, error) {
+	f.relays = append(f.relays, input)
+	return &relayer.Output{RelayOutput: &provider.RelayOutput{Response: f.response}}, f.relayError
+}
 
-// type fakeRepository struct {
-// 	lbs []repository.LoadBalancer
-// }
+type fakeRepository struct {
+	apps        map[string]repository.Application
+	blockchains map[string]repository.Blockchain
+	lbs         []repository.LoadBalancer
+	payPlans    repository.PayPlans
+}
 
-// func (f fakeRepository) GetLoadBalancer(id string) (repository.LoadBalancer, error) {
-// 	for _, lb := range f.lbs {
-// 		if lb.ID == id {
-// 			return lb, nil
-// 		}
-// 	}
-// 	return repository.LoadBalancer{}, fmt.Errorf("LoadBalancer not found")
-// }
+func (f fakeRepository) GetApplication(id string) (repository.Application, error) {
+	if app, ok := f.apps[id]; ok {
+		return app, nil
+	}
+	return repository.Application{}, fmt.Errorf("Application not found")
+}
 
-// type fakeNodeSticker struct {
-// 	success []*sticky.StickyDetails
-// 	failure []*sticky.StickyDetails
-// }
+func (f fakeRepository) GetBlockchain(alias string) (repository.Blockchain, error) {
+	if b, ok := f.blockchains[alias]; ok {
+		return b, nil
+	}
+	return repository.Blockchain{}, fmt.Errorf("Blockchain not found")
+}
 
-// func (f *fakeNodeSticker) GetStickyDetails(repository.StickyOptions, sticky.KeyBuilder, sticky.OptionsVerifier) sticky.StickyDetails {
-// 	return sticky.StickyDetails{}
-// }
+func (f fakeRepository) GetLoadBalancer(id string) (repository.LoadBalancer, error) {
+	for _, lb := range f.lbs {
+		if lb.ID == id {
+			return lb, nil
+		}
+	}
+	return repository.LoadBalancer{}, fmt.Errorf("LoadBalancer not found")
+}
 
-// func (f *fakeNodeSticker) Success(d *sticky.StickyDetails) error {
-// 	f.success = append(f.success, d)
-// 	return nil
-// }
+func (f fakeRepository) GetPayPlan(planType repository.PayPlanType) (repository.PayPlan, error) {
+	if plan, ok := f.payPlans[planType]; ok {
+		return plan, nil
+	}
+	return repository.PayPlan{}, fmt.Errorf("%w: %s", repository.ErrInvalidPayPlanType, planType)
+}
 
-// func (f *fakeNodeSticker) Failure(d *sticky.StickyDetails) error {
-// 	f.failure = append(f.failure, d)
-// 	return nil
-// }
+type fakeNodeSticker struct {
+	success []*sticky.StickyDetails
+	failure []*sticky.StickyDetails
+}
+
+func (f *fakeNodeSticker) GetStickyDetails(repository.StickyOptions, sticky.KeyBuilder, sticky.OptionsVerifier) sticky.StickyDetails {
+	return sticky.StickyDetails{}
+}
+
+func (f *fakeNodeSticker) Success(d *sticky.StickyDetails) error {
+	f.success = append(f.success, d)
+	return nil
+}
+
+func (f *fakeNodeSticker) Failure(d *sticky.StickyDetails) error {
+	f.failure = append(f.failure, d)
+	return nil
+}
@@ -0,0 +1,219 @@
+package repository
+
+import "sync"
+
+// NotificationDispatcher calls the handlers subscribed to the type of each notification,
+// so consumers do not need to switch on the notification table or data type.
+// Handlers run sequentially, in the order notifications are received.
+type NotificationDispatcher struct {
+	mu sync.RWMutex
+
+	applications         []func(Action, *Application)
+	appLimits            []func(Action, *AppLimit)
+	gatewayAATs          []func(Action, *GatewayAAT)
+	gatewaySettings      []func(Action, *GatewaySettings)
+	whitelistContracts   []func(Action, *WhitelistContract)
+	whitelistMethods     []func(Action, *WhitelistMethod)
+	notificationSettings []func(Action, *NotificationSettings)
+	payPlans             []func(Action, *PayPlan)
+	loadBalancers        []func(Action, *LoadBalancer)
+	stickyOptions        []func(Action, *StickyOptions)
+	lbApps               []func(Action, *LbApp)
+	blockchains          []func(Action, *Blockchain)
+	syncCheckOptions     []func(Action, *SyncCheckOptions)
+	redirects            []func(Action, *Redirect)
+	resyncs              []func()
+}
+
+// NewNotificationDispatcher returns a dispatcher without subscriptions
+func NewNotificationDispatcher() *NotificationDispatcher {
+	return &NotificationDispatcher{}
+}
+
+// OnApplication subscribes the handler to application notifications
+func (d *NotificationDispatcher) OnApplication(handler func(Action, *Application)) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.applications = append(d.applications, handler)
+}
+
+// OnAppLimit subscribes the handler to application limit notifications
+func (d *NotificationDispatcher) OnAppLimit(handler func(Action, *AppLimit)) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.appLimits = append(d.appLimits, handler)
+}
+
+// OnGatewayAAT subscribes the handler to gateway AAT notifications
+func (d *NotificationDispatcher) OnGatewayAAT(handler func(Action, *GatewayAAT)) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.gatewayAATs = append(d.gatewayAATs, handler)
+}
+
+// OnGatewaySettings subscribes the handler to gateway settings notifications
+func (d *NotificationDispatcher) OnGatewaySettings(handler func(Action, *GatewaySettings)) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.gatewaySettings = append(d.gatewaySettings, handler)
+}
+
+// OnWhitelistContract subscribes the handler to whitelisted contracts notifications
+func (d *NotificationDispatcher) OnWhitelistContract(handler func(Action, *WhitelistContract)) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.whitelistContracts = append(d.whitelistContracts, handler)
+}
+
+// OnWhitelistMethod subscribes the handler to whitelisted methods notifications
+func (d *NotificationDispatcher) OnWhitelistMethod(handler func(Action, *WhitelistMethod)) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.whitelistMethods = append(d.whitelistMethods, handler)
+}
+
+// OnNotificationSettings subscribes the handler to notification settings notifications
+func (d *NotificationDispatcher) OnNotificationSettings(handler func(Action, *NotificationSettings)) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.notificationSettings = append(d.notificationSettings, handler)
+}
+
+// OnPayPlan subscribes the handler to pay plan notifications
+func (d *NotificationDispatcher) OnPayPlan(handler func(Action, *PayPlan)) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.payPlans = append(d.payPlans, handler)
+}
+
+// OnLoadBalancer subscribes the handler to load balancer notifications
+func (d *NotificationDispatcher) OnLoadBalancer(handler func(Action, *LoadBalancer)) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.loadBalancers = append(d.loadBalancers, handler)
+}
+
+// OnStickyOptions subscribes the handler to load balancer stickiness options notifications
+func (d *NotificationDispatcher) OnStickyOptions(handler func(Action, *StickyOptions)) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.stickyOptions = append(d.stickyOptions, handler)
+}
+
+// OnLbApp subscribes the handler to notifications of applications added to or removed from load balancers
+func (d *NotificationDispatcher) OnLbApp(handler func(Action, *LbApp)) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.lbApps = append(d.lbApps, handler)
+}
+
+// OnBlockchain subscribes the handler to blockchain notifications
+func (d *NotificationDispatcher) OnBlockchain(handler func(Action, *Blockchain)) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.blockchains = append(d.blockchains, handler)
+}
+
+// OnSyncCheckOptions subscribes the handler to blockchain sync check options notifications
+func (d *NotificationDispatcher) OnSyncCheckOptions(handler func(Action, *SyncCheckOptions)) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.syncCheckOptions = append(d.syncCheckOptions, handler)
+}
+
+// OnRedirect subscribes the handler to redirect notifications
+func (d *NotificationDispatcher) OnRedirect(handler func(Action, *Redirect)) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.redirects = append(d.redirects, handler)
+}
+
+// OnResync subscribes the handler to resync notifications, sent when changes may have been missed
+func (d *NotificationDispatcher) OnResync(handler func()) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.resyncs = append(d.resyncs, handler)
+}
+
+// Dispatch calls the handlers subscribed to the notification type, notifications without subscribers are ignored
+func (d *NotificationDispatcher) Dispatch(n *Notification) {
+	if n == nil {
+		return
+	}
+
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if n.Action == ActionResync {
+		for _, h := range d.resyncs {
+			h()
+		}
+		return
+	}
+
+	switch data := n.Data.(type) {
+	case *Application:
+		for _, h := range d.applications {
+			h(n.Action, data)
+		}
+	case *AppLimit:
+		for _, h := range d.appLimits {
+			h(n.Action, data)
+		}
+	case *GatewayAAT:
+		for _, h := range d.gatewayAATs {
+			h(n.Action, data)
+		}
+	case *GatewaySettings:
+		for _, h := range d.gatewaySettings {
+			h(n.Action, data)
+		}
+	case *WhitelistContract:
+		for _, h := range d.whitelistContracts {
+			h(n.Action, data)
+		}
+	case *WhitelistMethod:
+		for _, h := range d.whitelistMethods {
+			h(n.Action, data)
+		}
+	case *NotificationSettings:
+		for _, h := range d.notificationSettings {
+			h(n.Action, data)
+		}
+	case *PayPlan:
+		for _, h := range d.payPlans {
+			h(n.Action, data)
+		}
+	case *LoadBalancer:
+		for _, h := range d.loadBalancers {
+			h(n.Action, data)
+		}
+	case *StickyOptions:
+		for _, h := range d.stickyOptions {
+			h(n.Action, data)
+		}
+	case *LbApp:
+		for _, h := range d.lbApps {
+			h(n.Action, data)
+		}
+	case *Blockchain:
+		for _, h := range d.blockchains {
+			h(n.Action, data)
+		}
+	case *SyncCheckOptions:
+		for _, h := range d.syncCheckOptions {
+			h(n.Action, data)
+		}
+	case *Redirect:
+		for _, h := range d.redirects {
+			h(n.Action, data)
+		}
+	}
+}
+
+// Listen dispatches every notification received on the channel, until the channel is closed
+func (d *NotificationDispatcher) Listen(notifications <-chan *Notification) {
+	for n := range notifications {
+		d.Dispatch(n)
+	}
+}
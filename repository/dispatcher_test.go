@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestNotificationDispatcher(t *testing.T) {
+	d := NewNotificationDispatcher()
+
+	var got []string
+	d.OnApplication(func(action Action, app *Application) {
+		got = append(got, string(action)+" application "+app.ID)
+	})
+	d.OnLbApp(func(action Action, lbApp *LbApp) {
+		got = append(got, string(action)+" lb app "+lbApp.LbID+"/"+lbApp.AppID)
+	})
+	d.OnLbApp(func(action Action, lbApp *LbApp) {
+		got = append(got, "second handler "+lbApp.AppID)
+	})
+	d.OnPayPlan(func(action Action, plan *PayPlan) {
+		got = append(got, string(action)+" pay plan "+string(plan.Type))
+	})
+	d.OnResync(func() {
+		got = append(got, "resync")
+	})
+
+	notifications := make(chan *Notification, 8)
+	notifications <- &Notification{Table: TableApplications, Action: ActionInsert, Data: &Application{ID: "app-1"}}
+	notifications <- &Notification{Table: TableLbApps, Action: ActionDelete, Data: &LbApp{LbID: "lb-1", AppID: "app-1"}}
+	notifications <- &Notification{Table: TablePayPlans, Action: ActionUpdate, Data: &PayPlan{Type: Enterprise}}
+	// Notifications without subscribers and nil notifications are ignored
+	notifications <- &Notification{Table: TableBlockchains, Action: ActionUpdate, Data: &Blockchain{ID: "0021"}}
+	notifications <- nil
+	notifications <- &Notification{Action: ActionResync}
+	close(notifications)
+
+	d.Listen(notifications)
+
+	expected := []string{
+		"INSERT application app-1",
+		"DELETE lb app lb-1/app-1",
+		"second handler app-1",
+		"UPDATE pay plan ENTERPRISE",
+		"resync",
+	}
+	if diff := cmp.Diff(expected, got); diff != "" {
+		t.Errorf("unexpected value (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,320 @@
+package repository
+
+import (
+	"fmt"
+
+	logger "github.com/sirupsen/logrus"
+)
+
+// NotificationHandler keeps the in-memory repository up to date with changes notified by the database
+type NotificationHandler interface {
+	ApplyNotification(*Notification) error
+	Listen(<-chan *Notification)
+}
+
+// Listen applies every notification received on the channel, until the channel is closed.
+// Resync notifications, sent after notifications may have been missed, trigger an incremental
+// resync if a change source is set, or a full reload otherwise.
+func (c *cachingRepository) Listen(notifications <-chan *Notification) {
+	for n := range notifications {
+		if n == nil {
+			continue
+		}
+		if n.Action == ActionResync {
+			c.resync()
+			continue
+		}
+		if err := c.ApplyNotification(n); err != nil {
+			c.log.WithFields(logger.Fields{"error": err, "table": n.Table, "action": n.Action}).Warn("Error applying notification")
+		}
+	}
+}
+
+// ApplyNotification updates a copy of the current snapshot with the notified change, and swaps it in.
+// Changes of the side tables are merged into their application, load balancer or blockchain, and the
+// watermark is advanced to the update time of the notified entity.
+func (c *cachingRepository) ApplyNotification(n *Notification) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	s := *c.snapshot
+	deleted := n.Action == ActionDelete
+
+	switch data := n.Data.(type) {
+	case *Blockchain:
+		s.blockchains = c.snapshot.blockchains.copy()
+		if deleted {
+			s.blockchains.remove(data.ID)
+			break
+		}
+		b := *data
+		if old, ok := s.blockchains.byID[b.ID]; ok {
+			// Side tables are notified separately
+			b.SyncCheckOptions = old.SyncCheckOptions
+			b.Redirects = old.Redirects
+		}
+		if err := s.blockchains.set(b); err != nil {
+			return err
+		}
+		s.watermark = laterOf(s.watermark, b.UpdatedAt)
+	case *SyncCheckOptions:
+		s.blockchains = c.snapshot.blockchains.copy()
+		b, ok := s.blockchains.byID[data.BlockchainID]
+		if !ok {
+			return fmt.Errorf("No blockchains found matching %s", data.BlockchainID)
+		}
+		b.SyncCheckOptions = *data
+		if deleted {
+			b.SyncCheckOptions = SyncCheckOptions{BlockchainID: b.ID}
+		}
+		s.blockchains.byID[b.ID] = b
+	case *Redirect:
+		s.blockchains = c.snapshot.blockchains.copy()
+		b, ok := s.blockchains.byID[data.BlockchainID]
+		if !ok {
+			return fmt.Errorf("No blockchains found matching %s", data.BlockchainID)
+		}
+		// Redirects are identified by their blockchain and domain
+		redirects := make([]Redirect, 0, len(b.Redirects)+1)
+		for _, r := range b.Redirects {
+			if r.Domain != data.Domain {
+				redirects = append(redirects, r)
+			}
+		}
+		if !deleted {
+			redirects = append(redirects, *data)
+		}
+		b.Redirects = redirects
+		s.blockchains.byID[b.ID] = b
+	case *PayPlan:
+		s.payPlans = make(PayPlans, len(c.snapshot.payPlans))
+		for planType, plan := range c.snapshot.payPlans {
+			s.payPlans[planType] = plan
+		}
+		if deleted {
+			delete(s.payPlans, data.Type)
+			break
+		}
+		if data.Type == "" {
+			return fmt.Errorf("Invalid pay plan: %w", ErrMissingID)
+		}
+		s.payPlans[data.Type] = *data
+	case *Application:
+		if deleted {
+			s.removeApplication(data.ID)
+			break
+		}
+		app := *data
+		if old, ok := c.snapshot.apps[app.ID]; ok {
+			// Side tables are notified separately
+			app.GatewayAAT = old.GatewayAAT
+			app.GatewaySettings = old.GatewaySettings
+			app.Limit = old.Limit
+			app.NotificationSettings = old.NotificationSettings
+		}
+		if app.ID == "" {
+			return fmt.Errorf("Invalid application: %w", ErrMissingID)
+		}
+		if err := app.Validate(); err != nil {
+			return fmt.Errorf("Invalid application %s: %w", app.ID, err)
+		}
+		s.setApplication(app)
+		s.watermark = laterOf(s.watermark, app.UpdatedAt)
+	case *AppLimit:
+		if !deleted {
+			if err := s.payPlans.Validate(data.PayPlan.Type); err != nil {
+				return fmt.Errorf("Invalid application %s: %w", data.ID, err)
+			}
+		}
+		err := s.updateApplication(data.ID, func(app *Application) {
+			app.Limit = *data
+			if deleted {
+				app.Limit = AppLimit{}
+			}
+		})
+		if err != nil {
+			return err
+		}
+	case *GatewayAAT:
+		err := s.updateApplication(data.ID, func(app *Application) {
+			app.GatewayAAT = *data
+			if deleted {
+				app.GatewayAAT = GatewayAAT{}
+			}
+		})
+		if err != nil {
+			return err
+		}
+	case *GatewaySettings:
+		err := s.updateApplication(data.ID, func(app *Application) {
+			settings := *data
+			if deleted {
+				settings = GatewaySettings{}
+			}
+			// Whitelisted contracts and methods are notified separately
+			settings.WhitelistContracts = app.GatewaySettings.WhitelistContracts
+			settings.WhitelistMethods = app.GatewaySettings.WhitelistMethods
+			app.GatewaySettings = settings
+		})
+		if err != nil {
+			return err
+		}
+	case *WhitelistContract:
+		err := s.updateApplication(data.ID, func(app *Application) {
+			// Whitelisted contracts are identified by their application and blockchain
+			contracts := make([]WhitelistContract, 0, len(app.GatewaySettings.WhitelistContracts)+1)
+			for _, wc := range app.GatewaySettings.WhitelistContracts {
+				if wc.BlockchainID != data.BlockchainID {
+					contracts = append(contracts, wc)
+				}
+			}
+			if !deleted {
+				contracts = append(contracts, *data)
+			}
+			app.GatewaySettings.WhitelistContracts = contracts
+		})
+		if err != nil {
+			return err
+		}
+	case *WhitelistMethod:
+		err := s.updateApplication(data.ID, func(app *Application) {
+			// Whitelisted methods are identified by their application and blockchain
+			methods := make([]WhitelistMethod, 0, len(app.GatewaySettings.WhitelistMethods)+1)
+			for _, wm := range app.GatewaySettings.WhitelistMethods {
+				if wm.BlockchainID != data.BlockchainID {
+					methods = append(methods, wm)
+				}
+			}
+			if !deleted {
+				methods = append(methods, *data)
+			}
+			app.GatewaySettings.WhitelistMethods = methods
+		})
+		if err != nil {
+			return err
+		}
+	case *NotificationSettings:
+		err := s.updateApplication(data.ID, func(app *Application) {
+			app.NotificationSettings = *data
+			if deleted {
+				app.NotificationSettings = NotificationSettings{}
+			}
+		})
+		if err != nil {
+			return err
+		}
+	case *LoadBalancer:
+		s.loadbalancers = c.snapshot.copyLoadBalancers()
+		if deleted {
+			delete(s.loadbalancers, data.ID)
+			break
+		}
+		if data.ID == "" {
+			return fmt.Errorf("Invalid load balancer: %w", ErrMissingID)
+		}
+		lb := *data
+		lb.ApplicationIDs = nil
+		lb.Applications = make(map[string]*Application)
+		if old, ok := c.snapshot.loadbalancers[lb.ID]; ok {
+			// Side tables are notified separately
+			lb.ApplicationIDs = old.ApplicationIDs
+			lb.Applications = old.Applications
+			lb.StickyOptions = old.StickyOptions
+		}
+		s.loadbalancers[lb.ID] = lb
+		s.watermark = laterOf(s.watermark, lb.UpdatedAt)
+	case *StickyOptions:
+		lb, ok := c.snapshot.loadbalancers[data.ID]
+		if !ok {
+			return fmt.Errorf("No loadbalancers found matching %s", data.ID)
+		}
+		s.loadbalancers = c.snapshot.copyLoadBalancers()
+		lb.StickyOptions = *data
+		if deleted {
+			lb.StickyOptions = StickyOptions{}
+		}
+		s.loadbalancers[lb.ID] = lb
+	case *LbApp:
+		lb, ok := c.snapshot.loadbalancers[data.LbID]
+		if !ok {
+			return fmt.Errorf("No loadbalancers found matching %s", data.LbID)
+		}
+		s.loadbalancers = c.snapshot.copyLoadBalancers()
+		if deleted {
+			s.loadbalancers[lb.ID] = lb.withoutApplication(data.AppID)
+			break
+		}
+		var verified *Application
+		if app, ok := c.snapshot.apps[data.AppID]; ok {
+			verified = &app
+		} else {
+			c.log.WithFields(logger.Fields{"loadbalancer": lb.ID, "application": data.AppID}).Warn("Load balancer application not found")
+		}
+		s.loadbalancers[lb.ID] = lb.withApplication(data.AppID, verified)
+	default:
+		return nil
+	}
+
+	c.snapshot = &s
+	return nil
+}
+
+// updateApplication applies the update to a copy of the application, and sets the copy on the snapshot
+func (s *snapshot) updateApplication(id string, update func(*Application)) error {
+	app, ok := s.apps[id]
+	if !ok {
+		return fmt.Errorf("No applications found matching %s", id)
+	}
+	update(&app)
+	s.setApplication(app)
+	return nil
+}
+
+// setApplication adds or replaces the application, along with its verified copy in the load balancers
+// that include it. The applications and load balancers are copied: the snapshot must be a copy of the current one.
+func (s *snapshot) setApplication(app Application) {
+	apps := make(map[string]Application, len(s.apps)+1)
+	for id, a := range s.apps {
+		apps[id] = a
+	}
+	apps[app.ID] = app
+	s.apps = apps
+
+	changed := map[string]bool{app.ID: true}
+	lbs := make(map[string]LoadBalancer, len(s.loadbalancers))
+	for id, lb := range s.loadbalancers {
+		lbs[id] = lb.withApplications(changed, s.apps)
+	}
+	s.loadbalancers = lbs
+}
+
+// removeApplication drops the application, along with its verified copy in the load balancers that include it.
+// The load balancers keep its ID until their lb_apps rows are notified as deleted.
+func (s *snapshot) removeApplication(id string) {
+	apps := make(map[string]Application, len(s.apps))
+	for appID, app := range s.apps {
+		if appID != id {
+			apps[appID] = app
+		}
+	}
+	s.apps = apps
+
+	lbs := make(map[string]LoadBalancer, len(s.loadbalancers))
+	for lbID, lb := range s.loadbalancers {
+		if _, ok := lb.Applications[id]; ok {
+			ids := lb.ApplicationIDs
+			lb = lb.withoutApplication(id)
+			lb.ApplicationIDs = ids
+		}
+		lbs[lbID] = lb
+	}
+	s.loadbalancers = lbs
+}
+
+func (s *snapshot) copyLoadBalancers() map[string]LoadBalancer {
+	lbs := make(map[string]LoadBalancer, len(s.loadbalancers))
+	for id, lb := range s.loadbalancers {
+		lbs[id] = lb
+	}
+	return lbs
+}
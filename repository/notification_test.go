@@ -0,0 +1,420 @@
+package repository
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestApplyBlockchainNotification(t *testing.T) {
+	repo := newTestRepository(t, `[
+		{"id": "0021", "blockchain": "eth-mainnet", "blockchainAliases": ["eth-mainnet"], "syncCheckOptions": {"blockchainID": "0021", "body": "body"}},
+		{"id": "0001", "blockchain": "pokt-mainnet", "blockchainAliases": ["mainnet"]}
+	]`, testApps, testLbs)
+
+	// Aliases are re-indexed when a blockchain is updated
+	err := repo.ApplyNotification(&Notification{
+		Table:  TableBlockchains,
+		Action: ActionUpdate,
+		Data:   &Blockchain{ID: "0021", BlockchainAliases: []string{"eth-archival"}},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := repo.GetBlockchain("eth-mainnet"); err == nil {
+		t.Errorf("Expected removed alias to no longer match")
+	}
+	b, err := repo.GetBlockchain("eth-archival")
+	if err != nil {
+		t.Fatalf("Expected new alias to match, got: %v", err)
+	}
+	if b.SyncCheckOptions.Body != "body" {
+		t.Errorf("Expected sync check options to be kept, got: %v", b.SyncCheckOptions)
+	}
+
+	// A new blockchain is added to the index
+	err = repo.ApplyNotification(&Notification{
+		Table:  TableBlockchains,
+		Action: ActionInsert,
+		Data:   &Blockchain{ID: "0040", BlockchainAliases: []string{"harmony-0"}},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := repo.GetBlockchain("HARMONY-0"); err != nil {
+		t.Errorf("Expected inserted blockchain to be found, got: %v", err)
+	}
+
+	// Duplicate aliases are rejected, keeping the existing index
+	err = repo.ApplyNotification(&Notification{
+		Table:  TableBlockchains,
+		Action: ActionUpdate,
+		Data:   &Blockchain{ID: "0001", BlockchainAliases: []string{"eth-archival"}},
+	})
+	if !errors.Is(err, ErrDuplicateBlockchainAlias) {
+		t.Fatalf("Expected error: %v, got: %v", ErrDuplicateBlockchainAlias, err)
+	}
+	if b, _ := repo.GetBlockchain("mainnet"); b.ID != "0001" {
+		t.Errorf("Expected existing alias to be kept, got: %v", b)
+	}
+
+	err = repo.ApplyNotification(&Notification{
+		Table:  TableSyncCheckOptions,
+		Action: ActionUpdate,
+		Data:   &SyncCheckOptions{BlockchainID: "0001", Path: "/v1/query/height"},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if b, _ := repo.GetBlockchain("0001"); b.SyncCheckOptions.Path != "/v1/query/height" {
+		t.Errorf("Expected sync check options to be updated, got: %v", b.SyncCheckOptions)
+	}
+
+	// Deleted sync check options are cleared
+	err = repo.ApplyNotification(&Notification{
+		Table:  TableSyncCheckOptions,
+		Action: ActionDelete,
+		Data:   &SyncCheckOptions{BlockchainID: "0001", Path: "/v1/query/height"},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if b, _ := repo.GetBlockchain("0001"); b.SyncCheckOptions != (SyncCheckOptions{BlockchainID: "0001"}) {
+		t.Errorf("Expected sync check options to be cleared, got: %v", b.SyncCheckOptions)
+	}
+
+	// Deleted blockchains are removed along with their aliases
+	err = repo.ApplyNotification(&Notification{
+		Table:  TableBlockchains,
+		Action: ActionDelete,
+		Data:   &Blockchain{ID: "0021"},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := repo.GetBlockchain("eth-archival"); err == nil {
+		t.Errorf("Expected deleted blockchain alias to no longer match")
+	}
+	if _, err := repo.GetBlockchain("0021"); err == nil {
+		t.Errorf("Expected deleted blockchain to no longer be found")
+	}
+}
+
+func TestApplyLbAppNotification(t *testing.T) {
+	repo := newTestRepository(t, testBlockchains,
+		`[{"id": "app-1", "name": "app one"}, {"id": "app-2", "name": "app two"}]`, testLbs)
+
+	before, err := repo.GetLoadBalancer("lb-1")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	err = repo.ApplyNotification(&Notification{
+		Table:  TableLbApps,
+		Action: ActionInsert,
+		Data:   &LbApp{LbID: "lb-1", AppID: "app-2"},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	lb, _ := repo.GetLoadBalancer("lb-1")
+	if diff := cmp.Diff([]string{"app-1", "app-2"}, lb.ApplicationIDs); diff != "" {
+		t.Errorf("unexpected value (-want +got):\n%s", diff)
+	}
+	if lb.Applications["app-2"] == nil || lb.Applications["app-2"].Name != "app two" {
+		t.Errorf("Expected added application to be verified, got: %v", lb.Applications)
+	}
+	if len(before.ApplicationIDs) != 1 || len(before.Applications) != 1 {
+		t.Errorf("Expected previous snapshot to be unchanged, got: %v", before)
+	}
+
+	// Adding the same application again does not duplicate it
+	err = repo.ApplyNotification(&Notification{
+		Table:  TableLbApps,
+		Action: ActionInsert,
+		Data:   &LbApp{LbID: "lb-1", AppID: "app-2"},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if lb, _ := repo.GetLoadBalancer("lb-1"); len(lb.ApplicationIDs) != 2 {
+		t.Errorf("Expected 2 applications, got: %v", lb.ApplicationIDs)
+	}
+
+	err = repo.ApplyNotification(&Notification{
+		Table:  TableLbApps,
+		Action: ActionDelete,
+		Data:   &LbApp{LbID: "lb-1", AppID: "app-1"},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	lb, _ = repo.GetLoadBalancer("lb-1")
+	if diff := cmp.Diff([]string{"app-2"}, lb.ApplicationIDs); diff != "" {
+		t.Errorf("unexpected value (-want +got):\n%s", diff)
+	}
+	if _, ok := lb.Applications["app-1"]; ok {
+		t.Errorf("Expected removed application to no longer be verified, got: %v", lb.Applications)
+	}
+
+	// Removing the last application leaves the load balancer invalid
+	err = repo.ApplyNotification(&Notification{
+		Table:  TableLbApps,
+		Action: ActionDelete,
+		Data:   &LbApp{LbID: "lb-1", AppID: "app-2"},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := repo.GetLoadBalancer("lb-1"); !errors.Is(err, ErrNoValidApplications) {
+		t.Errorf("Expected error: %v, got: %v", ErrNoValidApplications, err)
+	}
+
+	err = repo.ApplyNotification(&Notification{
+		Table:  TableLbApps,
+		Action: ActionInsert,
+		Data:   &LbApp{LbID: "lb-2", AppID: "app-1"},
+	})
+	if err == nil {
+		t.Errorf("Expected error for unknown load balancer")
+	}
+}
+
+func TestApplyPayPlanNotification(t *testing.T) {
+	repo := newTestRepository(t, testBlockchains, testApps, testLbs)
+
+	err := repo.ApplyNotification(&Notification{
+		Table:  TablePayPlans,
+		Action: ActionUpdate,
+		Data:   &PayPlan{Type: FreetierV0, Limit: 1000, RateLimit: 10},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if plan, err := repo.GetPayPlan(FreetierV0); err != nil || plan.Limit != 1000 || plan.RateLimit != 10 {
+		t.Errorf("Expected updated pay plan, got: %v, error: %v", plan, err)
+	}
+
+	err = repo.ApplyNotification(&Notification{
+		Table:  TablePayPlans,
+		Action: ActionDelete,
+		Data:   &PayPlan{Type: FreetierV0},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := repo.GetPayPlan(FreetierV0); !errors.Is(err, ErrInvalidPayPlanType) {
+		t.Errorf("Expected error: %v, got: %v", ErrInvalidPayPlanType, err)
+	}
+
+	err = repo.ApplyNotification(&Notification{Table: TablePayPlans, Action: ActionInsert, Data: &PayPlan{}})
+	if !errors.Is(err, ErrMissingID) {
+		t.Errorf("Expected error: %v, got: %v", ErrMissingID, err)
+	}
+}
+
+func TestApplyApplicationNotification(t *testing.T) {
+	repo := newTestRepository(t, testBlockchains,
+		`[{"id": "app-1", "name": "app one", "status": "IN_SERVICE", "limit": {"payPlan": {"planType": "FREETIER_V0"}}}]`, testLbs)
+
+	// Updated columns of an application are applied to the load balancers including it, keeping its side tables
+	updatedAt := time.Now()
+	err := repo.ApplyNotification(&Notification{
+		Table:  TableApplications,
+		Action: ActionUpdate,
+		Data:   &Application{ID: "app-1", Name: "app one", Status: Decomissioned, UpdatedAt: updatedAt},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	app, err := repo.GetApplication("app-1")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if app.Status != Decomissioned || app.Limit.PayPlan.Type != FreetierV0 {
+		t.Errorf("Expected updated status and kept limit, got: %v", app)
+	}
+	if lb, _ := repo.GetLoadBalancer("lb-1"); lb.Applications["app-1"].Status != Decomissioned {
+		t.Errorf("Expected load balancer application to be updated, got: %v", lb.Applications["app-1"])
+	}
+	if !repo.Watermark().Equal(updatedAt) {
+		t.Errorf("Expected watermark: %v, got: %v", updatedAt, repo.Watermark())
+	}
+
+	notifications := []*Notification{
+		{Table: TableAppLimits, Action: ActionUpdate, Data: &AppLimit{ID: "app-1", PayPlan: PayPlan{Type: Enterprise}, CustomLimit: 10}},
+		{Table: TableGatewayAAT, Action: ActionUpdate, Data: &GatewayAAT{ID: "app-1", Address: "address"}},
+		{Table: TableWhitelistContracts, Action: ActionInsert, Data: &WhitelistContract{ID: "app-1", BlockchainID: "0021", Contracts: []string{"0x1"}}},
+		{Table: TableWhitelistContracts, Action: ActionUpdate, Data: &WhitelistContract{ID: "app-1", BlockchainID: "0021", Contracts: []string{"0x2"}}},
+		{Table: TableWhitelistMethods, Action: ActionInsert, Data: &WhitelistMethod{ID: "app-1", BlockchainID: "0021", Methods: []string{"eth_call"}}},
+		{Table: TableWhitelistMethods, Action: ActionDelete, Data: &WhitelistMethod{ID: "app-1", BlockchainID: "0021"}},
+		{Table: TableGatewaySettings, Action: ActionUpdate, Data: &GatewaySettings{ID: "app-1", SecretKeyRequired: true}},
+		{Table: TableNotificationSettings, Action: ActionUpdate, Data: &NotificationSettings{ID: "app-1", Half: true}},
+	}
+	for _, n := range notifications {
+		if err := repo.ApplyNotification(n); err != nil {
+			t.Fatalf("Unexpected error applying %s notification: %v", n.Table, err)
+		}
+	}
+	expected := Application{
+		ID:        "app-1",
+		Name:      "app one",
+		Status:    Decomissioned,
+		UpdatedAt: updatedAt,
+		Limit:     AppLimit{ID: "app-1", PayPlan: PayPlan{Type: Enterprise}, CustomLimit: 10},
+		GatewayAAT: GatewayAAT{
+			ID:      "app-1",
+			Address: "address",
+		},
+		GatewaySettings: GatewaySettings{
+			ID:                 "app-1",
+			SecretKeyRequired:  true,
+			WhitelistContracts: []WhitelistContract{{ID: "app-1", BlockchainID: "0021", Contracts: []string{"0x2"}}},
+			WhitelistMethods:   []WhitelistMethod{},
+		},
+		NotificationSettings: NotificationSettings{ID: "app-1", Half: true},
+	}
+	app, _ = repo.GetApplication("app-1")
+	if diff := cmp.Diff(expected, app); diff != "" {
+		t.Errorf("unexpected value (-want +got):\n%s", diff)
+	}
+
+	// Limits on plans missing from the catalogue are rejected
+	err = repo.ApplyNotification(&Notification{
+		Table:  TableAppLimits,
+		Action: ActionUpdate,
+		Data:   &AppLimit{ID: "app-1", PayPlan: PayPlan{Type: "UNKNOWN"}},
+	})
+	if !errors.Is(err, ErrInvalidPayPlanType) {
+		t.Errorf("Expected error: %v, got: %v", ErrInvalidPayPlanType, err)
+	}
+
+	err = repo.ApplyNotification(&Notification{
+		Table:  TableGatewayAAT,
+		Action: ActionUpdate,
+		Data:   &GatewayAAT{ID: "app-2"},
+	})
+	if err == nil {
+		t.Errorf("Expected error for unknown application")
+	}
+
+	// Deleted applications are no longer served, nor selected by the load balancers including them
+	err = repo.ApplyNotification(&Notification{
+		Table:  TableApplications,
+		Action: ActionDelete,
+		Data:   &Application{ID: "app-1"},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := repo.GetApplication("app-1"); err == nil {
+		t.Errorf("Expected deleted application to no longer be found")
+	}
+	if _, err := repo.GetLoadBalancer("lb-1"); !errors.Is(err, ErrNoValidApplications) {
+		t.Errorf("Expected error: %v, got: %v", ErrNoValidApplications, err)
+	}
+}
+
+func TestApplyLoadBalancerNotification(t *testing.T) {
+	repo := newTestRepository(t, testBlockchains, testApps, testLbs)
+
+	// Updated columns of a load balancer are applied, keeping its applications and stickiness options
+	err := repo.ApplyNotification(&Notification{
+		Table:  TableStickinessOptions,
+		Action: ActionInsert,
+		Data:   &StickyOptions{ID: "lb-1", Stickiness: true},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	updatedAt := time.Now()
+	err = repo.ApplyNotification(&Notification{
+		Table:  TableLoadBalancers,
+		Action: ActionUpdate,
+		Data:   &LoadBalancer{ID: "lb-1", Name: "lb renamed", UpdatedAt: updatedAt},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	lb, err := repo.GetLoadBalancer("lb-1")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if lb.Name != "lb renamed" || !lb.StickyOptions.Stickiness || lb.Applications["app-1"] == nil {
+		t.Errorf("Expected updated name and kept applications and stickiness options, got: %v", lb)
+	}
+	if !repo.Watermark().Equal(updatedAt) {
+		t.Errorf("Expected watermark: %v, got: %v", updatedAt, repo.Watermark())
+	}
+
+	err = repo.ApplyNotification(&Notification{
+		Table:  TableStickinessOptions,
+		Action: ActionDelete,
+		Data:   &StickyOptions{ID: "lb-1"},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if lb, _ := repo.GetLoadBalancer("lb-1"); !lb.StickyOptions.IsEmpty() {
+		t.Errorf("Expected stickiness options to be cleared, got: %v", lb.StickyOptions)
+	}
+
+	// New load balancers are served once their applications are notified
+	notifications := []*Notification{
+		{Table: TableLoadBalancers, Action: ActionInsert, Data: &LoadBalancer{ID: "lb-2"}},
+		{Table: TableLbApps, Action: ActionInsert, Data: &LbApp{LbID: "lb-2", AppID: "app-1"}},
+	}
+	for _, n := range notifications {
+		if err := repo.ApplyNotification(n); err != nil {
+			t.Fatalf("Unexpected error applying %s notification: %v", n.Table, err)
+		}
+	}
+	if lb, err := repo.GetLoadBalancer("lb-2"); err != nil || lb.Applications["app-1"] == nil {
+		t.Errorf("Expected inserted load balancer to be served, got: %v, error: %v", lb, err)
+	}
+
+	err = repo.ApplyNotification(&Notification{
+		Table:  TableLoadBalancers,
+		Action: ActionDelete,
+		Data:   &LoadBalancer{ID: "lb-1"},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := repo.GetLoadBalancer("lb-1"); err == nil {
+		t.Errorf("Expected deleted load balancer to no longer be found")
+	}
+}
+
+func TestApplyRedirectNotification(t *testing.T) {
+	repo := newTestRepository(t, testBlockchains, testApps, testLbs)
+
+	notifications := []*Notification{
+		{Table: TableRedirects, Action: ActionInsert, Data: &Redirect{BlockchainID: "0021", Alias: "eth", Domain: "eth.example.com", LoadBalancerID: "lb-1"}},
+		{Table: TableRedirects, Action: ActionInsert, Data: &Redirect{BlockchainID: "0021", Alias: "eth", Domain: "other.example.com", LoadBalancerID: "lb-1"}},
+		{Table: TableRedirects, Action: ActionUpdate, Data: &Redirect{BlockchainID: "0021", Alias: "eth", Domain: "eth.example.com", LoadBalancerID: "lb-2"}},
+		{Table: TableRedirects, Action: ActionDelete, Data: &Redirect{BlockchainID: "0021", Domain: "other.example.com"}},
+	}
+	for _, n := range notifications {
+		if err := repo.ApplyNotification(n); err != nil {
+			t.Fatalf("Unexpected error applying %s notification: %v", n.Action, err)
+		}
+	}
+
+	b, _ := repo.GetBlockchain("0021")
+	expected := []Redirect{{BlockchainID: "0021", Alias: "eth", Domain: "eth.example.com", LoadBalancerID: "lb-2"}}
+	if diff := cmp.Diff(expected, b.Redirects); diff != "" {
+		t.Errorf("unexpected value (-want +got):\n%s", diff)
+	}
+
+	err := repo.ApplyNotification(&Notification{
+		Table:  TableRedirects,
+		Action: ActionInsert,
+		Data:   &Redirect{BlockchainID: "0040", Domain: "harmony.example.com"},
+	})
+	if err == nil {
+		t.Errorf("Expected error for unknown blockchain")
+	}
+}
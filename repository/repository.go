@@ -1,12 +1,16 @@
 package repository
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io/ioutil"
+	"os"
 	"path"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	logger "github.com/sirupsen/logrus"
@@ -16,17 +20,37 @@ type Repository interface {
 	GetApplication(id string) (Application, error)
 	GetBlockchain(alias string) (Blockchain, error)
 	GetLoadBalancer(id string) (LoadBalancer, error)
+	GetPayPlan(planType PayPlanType) (PayPlan, error)
 }
 
 var (
 	ErrNoFieldsToUpdate               = errors.New("no fields to update")
 	ErrInvalidAppStatus               = errors.New("invalid app status")
 	ErrInvalidPayPlanType             = errors.New("invalid pay plan type")
+	ErrDuplicatePayPlan               = errors.New("duplicate pay plan")
 	ErrNotEnterprisePlan              = errors.New("custom limits may only be set on enterprise plans")
 	ErrEnterprisePlanNeedsCustomLimit = errors.New("enterprise plans must have a custom limit set")
+	ErrMissingID                      = errors.New("missing id")
+	ErrDuplicateBlockchainID          = errors.New("duplicate blockchain id")
+	ErrDuplicateBlockchainAlias       = errors.New("duplicate blockchain alias")
+	ErrMissingRedirectFields          = errors.New("redirects must have a blockchain id, alias and domain")
+	ErrInvalidBlockchainAlias         = errors.New("blockchain aliases must be unique and not empty")
+	ErrNegativeBlockchainSetting      = errors.New("blockchain limits and timeouts can not be negative")
+	ErrRepositoryNotReady             = errors.New("repository has no blockchains to relay to")
+
+	ErrNoValidApplications = &CodedError{Code: -32058, Message: "load balancer configuration invalid: no valid applications"}
 )
 
-// TODO: identify fields that should be stored encrypted in-memory
+// CodedError is an error carrying the JSON-RPC error code returned to portal users
+type CodedError struct {
+	Code    int
+	Message string
+}
+
+func (e *CodedError) Error() string {
+	return e.Message
+}
+
 type Application struct {
 	ID                 string    `json:"id"`
 	UserID             string    `json:"userID"`
@@ -62,15 +86,12 @@ func (a *Application) DailyLimit() int {
 	return a.Limit.PayPlan.Limit
 }
 
+// Validate checks the application fields, its pay plan type is checked against the catalogue by PayPlans.Validate
 func (a *Application) Validate() error {
 	if !ValidAppStatuses[a.Status] {
 		return ErrInvalidAppStatus
 	}
 
-	if !ValidPayPlanTypes[a.Limit.PayPlan.Type] {
-		return ErrInvalidPayPlanType
-	}
-
 	if a.Limit.PayPlan.Type != Enterprise && a.Limit.CustomLimit != 0 {
 		return ErrNotEnterprisePlan
 	}
@@ -129,6 +150,29 @@ var (
 type PayPlan struct {
 	Type  PayPlanType `json:"planType"`
 	Limit int         `json:"dailyLimit"`
+	// RateLimit is the maximum number of relays per second of each application on the plan, 0 for no limit
+	RateLimit int `json:"rateLimit,omitempty"`
+	// AllowedChains are the IDs of the blockchains applications on the plan may relay to, empty for all
+	AllowedChains []string `json:"allowedChains,omitempty"`
+	// AatPlan, when set, overrides the relayer's AAT plan for applications on the plan
+	AatPlan AatPlan `json:"aatPlan,omitempty"`
+}
+
+func (p *PayPlan) Table() Table {
+	return TablePayPlans
+}
+
+// AllowsChain returns whether applications on the plan may relay to the blockchain
+func (p *PayPlan) AllowsChain(blockchainID string) bool {
+	if len(p.AllowedChains) == 0 {
+		return true
+	}
+	for _, id := range p.AllowedChains {
+		if id == blockchainID {
+			return true
+		}
+	}
+	return false
 }
 
 type PayPlanType string
@@ -142,16 +186,54 @@ const (
 	Enterprise   PayPlanType = "ENTERPRISE"
 )
 
-var (
-	ValidPayPlanTypes = map[PayPlanType]bool{
-		"":           true, // needs to be allowed while the change for all apps to have plans is done
-		TestPlanV0:   true,
-		TestPlan10K:  true,
-		TestPlan90k:  true,
-		FreetierV0:   true,
-		PayAsYouGoV0: true,
-		Enterprise:   true,
+// PayPlans is the catalogue of pay plans applications may be on, keyed by plan type
+type PayPlans map[PayPlanType]PayPlan
+
+// NewPayPlans returns the catalogue of the plans, failing on plans without type or listed twice
+func NewPayPlans(plans []PayPlan) (PayPlans, error) {
+	p := make(PayPlans, len(plans))
+	for _, plan := range plans {
+		if plan.Type == "" {
+			return nil, fmt.Errorf("Invalid pay plan: %w", ErrMissingID)
+		}
+		if _, ok := p[plan.Type]; ok {
+			return nil, fmt.Errorf("%w: %s", ErrDuplicatePayPlan, plan.Type)
+		}
+		p[plan.Type] = plan
 	}
+	return p, nil
+}
+
+// DefaultPayPlans returns the catalogue used when none is provided, matching the plans seeded on the database
+func DefaultPayPlans() PayPlans {
+	return PayPlans{
+		TestPlanV0:   {Type: TestPlanV0, Limit: 100},
+		TestPlan10K:  {Type: TestPlan10K, Limit: 10000},
+		TestPlan90k:  {Type: TestPlan90k, Limit: 90000},
+		FreetierV0:   {Type: FreetierV0, Limit: 250000},
+		PayAsYouGoV0: {Type: PayAsYouGoV0},
+		Enterprise:   {Type: Enterprise},
+	}
+}
+
+// Validate returns ErrInvalidPayPlanType if the plan type is not in the catalogue.
+// An empty plan type is valid while the change for all apps to have plans is done.
+func (p PayPlans) Validate(planType PayPlanType) error {
+	if planType == "" {
+		return nil
+	}
+	if _, ok := p[planType]; !ok {
+		return fmt.Errorf("%w: %s", ErrInvalidPayPlanType, planType)
+	}
+	return nil
+}
+
+// AatPlan determines how the AAT of an application's relays is obtained
+type AatPlan string
+
+const (
+	AatPlanPremium  AatPlan = "Premium"
+	AatPlanFreemium AatPlan = "Freemium"
 )
 
 type AppLimits struct {
@@ -183,9 +265,6 @@ func (u *UpdateApplication) Validate() error {
 	if !ValidAppStatuses[u.Status] {
 		return ErrInvalidAppStatus
 	}
-	if u.Limit != nil && !ValidPayPlanTypes[u.Limit.PayPlan.Type] {
-		return ErrInvalidPayPlanType
-	}
 	if u.Limit != nil && u.Limit.PayPlan.Type != Enterprise && u.Limit.CustomLimit != 0 {
 		return ErrNotEnterprisePlan
 	}
@@ -206,7 +285,7 @@ type GatewayAAT struct {
 	ApplicationPublicKey string `json:"applicationPublicKey"`
 	ApplicationSignature string `json:"applicationSignature"`
 	ClientPublicKey      string `json:"clientPublicKey"`
-	PrivateKey           string `json:"privateKey"`
+	PrivateKey           Secret `json:"privateKey"`
 	Version              string `json:"version"`
 }
 
@@ -216,7 +295,7 @@ func (a *GatewayAAT) Table() Table {
 
 type GatewaySettings struct {
 	ID                   string              `json:"id,omitempty"`
-	SecretKey            string              `json:"secretKey"`
+	SecretKey            Secret              `json:"secretKey"`
 	SecretKeyRequired    bool                `json:"secretKeyRequired"`
 	WhitelistOrigins     []string            `json:"whitelistOrigins,omitempty"`
 	WhitelistUserAgents  []string            `json:"whitelistUserAgents,omitempty"`
@@ -289,6 +368,44 @@ func (b *Blockchain) Table() Table {
 	return TableBlockchains
 }
 
+func (b *Blockchain) Validate() error {
+	if b.ID == "" {
+		return ErrMissingID
+	}
+	return nil
+}
+
+// UpdateBlockchain struct holding possible fields to update
+type UpdateBlockchain struct {
+	BlockchainAliases []string          `json:"blockchainAliases,omitempty"`
+	ChainIDCheck      string            `json:"chainIDCheck,omitempty"`
+	LogLimitBlocks    int               `json:"logLimitBlocks,omitempty"`
+	RequestTimeout    int               `json:"requestTimeout,omitempty"`
+	SyncCheckOptions  *SyncCheckOptions `json:"syncCheckOptions,omitempty"`
+}
+
+func (u *UpdateBlockchain) Validate() error {
+	if u == nil || (len(u.BlockchainAliases) == 0 && u.ChainIDCheck == "" && u.LogLimitBlocks == 0 &&
+		u.RequestTimeout == 0 && u.SyncCheckOptions == nil) {
+		return ErrNoFieldsToUpdate
+	}
+	aliases := make(map[string]bool)
+	for _, alias := range u.BlockchainAliases {
+		alias = strings.ToLower(alias)
+		if alias == "" || aliases[alias] {
+			return ErrInvalidBlockchainAlias
+		}
+		aliases[alias] = true
+	}
+	if u.LogLimitBlocks < 0 || u.RequestTimeout < 0 {
+		return ErrNegativeBlockchainSetting
+	}
+	if u.SyncCheckOptions != nil && u.SyncCheckOptions.Allowance < 0 {
+		return ErrNegativeBlockchainSetting
+	}
+	return nil
+}
+
 type Redirect struct {
 	ID             string    `json:"id"`
 	BlockchainID   string    `json:"blockchainID"`
@@ -303,6 +420,13 @@ func (r *Redirect) Table() Table {
 	return TableRedirects
 }
 
+func (r *Redirect) Validate() error {
+	if r.BlockchainID == "" || r.Alias == "" || r.Domain == "" {
+		return ErrMissingRedirectFields
+	}
+	return nil
+}
+
 type SyncCheckOptions struct {
 	BlockchainID string `json:"blockchainID"`
 	Body         string `json:"body"`
@@ -317,12 +441,16 @@ func (o *SyncCheckOptions) Table() Table {
 
 // loadBalancer is an internal struct, reflects json, contains unverified fields, e.g. applicationIDs
 type loadBalancer struct {
-	ID             string   `json:"id"`
-	Name           string   `json:"name"`
-	ApplicationIDs []string `json:"applicationIDs"`
-	// User []*User
-	// TODO: load from db table/view
-	StickyOptions StickyOptions `json:"stickinessOptions"`
+	ID                string        `json:"id"`
+	Name              string        `json:"name"`
+	UserID            string        `json:"userID"`
+	ApplicationIDs    []string      `json:"applicationIDs"`
+	RequestTimeout    int           `json:"requestTimeout"`
+	Gigastake         bool          `json:"gigastake"`
+	GigastakeRedirect bool          `json:"gigastakeRedirect"`
+	StickyOptions     StickyOptions `json:"stickinessOptions"`
+	CreatedAt         time.Time     `json:"createdAt"`
+	UpdatedAt         time.Time     `json:"updatedAt"`
 }
 
 // LoadBalancer contains verified fields, e.g. applications (referred to as Endpoints on the Portal UI frontend/API)
@@ -336,8 +464,8 @@ type LoadBalancer struct {
 	// TODO: this likely needs to be replaced with gigastake apps
 	GigastakeRedirect bool          `json:"gigastakeRedirect"`
 	StickyOptions     StickyOptions `json:"stickinessOptions"`
-	// TODO: use map[AppID]*Application instead (to speed-up fetchLoadBalancerApplication routine)
-	Applications []*Application
+	// Applications holds the verified applications of the load balancer, keyed by application ID
+	Applications map[string]*Application `json:"-"`
 	// User []*User
 	CreatedAt time.Time `json:"createdAt"`
 	UpdatedAt time.Time `json:"updatedAt"`
@@ -347,6 +475,46 @@ func (l *LoadBalancer) Table() Table {
 	return TableLoadBalancers
 }
 
+// Validate returns ErrNoValidApplications if none of the load balancer's applications could be verified
+func (l *LoadBalancer) Validate() error {
+	if len(l.Applications) == 0 {
+		return fmt.Errorf("%w: load balancer %s", ErrNoValidApplications, l.ID)
+	}
+	return nil
+}
+
+// withApplication returns a copy of the load balancer which includes the application,
+// app is nil if the application could not be verified
+func (l LoadBalancer) withApplication(id string, app *Application) LoadBalancer {
+	lb := l.withoutApplication(id)
+	lb.ApplicationIDs = append(lb.ApplicationIDs, id)
+	if app != nil {
+		lb.Applications[id] = app
+	}
+	return lb
+}
+
+// withoutApplication returns a copy of the load balancer which does not include the application
+func (l LoadBalancer) withoutApplication(id string) LoadBalancer {
+	ids := make([]string, 0, len(l.ApplicationIDs)+1)
+	for _, appID := range l.ApplicationIDs {
+		if appID != id {
+			ids = append(ids, appID)
+		}
+	}
+	l.ApplicationIDs = ids
+
+	apps := make(map[string]*Application, len(l.Applications)+1)
+	for appID, app := range l.Applications {
+		if appID != id {
+			apps[appID] = app
+		}
+	}
+	l.Applications = apps
+
+	return l
+}
+
 // LbApp represents in DB relationships of lb and apps
 // do not change the tags, they're snake_case on purpose
 type LbApp struct {
@@ -365,6 +533,13 @@ type UpdateLoadBalancer struct {
 	Remove        bool           `json:"remove,omitempty"`
 }
 
+func (u *UpdateLoadBalancer) Validate() error {
+	if u == nil || (u.Name == "" && u.StickyOptions == nil && !u.Remove) {
+		return ErrNoFieldsToUpdate
+	}
+	return nil
+}
+
 type StickyOptions struct {
 	ID            string   `json:"id,omitempty"`
 	Duration      string   `json:"duration"`
@@ -393,16 +568,77 @@ type AppStickiness struct {
 	NodeAddress   string
 }
 
-func NewRepository(jsonFilesPath string, log *logger.Logger) (Repository, error) {
-	l := log.WithFields(logger.Fields{"path": jsonFilesPath})
+// ReloadableRepository is a Repository whose contents can be reloaded at runtime, from its json files or
+// its change source, and kept up to date by the notifications of the database
+type ReloadableRepository interface {
+	Repository
+	NotificationHandler
+	Reload() error
+	Watch(ctx context.Context, interval time.Duration, reload <-chan os.Signal)
+	SetChangeSource(source ChangeSource)
+	Resync(ctx context.Context) (ResyncResult, error)
+	Watermark() time.Time
+	Ready() error
+	Blockchains() []Blockchain
+	InvalidApplicationIDs() map[string][]string
+}
+
+var repositoryFiles = []string{"Blockchains.json", "Applications.json", "LoadBalancers.json"}
+
+// payPlansFile holds the pay plan catalogue, DefaultPayPlans is used if it does not exist
+const payPlansFile = "PayPlans.json"
+
+func NewRepository(jsonFilesPath string, log *logger.Logger) (ReloadableRepository, error) {
+	c := &cachingRepository{
+		path: jsonFilesPath,
+		log:  log,
+	}
 
 	// TODO: use a db (postgres?) backend once migration from mongo is done.
 	//	for now, just load from json files
+	if err := c.Reload(); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// snapshot holds a consistent, validated view of the repository contents.
+type snapshot struct {
+	apps          map[string]Application
+	blockchains   *blockchainIndex
+	loadbalancers map[string]LoadBalancer
+	payPlans      PayPlans
+	// invalidAppIDs holds the IDs of the applications of each load balancer that do not exist, by load balancer ID
+	invalidAppIDs map[string][]string
+	modTimes      map[string]time.Time
+	// watermark is the latest update time of the snapshot contents, changes after it are fetched on resyncs
+	watermark time.Time
+}
+
+func loadSnapshot(jsonFilesPath string, log *logger.Logger) (*snapshot, error) {
+	l := log.WithFields(logger.Fields{"path": jsonFilesPath})
+
+	modTimes, err := filesModTime(jsonFilesPath)
+	if err != nil {
+		return nil, err
+	}
+
 	blockchains, err := loadBlockchains(path.Join(jsonFilesPath, "Blockchains.json"))
 	if err != nil {
 		return nil, fmt.Errorf("Error loading blockchains: %v", err)
 	}
 
+	index, err := newBlockchainIndex(blockchains)
+	if err != nil {
+		return nil, fmt.Errorf("Error loading blockchains: %w", err)
+	}
+
+	payPlans, err := loadPayPlans(path.Join(jsonFilesPath, payPlansFile))
+	if err != nil {
+		return nil, fmt.Errorf("Error loading pay plans: %w", err)
+	}
+
 	applications, err := loadApplications(path.Join(jsonFilesPath, "Applications.json"))
 	if err != nil {
 		return nil, fmt.Errorf("Error loading applications: %v", err)
@@ -420,27 +656,290 @@ func NewRepository(jsonFilesPath string, log *logger.Logger) (Repository, error)
 	if len(invalidAppIDs) > 0 {
 		l.WithFields(logger.Fields{"invalidApplicationIDs": invalidAppIDs}).Warnf("One or more of the specified application IDs were invalid.")
 	}
+	for _, lb := range lbs {
+		if err := lb.Validate(); err != nil {
+			l.WithFields(logger.Fields{"error": err}).Warn("Load balancer has no valid applications")
+		}
+	}
 
-	return &cachingRepository{
-		blockchains:   blockchains,
+	s := &snapshot{
+		blockchains:   index,
 		apps:          applications,
 		loadbalancers: lbs,
-		log:           log,
-	}, nil
+		payPlans:      payPlans,
+		invalidAppIDs: invalidAppIDs,
+		modTimes:      modTimes,
+	}
+	if err := s.validate(); err != nil {
+		return nil, err
+	}
+	s.watermark = s.latestUpdate()
+	return s, nil
+}
+
+func (s *snapshot) validate() error {
+	for id, app := range s.apps {
+		if id == "" {
+			return fmt.Errorf("Invalid application: %w", ErrMissingID)
+		}
+		if err := app.Validate(); err != nil {
+			return fmt.Errorf("Invalid application %s: %w", id, err)
+		}
+		if err := s.payPlans.Validate(app.Limit.PayPlan.Type); err != nil {
+			return fmt.Errorf("Invalid application %s: %w", id, err)
+		}
+	}
+	return nil
+}
+
+// blockchainIndex allows looking up blockchains by either ID or (case-insensitive) alias
+type blockchainIndex struct {
+	byID map[string]Blockchain
+	// aliases maps lowercased aliases to blockchain IDs
+	aliases map[string]string
+}
+
+func newBlockchainIndex(blockchains []Blockchain) (*blockchainIndex, error) {
+	i := &blockchainIndex{
+		byID:    make(map[string]Blockchain),
+		aliases: make(map[string]string),
+	}
+	for _, b := range blockchains {
+		if _, ok := i.byID[b.ID]; ok {
+			return nil, fmt.Errorf("%w: %s", ErrDuplicateBlockchainID, b.ID)
+		}
+		if err := i.set(b); err != nil {
+			return nil, err
+		}
+	}
+	return i, nil
+}
+
+// set adds or replaces a blockchain, failing if any of its aliases already belongs to a different blockchain.
+func (i *blockchainIndex) set(b Blockchain) error {
+	if b.ID == "" {
+		return fmt.Errorf("Invalid blockchain %q: %w", b.Blockchain, ErrMissingID)
+	}
+
+	for _, alias := range b.BlockchainAliases {
+		if id, ok := i.aliases[strings.ToLower(alias)]; ok && id != b.ID {
+			return fmt.Errorf("%w: %q is used by blockchains %s and %s", ErrDuplicateBlockchainAlias, alias, id, b.ID)
+		}
+	}
+
+	if old, ok := i.byID[b.ID]; ok {
+		for _, alias := range old.BlockchainAliases {
+			delete(i.aliases, strings.ToLower(alias))
+		}
+	}
+	for _, alias := range b.BlockchainAliases {
+		i.aliases[strings.ToLower(alias)] = b.ID
+	}
+	i.byID[b.ID] = b
+	return nil
+}
+
+// remove drops the blockchain and its aliases from the index
+func (i *blockchainIndex) remove(id string) {
+	old, ok := i.byID[id]
+	if !ok {
+		return
+	}
+	for _, alias := range old.BlockchainAliases {
+		if i.aliases[strings.ToLower(alias)] == id {
+			delete(i.aliases, strings.ToLower(alias))
+		}
+	}
+	delete(i.byID, id)
+}
+
+// get returns the blockchain matching the alias, falling back to matching by blockchain ID
+func (i *blockchainIndex) get(alias string) (Blockchain, bool) {
+	if id, ok := i.aliases[strings.ToLower(alias)]; ok {
+		return i.byID[id], true
+	}
+	b, ok := i.byID[alias]
+	return b, ok
+}
+
+func (i *blockchainIndex) copy() *blockchainIndex {
+	c := &blockchainIndex{
+		byID:    make(map[string]Blockchain, len(i.byID)),
+		aliases: make(map[string]string, len(i.aliases)),
+	}
+	for id, b := range i.byID {
+		c.byID[id] = b
+	}
+	for alias, id := range i.aliases {
+		c.aliases[alias] = id
+	}
+	return c
+}
+
+// filesModTime returns the modification time of each of the files backing the repository
+func filesModTime(jsonFilesPath string) (map[string]time.Time, error) {
+	modTimes := make(map[string]time.Time)
+	for _, f := range repositoryFiles {
+		info, err := os.Stat(path.Join(jsonFilesPath, f))
+		if err != nil {
+			return nil, err
+		}
+		modTimes[f] = info.ModTime()
+	}
+
+	info, err := os.Stat(path.Join(jsonFilesPath, payPlansFile))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	if err == nil {
+		modTimes[payPlansFile] = info.ModTime()
+	}
+	return modTimes, nil
 }
 
 // TODO: caching
 type cachingRepository struct {
-	apps map[string]Application
-	// TODO: if it helps performance, use a map: multiple keys (i.e. aliases of a blockchain) can refer to the same value (i.e. address of Blockchain struct)
-	blockchains   []Blockchain
-	loadbalancers map[string]LoadBalancer
+	path string
+
+	mu       sync.RWMutex
+	snapshot *snapshot
+	source   ChangeSource
 
 	log *logger.Logger
 }
 
+func (c *cachingRepository) current() *snapshot {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.snapshot
+}
+
+// Reload builds a new snapshot from the json files, or the change source of repositories created by
+// NewRepositoryFromSource, and swaps it in only if it is valid. On failure the previous snapshot keeps being served.
+func (c *cachingRepository) Reload() error {
+	var (
+		s   *snapshot
+		err error
+	)
+	if c.path == "" {
+		ctx, cancel := context.WithTimeout(context.Background(), resyncTimeout)
+		defer cancel()
+		s, err = loadSourceSnapshot(ctx, c.source, c.log)
+	} else {
+		s, err = loadSnapshot(c.path, c.log)
+	}
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	c.snapshot = s
+	c.mu.Unlock()
+	return nil
+}
+
+// Ready returns ErrRepositoryNotReady if the repository holds no blockchains, i.e. no relays can be served
+func (c *cachingRepository) Ready() error {
+	s := c.current()
+	if s == nil || len(s.blockchains.byID) == 0 {
+		return ErrRepositoryNotReady
+	}
+	return nil
+}
+
+// Blockchains returns all the blockchains of the repository, sorted by ID
+func (c *cachingRepository) Blockchains() []Blockchain {
+	s := c.current()
+	blockchains := make([]Blockchain, 0, len(s.blockchains.byID))
+	for _, b := range s.blockchains.byID {
+		blockchains = append(blockchains, b)
+	}
+	sort.Slice(blockchains, func(i, j int) bool {
+		return blockchains[i].ID < blockchains[j].ID
+	})
+	return blockchains
+}
+
+// InvalidApplicationIDs returns the IDs of the applications of load balancers that do not exist in the
+// repository, by load balancer ID. Load balancers whose applications are all valid are not included.
+func (c *cachingRepository) InvalidApplicationIDs() map[string][]string {
+	invalid := make(map[string][]string)
+	for lbID, ids := range c.current().invalidAppIDs {
+		invalid[lbID] = append([]string(nil), ids...)
+	}
+	return invalid
+}
+
+// Watch reloads the repository whenever one of its files is modified, checked every interval,
+// or a signal (e.g. SIGHUP) is received on the reload channel. It returns once the context is done.
+// Files that fail to reload are warned about once, and only reloaded again once they are modified.
+func (c *cachingRepository) Watch(ctx context.Context, interval time.Duration, reload <-chan os.Signal) {
+	log := c.log.WithFields(logger.Fields{"path": c.path})
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	// failed holds the modification times of the files of the last failed reload
+	var failed map[string]time.Time
+	for {
+		entry := log
+		var attempted map[string]time.Time
+		select {
+		case <-ctx.Done():
+			return
+		case sig := <-reload:
+			entry = log.WithFields(logger.Fields{"signal": sig})
+			attempted, _ = c.filesChanged()
+		case <-ticker.C:
+			modTimes, changed := c.filesChanged()
+			if !changed || sameModTimes(modTimes, failed) {
+				continue
+			}
+			attempted = modTimes
+		}
+
+		if err := c.Reload(); err != nil {
+			entry.WithFields(logger.Fields{"error": err}).Warn("Error reloading repository, keeping previous contents")
+			failed = attempted
+			continue
+		}
+		failed = nil
+		entry.Info("Repository reloaded")
+	}
+}
+
+// filesChanged returns the modification times of the files, and whether they differ from those of the current contents
+func (c *cachingRepository) filesChanged() (map[string]time.Time, bool) {
+	if c.path == "" {
+		// Repositories loaded from a change source have no files
+		return nil, false
+	}
+
+	modTimes, err := filesModTime(c.path)
+	if err != nil {
+		// Files may be in the middle of being replaced: try again on the next tick
+		return nil, false
+	}
+
+	return modTimes, !sameModTimes(modTimes, c.current().modTimes)
+}
+
+// sameModTimes returns whether both sets of files exist, with the same modification times
+func sameModTimes(a, b map[string]time.Time) bool {
+	if a == nil || b == nil || len(a) != len(b) {
+		// e.g. the pay plans file was added or removed
+		return false
+	}
+	for f, t := range a {
+		if !t.Equal(b[f]) {
+			return false
+		}
+	}
+	return true
+}
+
 func (c *cachingRepository) GetApplication(id string) (Application, error) {
-	if app, ok := c.apps[id]; ok {
+	if app, ok := c.current().apps[id]; ok {
 		return app, nil
 	}
 	return Application{}, fmt.Errorf("No applications found matching %s", id)
@@ -451,27 +950,26 @@ func (c *cachingRepository) GetApplication(id string) (Application, error) {
 // chainIDCheck -> strings.Replace(All?)(chainIDCheck, `\\"`, `"`)
 func (c *cachingRepository) GetBlockchain(alias string) (Blockchain, error) {
 	// TODO: throw error -32057 on blockchain not found
-	return blockchainForAlias(alias, c.blockchains)
+	if b, ok := c.current().blockchains.get(alias); ok {
+		return b, nil
+	}
+	return Blockchain{}, fmt.Errorf("No blockchains found matching %s", strings.ToLower(alias))
 }
 
 func (c *cachingRepository) GetLoadBalancer(id string) (LoadBalancer, error) {
-	if lb, ok := c.loadbalancers[id]; ok {
-		return lb, nil
+	if lb, ok := c.current().loadbalancers[id]; ok {
+		return lb, lb.Validate()
 	}
 
 	return LoadBalancer{}, fmt.Errorf("No loadbalancers found matching %s", id)
 }
 
-func blockchainForAlias(alias string, blockchains []Blockchain) (Blockchain, error) {
-	lowercaseAlias := strings.ToLower(alias)
-	for _, b := range blockchains {
-		for _, alias := range b.BlockchainAliases {
-			if strings.ToLower(alias) == lowercaseAlias {
-				return b, nil
-			}
-		}
+// GetPayPlan returns the plan of the catalogue with the type
+func (c *cachingRepository) GetPayPlan(planType PayPlanType) (PayPlan, error) {
+	if plan, ok := c.current().payPlans[planType]; ok {
+		return plan, nil
 	}
-	return Blockchain{}, fmt.Errorf("No blockchains found matching %s", lowercaseAlias)
+	return PayPlan{}, fmt.Errorf("%w: %s", ErrInvalidPayPlanType, planType)
 }
 
 // TODO: these can be moved to the repository_test.go file once we have integrated with a db.
@@ -497,6 +995,19 @@ func loadApplications(file string) (map[string]Application, error) {
 	return m, nil
 }
 
+// loadPayPlans returns the catalogue of the file, or DefaultPayPlans if the file does not exist
+func loadPayPlans(file string) (PayPlans, error) {
+	var plans []PayPlan
+	err := loadData(file, &plans)
+	if os.IsNotExist(err) {
+		return DefaultPayPlans(), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return NewPayPlans(plans)
+}
+
 func loadLoadBalancers(file string) ([]loadBalancer, error) {
 	var lbs []loadBalancer
 	err := loadData(file, &lbs)
@@ -504,23 +1015,33 @@ func loadLoadBalancers(file string) ([]loadBalancer, error) {
 }
 
 // Returns invalid application IDs as the second return value.
+// Load balancers with no valid applications are still returned, and are flagged by LoadBalancer.Validate
 // TODO: remove this check once postgres migration is done as db will check for data integrity
 func buildLoadBalancers(items []loadBalancer, apps map[string]Application) (map[string]LoadBalancer, map[string][]string, error) {
 	lbs := make(map[string]LoadBalancer)
 	invalid := make(map[string][]string)
 	for _, lb := range items {
-		var verifiedApps []*Application
+		verifiedApps := make(map[string]*Application)
 		for _, id := range lb.ApplicationIDs {
-			if app, ok := apps[id]; ok {
-				verifiedApps = append(verifiedApps, &app)
-			} else {
+			app, ok := apps[id]
+			if !ok {
 				invalid[lb.ID] = append(invalid[lb.ID], id)
+				continue
 			}
+			verifiedApps[id] = &app
 		}
 		lbs[lb.ID] = LoadBalancer{
-			ID:           lb.ID,
-			Name:         lb.Name,
-			Applications: verifiedApps,
+			ID:                lb.ID,
+			Name:              lb.Name,
+			UserID:            lb.UserID,
+			ApplicationIDs:    lb.ApplicationIDs,
+			RequestTimeout:    lb.RequestTimeout,
+			Gigastake:         lb.Gigastake,
+			GigastakeRedirect: lb.GigastakeRedirect,
+			StickyOptions:     lb.StickyOptions,
+			Applications:      verifiedApps,
+			CreatedAt:         lb.CreatedAt,
+			UpdatedAt:         lb.UpdatedAt,
 		}
 	}
 	return lbs, invalid, nil
@@ -551,6 +1072,7 @@ const (
 	TableBlockchains          Table = "blockchains"
 	TableRedirects            Table = "redirects"
 	TableSyncCheckOptions     Table = "sync_check_options"
+	TablePayPlans             Table = "pay_plans"
 )
 
 type Action string
@@ -558,6 +1080,10 @@ type Action string
 const (
 	ActionInsert Action = "INSERT"
 	ActionUpdate Action = "UPDATE"
+	ActionDelete Action = "DELETE"
+	// ActionResync is notified when changes may have been missed, e.g. after the listener reconnected:
+	// consumers must reload their whole state. Resync notifications carry no table nor data.
+	ActionResync Action = "RESYNC"
 )
 
 type Notification struct {
@@ -1,5 +1,573 @@
 package repository
 
+import (
+	"context"
+	"errors"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	logger "github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+)
+
+const (
+	testBlockchains = `[{"id": "0021", "blockchain": "eth-mainnet", "blockchainAliases": ["eth-mainnet"]}]`
+	testApps        = `[{"id": "app-1", "name": "app one"}]`
+	testLbs         = `[{"id": "lb-1", "name": "lb one", "applicationIDs": ["app-1"]}]`
+)
+
+func writeRepositoryFiles(t *testing.T, dir, blockchains, apps, lbs string) {
+	t.Helper()
+	files := map[string]string{
+		"Blockchains.json":   blockchains,
+		"Applications.json":  apps,
+		"LoadBalancers.json": lbs,
+	}
+	for name, contents := range files {
+		if err := os.WriteFile(path.Join(dir, name), []byte(contents), 0600); err != nil {
+			t.Fatalf("Error writing %s: %v", name, err)
+		}
+	}
+}
+
+func TestNewRepositoryFailsOnInvalidData(t *testing.T) {
+	testCases := []struct {
+		name        string
+		blockchains string
+		apps        string
+	}{
+		{
+			name:        "Malformed json",
+			blockchains: `[{"id": `,
+			apps:        testApps,
+		},
+		{
+			name:        "Invalid application status",
+			blockchains: testBlockchains,
+			apps:        `[{"id": "app-1", "status": "foo"}]`,
+		},
+		{
+			name:        "Blockchain without ID",
+			blockchains: `[{"blockchain": "eth-mainnet"}]`,
+			apps:        testApps,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeRepositoryFiles(t, dir, tc.blockchains, tc.apps, testLbs)
+
+			if _, err := NewRepository(dir, logger.New()); err == nil {
+				t.Fatalf("Expected error loading repository, got nil")
+			}
+		})
+	}
+
+	if _, err := NewRepository(t.TempDir(), logger.New()); err == nil {
+		t.Errorf("Expected error loading repository from an empty directory, got nil")
+	}
+}
+
+func TestReload(t *testing.T) {
+	dir := t.TempDir()
+	writeRepositoryFiles(t, dir, testBlockchains, testApps, testLbs)
+
+	repo, err := NewRepository(dir, logger.New())
+	if err != nil {
+		t.Fatalf("Error setting up the repository: %v", err)
+	}
+
+	// An invalid update is rejected and the previous contents keep being served
+	writeRepositoryFiles(t, dir, testBlockchains, `[{"id": "app-2", "status": "foo"}]`, testLbs)
+	if err := repo.Reload(); err == nil {
+		t.Fatalf("Expected error reloading invalid data, got nil")
+	}
+	if _, err := repo.GetApplication("app-1"); err != nil {
+		t.Errorf("Expected previous application to be served after failed reload, got: %v", err)
+	}
+
+	writeRepositoryFiles(t, dir, testBlockchains, `[{"id": "app-2", "name": "app two"}]`, testLbs)
+	if err := repo.Reload(); err != nil {
+		t.Fatalf("Unexpected error reloading: %v", err)
+	}
+	if _, err := repo.GetApplication("app-1"); err == nil {
+		t.Errorf("Expected removed application to no longer be served")
+	}
+	if app, err := repo.GetApplication("app-2"); err != nil || app.Name != "app two" {
+		t.Errorf("Expected reloaded application, got: %v, error: %v", app, err)
+	}
+}
+
+func TestWatch(t *testing.T) {
+	dir := t.TempDir()
+	writeRepositoryFiles(t, dir, testBlockchains, testApps, testLbs)
+
+	repo, err := NewRepository(dir, logger.New())
+	if err != nil {
+		t.Fatalf("Error setting up the repository: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	reload := make(chan os.Signal)
+	go repo.Watch(ctx, time.Hour, reload)
+
+	writeRepositoryFiles(t, dir, testBlockchains, `[{"id": "app-2"}]`, testLbs)
+	reload <- os.Interrupt
+	// A second send only completes once the first reload has been processed
+	reload <- os.Interrupt
+
+	if _, err := repo.GetApplication("app-2"); err != nil {
+		t.Errorf("Expected application to be served after signal, got: %v", err)
+	}
+
+	// File modifications are picked up without a signal
+	writeRepositoryFiles(t, dir, testBlockchains, `[{"id": "app-3"}]`, testLbs)
+	future := time.Now().Add(time.Minute)
+	for _, f := range repositoryFiles {
+		if err := os.Chtimes(path.Join(dir, f), future, future); err != nil {
+			t.Fatalf("Error updating modification time: %v", err)
+		}
+	}
+	if _, changed := repo.(*cachingRepository).filesChanged(); !changed {
+		t.Fatalf("Expected file modification to be detected")
+	}
+}
+
+func TestWatchLogFields(t *testing.T) {
+	dir := t.TempDir()
+	writeRepositoryFiles(t, dir, testBlockchains, testApps, testLbs)
+
+	log, hook := test.NewNullLogger()
+	repo, err := NewRepository(dir, log)
+	if err != nil {
+		t.Fatalf("Error setting up the repository: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	reload := make(chan os.Signal)
+	go repo.Watch(ctx, 10*time.Millisecond, reload)
+
+	reload <- os.Interrupt
+
+	// The signal of a previous reload is not logged by the reloads of modified files
+	future := time.Now().Add(time.Minute)
+	for _, f := range repositoryFiles {
+		if err := os.Chtimes(path.Join(dir, f), future, future); err != nil {
+			t.Fatalf("Error updating modification time: %v", err)
+		}
+	}
+
+	var reloads []*logger.Entry
+	for deadline := time.Now().Add(5 * time.Second); len(reloads) < 2 && time.Now().Before(deadline); {
+		time.Sleep(10 * time.Millisecond)
+		reloads = nil
+		for _, entry := range hook.AllEntries() {
+			if entry.Message == "Repository reloaded" {
+				reloads = append(reloads, entry)
+			}
+		}
+	}
+	if len(reloads) != 2 {
+		t.Fatalf("Expected 2 reloads, got: %d", len(reloads))
+	}
+	if _, ok := reloads[0].Data["signal"]; !ok {
+		t.Errorf("Expected signal to be logged on reload by signal")
+	}
+	if sig, ok := reloads[1].Data["signal"]; ok {
+		t.Errorf("Expected no signal to be logged on reload by modification, got: %v", sig)
+	}
+}
+
+func TestWatchFailedReload(t *testing.T) {
+	dir := t.TempDir()
+	writeRepositoryFiles(t, dir, testBlockchains, testApps, testLbs)
+
+	log, hook := test.NewNullLogger()
+	repo, err := NewRepository(dir, log)
+	if err != nil {
+		t.Fatalf("Error setting up the repository: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go repo.Watch(ctx, 10*time.Millisecond, make(chan os.Signal))
+
+	touch := func(modTime time.Time) {
+		for _, f := range repositoryFiles {
+			if err := os.Chtimes(path.Join(dir, f), modTime, modTime); err != nil {
+				t.Fatalf("Error updating modification time: %v", err)
+			}
+		}
+	}
+	countEntries := func(message string) int {
+		count := 0
+		for _, entry := range hook.AllEntries() {
+			if entry.Message == message {
+				count++
+			}
+		}
+		return count
+	}
+	waitEntries := func(message string, expected int) {
+		for deadline := time.Now().Add(5 * time.Second); countEntries(message) < expected && time.Now().Before(deadline); {
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	// Invalid files are warned about once, not on every tick
+	writeRepositoryFiles(t, dir, testBlockchains, `[{"id": "app-2", "status": "foo"}]`, testLbs)
+	touch(time.Now().Add(time.Minute))
+	waitEntries("Error reloading repository, keeping previous contents", 1)
+	time.Sleep(100 * time.Millisecond)
+	if warnings := countEntries("Error reloading repository, keeping previous contents"); warnings != 1 {
+		t.Fatalf("Expected 1 warning for the invalid files, got: %d", warnings)
+	}
+
+	// Files modified again are reloaded
+	writeRepositoryFiles(t, dir, testBlockchains, `[{"id": "app-2"}]`, testLbs)
+	touch(time.Now().Add(2 * time.Minute))
+	waitEntries("Repository reloaded", 1)
+	if _, err := repo.GetApplication("app-2"); err != nil {
+		t.Errorf("Expected application to be served after the files are fixed, got: %v", err)
+	}
+}
+
+func TestReady(t *testing.T) {
+	if err := newTestRepository(t, testBlockchains, testApps, testLbs).Ready(); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if err := newTestRepository(t, `[]`, testApps, testLbs).Ready(); !errors.Is(err, ErrRepositoryNotReady) {
+		t.Errorf("Expected error: %v, got: %v", ErrRepositoryNotReady, err)
+	}
+}
+
+func TestInvalidApplicationIDs(t *testing.T) {
+	repo := newTestRepository(t,
+		`[{"id": "0040", "blockchain": "harmony-0"}, {"id": "0021", "blockchain": "eth-mainnet"}]`,
+		testApps,
+		`[{"id": "lb-1", "applicationIDs": ["app-1", "app-2", "app-3"]}, {"id": "lb-2", "applicationIDs": ["app-1"]}]`,
+	)
+
+	if diff := cmp.Diff(map[string][]string{"lb-1": {"app-2", "app-3"}}, repo.InvalidApplicationIDs()); diff != "" {
+		t.Errorf("unexpected invalid application IDs (-want +got):\n%s", diff)
+	}
+
+	var ids []string
+	for _, b := range repo.Blockchains() {
+		ids = append(ids, b.ID)
+	}
+	if diff := cmp.Diff([]string{"0021", "0040"}, ids); diff != "" {
+		t.Errorf("unexpected blockchains (-want +got):\n%s", diff)
+	}
+
+	// Resynced applications are no longer reported as invalid
+	repo.SetChangeSource(&fakeChangeSource{apps: []*Application{{ID: "app-2"}}})
+	if _, err := repo.Resync(context.Background()); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if diff := cmp.Diff(map[string][]string{"lb-1": {"app-3"}}, repo.InvalidApplicationIDs()); diff != "" {
+		t.Errorf("unexpected invalid application IDs after resync (-want +got):\n%s", diff)
+	}
+}
+
+func newTestRepository(t *testing.T, blockchains, apps, lbs string) ReloadableRepository {
+	t.Helper()
+	dir := t.TempDir()
+	writeRepositoryFiles(t, dir, blockchains, apps, lbs)
+
+	repo, err := NewRepository(dir, logger.New())
+	if err != nil {
+		t.Fatalf("Error setting up the repository: %v", err)
+	}
+	return repo
+}
+
+func TestPayPlans(t *testing.T) {
+	// The default catalogue is used without a pay plans file
+	repo := newTestRepository(t, testBlockchains, `[{"id": "app-1", "limit": {"payPlan": {"planType": "FREETIER_V0"}}}]`, testLbs)
+	if plan, err := repo.GetPayPlan(FreetierV0); err != nil || plan.Limit != 250000 {
+		t.Errorf("Expected default pay plan, got: %v, error: %v", plan, err)
+	}
+
+	dir := t.TempDir()
+	writeRepositoryFiles(t, dir, testBlockchains, `[{"id": "app-1", "limit": {"payPlan": {"planType": "FREETIER_V0"}}}]`, testLbs)
+	plans := `[{"planType": "FREETIER_V0", "dailyLimit": 1000, "rateLimit": 10, "allowedChains": ["0021"], "aatPlan": "Premium"}]`
+	if err := os.WriteFile(path.Join(dir, payPlansFile), []byte(plans), 0600); err != nil {
+		t.Fatalf("Error writing %s: %v", payPlansFile, err)
+	}
+
+	repo, err := NewRepository(dir, logger.New())
+	if err != nil {
+		t.Fatalf("Error setting up the repository: %v", err)
+	}
+	plan, err := repo.GetPayPlan(FreetierV0)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := PayPlan{Type: FreetierV0, Limit: 1000, RateLimit: 10, AllowedChains: []string{"0021"}, AatPlan: AatPlanPremium}
+	if diff := cmp.Diff(expected, plan); diff != "" {
+		t.Errorf("unexpected value (-want +got):\n%s", diff)
+	}
+	if !plan.AllowsChain("0021") || plan.AllowsChain("0040") {
+		t.Errorf("Expected only allowed chains to be allowed, got: %v", plan.AllowedChains)
+	}
+
+	// Applications on plans missing from the catalogue are rejected
+	writeRepositoryFiles(t, dir, testBlockchains, `[{"id": "app-1", "limit": {"payPlan": {"planType": "ENTERPRISE"}, "customLimit": 10}}]`, testLbs)
+	if err := repo.Reload(); !errors.Is(err, ErrInvalidPayPlanType) {
+		t.Errorf("Expected error: %v, got: %v", ErrInvalidPayPlanType, err)
+	}
+
+	if err := os.WriteFile(path.Join(dir, payPlansFile), []byte(`[{"planType": "ENTERPRISE"}, {"planType": "ENTERPRISE"}]`), 0600); err != nil {
+		t.Fatalf("Error writing %s: %v", payPlansFile, err)
+	}
+	if err := repo.Reload(); !errors.Is(err, ErrDuplicatePayPlan) {
+		t.Errorf("Expected error: %v, got: %v", ErrDuplicatePayPlan, err)
+	}
+}
+
+func TestGetBlockchain(t *testing.T) {
+	repo := newTestRepository(t, `[
+		{"id": "0021", "blockchain": "eth-mainnet", "blockchainAliases": ["eth-mainnet", "Eth-Archival"]},
+		{"id": "0001", "blockchain": "pokt-mainnet", "blockchainAliases": ["mainnet"]}
+	]`, testApps, testLbs)
+
+	testCases := []struct {
+		name        string
+		alias       string
+		expectedID  string
+		expectedErr bool
+	}{
+		{
+			name:       "Blockchain is found by alias",
+			alias:      "eth-mainnet",
+			expectedID: "0021",
+		},
+		{
+			name:       "Alias lookup is case-insensitive",
+			alias:      "ETH-archival",
+			expectedID: "0021",
+		},
+		{
+			name:       "Blockchain is found by ID",
+			alias:      "0001",
+			expectedID: "0001",
+		},
+		{
+			name:        "Unknown alias returns error",
+			alias:       "foo",
+			expectedErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := repo.GetBlockchain(tc.alias)
+			if tc.expectedErr {
+				if err == nil {
+					t.Fatalf("Expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if got.ID != tc.expectedID {
+				t.Errorf("Expected blockchain %s, got: %s", tc.expectedID, got.ID)
+			}
+		})
+	}
+}
+
+func TestNewBlockchainIndex(t *testing.T) {
+	testCases := []struct {
+		name        string
+		blockchains []Blockchain
+		expectedErr error
+	}{
+		{
+			name: "Distinct aliases are indexed",
+			blockchains: []Blockchain{
+				{ID: "0021", BlockchainAliases: []string{"eth-mainnet"}},
+				{ID: "0001", BlockchainAliases: []string{"mainnet"}},
+			},
+		},
+		{
+			name: "Duplicate aliases across blockchains are rejected",
+			blockchains: []Blockchain{
+				{ID: "0021", BlockchainAliases: []string{"eth-mainnet"}},
+				{ID: "0028", BlockchainAliases: []string{"ETH-Mainnet"}},
+			},
+			expectedErr: ErrDuplicateBlockchainAlias,
+		},
+		{
+			name: "Duplicate IDs are rejected",
+			blockchains: []Blockchain{
+				{ID: "0021", BlockchainAliases: []string{"eth-mainnet"}},
+				{ID: "0021", BlockchainAliases: []string{"eth-archival"}},
+			},
+			expectedErr: ErrDuplicateBlockchainID,
+		},
+		{
+			name:        "Blockchains without ID are rejected",
+			blockchains: []Blockchain{{Blockchain: "eth-mainnet"}},
+			expectedErr: ErrMissingID,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := newBlockchainIndex(tc.blockchains)
+			if !errors.Is(err, tc.expectedErr) {
+				t.Errorf("Expected error: %v, got: %v", tc.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestBuildLoadBalancers(t *testing.T) {
+	createdAt := time.Date(2022, time.June, 1, 0, 0, 0, 0, time.UTC)
+	apps := map[string]Application{
+		"app-1": {ID: "app-1", Name: "app one"},
+		"app-2": {ID: "app-2", Name: "app two"},
+	}
+
+	testCases := []struct {
+		name            string
+		items           []loadBalancer
+		expected        map[string]LoadBalancer
+		expectedInvalid map[string][]string
+		expectedErrs    map[string]error
+	}{
+		{
+			name: "All fields are carried over",
+			items: []loadBalancer{
+				{
+					ID:                "lb-1",
+					Name:              "lb one",
+					UserID:            "user-1",
+					ApplicationIDs:    []string{"app-1", "app-2"},
+					RequestTimeout:    2000,
+					Gigastake:         true,
+					GigastakeRedirect: true,
+					StickyOptions: StickyOptions{
+						Duration:      "30",
+						StickyOrigins: []string{"origin-1"},
+						StickyMax:     100,
+						Stickiness:    true,
+					},
+					CreatedAt: createdAt,
+					UpdatedAt: createdAt,
+				},
+			},
+			expected: map[string]LoadBalancer{
+				"lb-1": {
+					ID:                "lb-1",
+					Name:              "lb one",
+					UserID:            "user-1",
+					ApplicationIDs:    []string{"app-1", "app-2"},
+					RequestTimeout:    2000,
+					Gigastake:         true,
+					GigastakeRedirect: true,
+					StickyOptions: StickyOptions{
+						Duration:      "30",
+						StickyOrigins: []string{"origin-1"},
+						StickyMax:     100,
+						Stickiness:    true,
+					},
+					Applications: map[string]*Application{
+						"app-1": {ID: "app-1", Name: "app one"},
+						"app-2": {ID: "app-2", Name: "app two"},
+					},
+					CreatedAt: createdAt,
+					UpdatedAt: createdAt,
+				},
+			},
+			expectedInvalid: map[string][]string{},
+		},
+		{
+			name: "Invalid application IDs are reported",
+			items: []loadBalancer{
+				{ID: "lb-1", ApplicationIDs: []string{"app-1", "app-3"}},
+			},
+			expected: map[string]LoadBalancer{
+				"lb-1": {
+					ID:             "lb-1",
+					ApplicationIDs: []string{"app-1", "app-3"},
+					Applications: map[string]*Application{
+						"app-1": {ID: "app-1", Name: "app one"},
+					},
+				},
+			},
+			expectedInvalid: map[string][]string{"lb-1": {"app-3"}},
+		},
+		{
+			name: "Load balancer with no valid applications is flagged",
+			items: []loadBalancer{
+				{ID: "lb-1", ApplicationIDs: []string{"app-3"}},
+				{ID: "lb-2"},
+			},
+			expected: map[string]LoadBalancer{
+				"lb-1": {
+					ID:             "lb-1",
+					ApplicationIDs: []string{"app-3"},
+					Applications:   map[string]*Application{},
+				},
+				"lb-2": {
+					ID:           "lb-2",
+					Applications: map[string]*Application{},
+				},
+			},
+			expectedInvalid: map[string][]string{"lb-1": {"app-3"}},
+			expectedErrs: map[string]error{
+				"lb-1": ErrNoValidApplications,
+				"lb-2": ErrNoValidApplications,
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			lbs, invalid, err := buildLoadBalancers(tc.items, apps)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tc.expected, lbs); diff != "" {
+				t.Errorf("unexpected value (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.expectedInvalid, invalid); diff != "" {
+				t.Errorf("unexpected invalid IDs (-want +got):\n%s", diff)
+			}
+			for id, lb := range lbs {
+				if err := lb.Validate(); !errors.Is(err, tc.expectedErrs[id]) {
+					t.Errorf("Expected error for %s: %v, got: %v", id, tc.expectedErrs[id], err)
+				}
+			}
+		})
+	}
+}
+
+func TestBuildLoadBalancersDoesNotAliasApplications(t *testing.T) {
+	apps := map[string]Application{
+		"app-1": {ID: "app-1"},
+		"app-2": {ID: "app-2"},
+		"app-3": {ID: "app-3"},
+	}
+
+	lbs, _, _ := buildLoadBalancers([]loadBalancer{{ID: "lb-1", ApplicationIDs: []string{"app-1", "app-2", "app-3"}}}, apps)
+	for id, app := range lbs["lb-1"].Applications {
+		if app.ID != id {
+			t.Errorf("Expected application %s, got: %s", id, app.ID)
+		}
+	}
+}
+
 // "encoding/json"
 
 // "io/ioutil"
@@ -0,0 +1,357 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	logger "github.com/sirupsen/logrus"
+)
+
+// resyncTimeout bounds the reads of an incremental resync triggered by a notification gap
+const resyncTimeout = time.Minute
+
+// resyncOverlap is read again before the watermark on resyncs: update times are stamped by the writers before
+// their transactions commit, so a transaction committing after a later-stamped one, or stamped by a writer with a
+// clock behind, is only visible below the watermark. Entities read again are merged as they are, so the overlap
+// only costs the reads.
+const resyncOverlap = 5 * time.Minute
+
+// ErrNoChangeSource error when an incremental resync is requested without a source of changes
+var ErrNoChangeSource = errors.New("no change source set")
+
+// ChangeSource returns the entities updated at or after a given time, and the pay plan catalogue,
+// e.g. postgresdriver.PostgresDriver
+type ChangeSource interface {
+	ReadPayPlansContext(ctx context.Context) ([]*PayPlan, error)
+	ReadApplicationsUpdatedSinceContext(ctx context.Context, since time.Time) ([]*Application, error)
+	ReadLoadBalancersUpdatedSinceContext(ctx context.Context, since time.Time) ([]*LoadBalancer, error)
+	ReadBlockchainsUpdatedSinceContext(ctx context.Context, since time.Time) ([]*Blockchain, error)
+}
+
+// ResyncResult is the outcome of an incremental resync
+type ResyncResult struct {
+	Applications  int
+	LoadBalancers int
+	Blockchains   int
+	PayPlans      int
+	// Watermark is the latest update time of the repository contents after the resync
+	Watermark time.Time
+}
+
+// NewRepositoryFromSource returns a repository loaded from the change source, e.g. postgres, instead of
+// json files. Reloads read all the entities from the source again.
+func NewRepositoryFromSource(ctx context.Context, source ChangeSource, log *logger.Logger) (ReloadableRepository, error) {
+	c := &cachingRepository{
+		source: source,
+		log:    log,
+	}
+
+	s, err := loadSourceSnapshot(ctx, source, log)
+	if err != nil {
+		return nil, err
+	}
+	c.snapshot = s
+
+	return c, nil
+}
+
+// loadSourceSnapshot builds a snapshot from all the entities of the source
+func loadSourceSnapshot(ctx context.Context, source ChangeSource, log *logger.Logger) (*snapshot, error) {
+	changes, err := readChanges(ctx, source, time.Time{})
+	if err != nil {
+		return nil, err
+	}
+
+	index, err := newBlockchainIndex(nil)
+	if err != nil {
+		return nil, err
+	}
+	empty := &snapshot{
+		apps:          make(map[string]Application),
+		blockchains:   index,
+		loadbalancers: make(map[string]LoadBalancer),
+		payPlans:      DefaultPayPlans(),
+	}
+
+	return empty.merge(changes.payPlans, changes.blockchains, changes.apps, changes.lbs, log), nil
+}
+
+// changes holds the pay plan catalogue and the entities updated since a given time
+type changes struct {
+	payPlans    PayPlans
+	blockchains []*Blockchain
+	apps        []*Application
+	lbs         []*LoadBalancer
+}
+
+func readChanges(ctx context.Context, source ChangeSource, since time.Time) (changes, error) {
+	payPlans, err := source.ReadPayPlansContext(ctx)
+	if err != nil {
+		return changes{}, err
+	}
+
+	blockchains, err := source.ReadBlockchainsUpdatedSinceContext(ctx, since)
+	if err != nil {
+		return changes{}, err
+	}
+
+	apps, err := source.ReadApplicationsUpdatedSinceContext(ctx, since)
+	if err != nil {
+		return changes{}, err
+	}
+
+	lbs, err := source.ReadLoadBalancersUpdatedSinceContext(ctx, since)
+	if err != nil {
+		return changes{}, err
+	}
+
+	plans := make([]PayPlan, 0, len(payPlans))
+	for _, plan := range payPlans {
+		plans = append(plans, *plan)
+	}
+	catalogue, err := NewPayPlans(plans)
+	if err != nil {
+		return changes{}, err
+	}
+
+	return changes{
+		payPlans:    catalogue,
+		blockchains: blockchains,
+		apps:        apps,
+		lbs:         lbs,
+	}, nil
+}
+
+// SetChangeSource enables incremental resyncs: once set, resync notifications fetch from the source
+// only the entities updated since the watermark, instead of reloading the whole repository
+func (c *cachingRepository) SetChangeSource(source ChangeSource) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.source = source
+}
+
+// Watermark returns the latest update time of the repository contents
+func (c *cachingRepository) Watermark() time.Time {
+	return c.current().watermark
+}
+
+// Resync fetches the entities updated since the watermark, less resyncOverlap, and merges them into a copy of
+// the current snapshot, which is swapped in with the new watermark.
+// Deleted entities are not detected, they are kept until the next full reload.
+func (c *cachingRepository) Resync(ctx context.Context) (ResyncResult, error) {
+	c.mu.RLock()
+	source := c.source
+	since := c.snapshot.watermark
+	c.mu.RUnlock()
+
+	if !since.IsZero() {
+		since = since.Add(-resyncOverlap)
+	}
+
+	if source == nil {
+		return ResyncResult{}, ErrNoChangeSource
+	}
+
+	changes, err := readChanges(ctx, source, since)
+	if err != nil {
+		return ResyncResult{}, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	s := c.snapshot.merge(changes.payPlans, changes.blockchains, changes.apps, changes.lbs, c.log)
+	c.snapshot = s
+
+	return ResyncResult{
+		Applications:  len(changes.apps),
+		LoadBalancers: len(changes.lbs),
+		Blockchains:   len(changes.blockchains),
+		PayPlans:      len(changes.payPlans),
+		Watermark:     s.watermark,
+	}, nil
+}
+
+// resync runs an incremental resync if a change source is set, falling back to a full reload
+func (c *cachingRepository) resync() {
+	ctx, cancel := context.WithTimeout(context.Background(), resyncTimeout)
+	defer cancel()
+
+	result, err := c.Resync(ctx)
+	if err == nil {
+		c.log.WithFields(logger.Fields{
+			"applications":  result.Applications,
+			"loadBalancers": result.LoadBalancers,
+			"blockchains":   result.Blockchains,
+			"payPlans":      result.PayPlans,
+			"watermark":     result.Watermark,
+		}).Info("Repository incrementally resynced")
+		return
+	}
+	if !errors.Is(err, ErrNoChangeSource) {
+		c.log.WithFields(logger.Fields{"error": err}).Warn("Error incrementally resyncing repository, reloading")
+	}
+
+	if err := c.Reload(); err != nil {
+		c.log.WithFields(logger.Fields{"error": err}).Warn("Error resyncing repository, keeping previous contents")
+		return
+	}
+	c.log.Info("Repository resynced")
+}
+
+// merge returns a copy of the snapshot with the updated entities, entities failing validation are skipped.
+// An empty catalogue keeps the current one.
+func (s *snapshot) merge(payPlans PayPlans, blockchains []*Blockchain, apps []*Application, lbs []*LoadBalancer, log *logger.Logger) *snapshot {
+	merged := *s
+
+	if len(payPlans) > 0 {
+		merged.payPlans = payPlans
+	}
+
+	if len(blockchains) > 0 {
+		merged.blockchains = s.blockchains.copy()
+	}
+	for _, data := range blockchains {
+		b := *data
+		if old, ok := merged.blockchains.byID[b.ID]; ok && len(b.Redirects) == 0 {
+			// Redirects are not part of the blockchain reads
+			b.Redirects = old.Redirects
+		}
+		if err := merged.blockchains.set(b); err != nil {
+			log.WithFields(logger.Fields{"error": err, "blockchain": b.ID}).Warn("Skipping invalid blockchain on resync")
+			continue
+		}
+		merged.watermark = laterOf(merged.watermark, b.UpdatedAt)
+	}
+
+	changedApps := make(map[string]bool, len(apps))
+	if len(apps) > 0 {
+		merged.apps = make(map[string]Application, len(s.apps)+len(apps))
+		for id, app := range s.apps {
+			merged.apps[id] = app
+		}
+	}
+	for _, app := range apps {
+		err := app.Validate()
+		if err == nil {
+			err = merged.payPlans.Validate(app.Limit.PayPlan.Type)
+		}
+		if err != nil || app.ID == "" {
+			log.WithFields(logger.Fields{"error": err, "application": app.ID}).Warn("Skipping invalid application on resync")
+			continue
+		}
+		merged.apps[app.ID] = *app
+		changedApps[app.ID] = true
+		merged.watermark = laterOf(merged.watermark, app.UpdatedAt)
+	}
+
+	if len(lbs) == 0 && len(changedApps) == 0 {
+		return &merged
+	}
+
+	merged.loadbalancers = make(map[string]LoadBalancer, len(s.loadbalancers)+len(lbs))
+	for id, lb := range s.loadbalancers {
+		merged.loadbalancers[id] = lb.withApplications(changedApps, merged.apps)
+	}
+
+	items := make([]loadBalancer, 0, len(lbs))
+	for _, lb := range lbs {
+		items = append(items, loadBalancer{
+			ID:                lb.ID,
+			Name:              lb.Name,
+			UserID:            lb.UserID,
+			ApplicationIDs:    lb.ApplicationIDs,
+			RequestTimeout:    lb.RequestTimeout,
+			Gigastake:         lb.Gigastake,
+			GigastakeRedirect: lb.GigastakeRedirect,
+			StickyOptions:     lb.StickyOptions,
+			CreatedAt:         lb.CreatedAt,
+			UpdatedAt:         lb.UpdatedAt,
+		})
+		merged.watermark = laterOf(merged.watermark, lb.UpdatedAt)
+	}
+
+	built, invalidAppIDs, _ := buildLoadBalancers(items, merged.apps)
+	if len(invalidAppIDs) > 0 {
+		log.WithFields(logger.Fields{"invalidApplicationIDs": invalidAppIDs}).Warn("One or more of the resynced application IDs were invalid.")
+	}
+	for id, lb := range built {
+		merged.loadbalancers[id] = lb
+	}
+	merged.invalidAppIDs = mergeInvalidAppIDs(s.invalidAppIDs, invalidAppIDs, built, merged.apps)
+
+	return &merged
+}
+
+// mergeInvalidAppIDs returns the invalid application IDs of the rebuilt load balancers, and the ones of the
+// other load balancers that are still missing from the applications
+func mergeInvalidAppIDs(current, rebuiltInvalid map[string][]string, rebuilt map[string]LoadBalancer, apps map[string]Application) map[string][]string {
+	merged := make(map[string][]string, len(current)+len(rebuiltInvalid))
+	for lbID, ids := range current {
+		if _, ok := rebuilt[lbID]; ok {
+			continue
+		}
+		for _, id := range ids {
+			if _, ok := apps[id]; !ok {
+				merged[lbID] = append(merged[lbID], id)
+			}
+		}
+	}
+	for lbID, ids := range rebuiltInvalid {
+		merged[lbID] = ids
+	}
+	return merged
+}
+
+// withApplications returns the load balancer with the verified copies of the changed applications
+// it includes, or the load balancer itself if it includes none of them
+func (l LoadBalancer) withApplications(changed map[string]bool, apps map[string]Application) LoadBalancer {
+	includesChanged := false
+	for _, id := range l.ApplicationIDs {
+		if changed[id] {
+			includesChanged = true
+			break
+		}
+	}
+	if !includesChanged {
+		return l
+	}
+
+	verified := make(map[string]*Application, len(l.Applications))
+	for id, app := range l.Applications {
+		verified[id] = app
+	}
+	for _, id := range l.ApplicationIDs {
+		if !changed[id] {
+			continue
+		}
+		app := apps[id]
+		verified[id] = &app
+	}
+	l.Applications = verified
+
+	return l
+}
+
+// latestUpdate returns the latest update time of the snapshot contents
+func (s *snapshot) latestUpdate() time.Time {
+	var latest time.Time
+	for _, app := range s.apps {
+		latest = laterOf(latest, app.UpdatedAt)
+	}
+	for _, lb := range s.loadbalancers {
+		latest = laterOf(latest, lb.UpdatedAt)
+	}
+	for _, b := range s.blockchains.byID {
+		latest = laterOf(latest, b.UpdatedAt)
+	}
+	return latest
+}
+
+func laterOf(a, b time.Time) time.Time {
+	if b.After(a) {
+		return b
+	}
+	return a
+}
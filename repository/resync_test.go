@@ -0,0 +1,210 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	logger "github.com/sirupsen/logrus"
+)
+
+type fakeChangeSource struct {
+	payPlans    []*PayPlan
+	apps        []*Application
+	lbs         []*LoadBalancer
+	blockchains []*Blockchain
+	err         error
+	since       time.Time
+}
+
+func (f *fakeChangeSource) ReadPayPlansContext(ctx context.Context) ([]*PayPlan, error) {
+	return f.payPlans, f.err
+}
+
+func (f *fakeChangeSource) ReadApplicationsUpdatedSinceContext(ctx context.Context, since time.Time) ([]*Application, error) {
+	f.since = since
+	return f.apps, f.err
+}
+
+func (f *fakeChangeSource) ReadLoadBalancersUpdatedSinceContext(ctx context.Context, since time.Time) ([]*LoadBalancer, error) {
+	return f.lbs, f.err
+}
+
+func (f *fakeChangeSource) ReadBlockchainsUpdatedSinceContext(ctx context.Context, since time.Time) ([]*Blockchain, error) {
+	return f.blockchains, f.err
+}
+
+func TestResync(t *testing.T) {
+	repo := newTestRepository(t,
+		`[{"id": "0021", "blockchain": "eth-mainnet", "blockchainAliases": ["eth-mainnet"], "redirects": [{"alias": "eth-rpc", "domain": "eth-rpc.gateway.pokt.network"}], "updatedAt": "2022-01-01T00:00:00Z"}]`,
+		`[{"id": "app-1", "name": "app one", "updatedAt": "2022-01-02T00:00:00Z"}, {"id": "app-2", "name": "app two"}]`,
+		`[{"id": "lb-1", "name": "lb one", "applicationIDs": ["app-1", "app-2"], "updatedAt": "2022-01-03T00:00:00Z"}]`,
+	)
+
+	watermark := time.Date(2022, time.January, 3, 0, 0, 0, 0, time.UTC)
+	if !repo.Watermark().Equal(watermark) {
+		t.Fatalf("Expected initial watermark %v, got: %v", watermark, repo.Watermark())
+	}
+
+	if _, err := repo.Resync(context.Background()); !errors.Is(err, ErrNoChangeSource) {
+		t.Fatalf("Expected error: %v, got: %v", ErrNoChangeSource, err)
+	}
+
+	updatedAt := watermark.Add(time.Hour)
+	source := &fakeChangeSource{
+		payPlans: []*PayPlan{{Type: FreetierV0, Limit: 1000}},
+		apps: []*Application{
+			{ID: "app-1", Name: "app one renamed", Limit: AppLimit{PayPlan: PayPlan{Type: FreetierV0}}, UpdatedAt: updatedAt},
+			{ID: "app-3", Status: "foo", UpdatedAt: updatedAt.Add(time.Hour)},
+			{ID: "app-4", Limit: AppLimit{PayPlan: PayPlan{Type: Enterprise}}, UpdatedAt: updatedAt.Add(time.Hour)},
+		},
+		lbs: []*LoadBalancer{
+			{ID: "lb-2", Name: "lb two", ApplicationIDs: []string{"app-1"}, UpdatedAt: updatedAt},
+		},
+		blockchains: []*Blockchain{
+			{ID: "0021", Blockchain: "eth-mainnet", BlockchainAliases: []string{"eth-mainnet", "eth-archival"}, UpdatedAt: updatedAt},
+			{ID: "0040", Blockchain: "harmony-0", BlockchainAliases: []string{"harmony-0"}, UpdatedAt: updatedAt},
+		},
+	}
+	repo.SetChangeSource(source)
+
+	result, err := repo.Resync(context.Background())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if since := watermark.Add(-resyncOverlap); !source.since.Equal(since) {
+		t.Errorf("Expected changes to be read since %v, got: %v", since, source.since)
+	}
+	if result.Applications != 3 || result.LoadBalancers != 1 || result.Blockchains != 2 || result.PayPlans != 1 {
+		t.Errorf("Unexpected resync counts: %+v", result)
+	}
+	// Skipped entities do not advance the watermark
+	if !result.Watermark.Equal(updatedAt) || !repo.Watermark().Equal(updatedAt) {
+		t.Errorf("Expected watermark %v, got: %v, %v", updatedAt, result.Watermark, repo.Watermark())
+	}
+
+	if app, err := repo.GetApplication("app-1"); err != nil || app.Name != "app one renamed" {
+		t.Errorf("Expected updated application, got: %v, error: %v", app, err)
+	}
+	if _, err := repo.GetApplication("app-3"); err == nil {
+		t.Errorf("Expected invalid application to be skipped")
+	}
+	if _, err := repo.GetApplication("app-4"); err == nil {
+		t.Errorf("Expected application on a plan missing from the catalogue to be skipped")
+	}
+	if plan, err := repo.GetPayPlan(FreetierV0); err != nil || plan.Limit != 1000 {
+		t.Errorf("Expected resynced pay plan, got: %v, error: %v", plan, err)
+	}
+	if _, err := repo.GetPayPlan(Enterprise); !errors.Is(err, ErrInvalidPayPlanType) {
+		t.Errorf("Expected pay plan missing from the resynced catalogue to be removed, got: %v", err)
+	}
+
+	lb, err := repo.GetLoadBalancer("lb-1")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if lb.Applications["app-1"].Name != "app one renamed" || lb.Applications["app-2"].Name != "app two" {
+		t.Errorf("Expected load balancer applications to be refreshed, got: %v", lb.Applications)
+	}
+	if lb, err := repo.GetLoadBalancer("lb-2"); err != nil || lb.Applications["app-1"] == nil {
+		t.Errorf("Expected new load balancer with its applications, got: %v, error: %v", lb, err)
+	}
+
+	b, err := repo.GetBlockchain("eth-archival")
+	if err != nil {
+		t.Fatalf("Expected new alias to match, got: %v", err)
+	}
+	if len(b.Redirects) != 1 {
+		t.Errorf("Expected redirects to be kept, got: %v", b.Redirects)
+	}
+	if _, err := repo.GetBlockchain("harmony-0"); err != nil {
+		t.Errorf("Expected new blockchain to be found, got: %v", err)
+	}
+
+	// Entities committed after later-stamped ones are read within the overlap, without moving the watermark back
+	source.apps = []*Application{{ID: "app-2", Name: "app two renamed", UpdatedAt: updatedAt.Add(-time.Minute)}}
+	source.lbs, source.blockchains = nil, nil
+	if _, err := repo.Resync(context.Background()); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if app, err := repo.GetApplication("app-2"); err != nil || app.Name != "app two renamed" {
+		t.Errorf("Expected application updated before the watermark, got: %v, error: %v", app, err)
+	}
+	if !repo.Watermark().Equal(updatedAt) {
+		t.Errorf("Expected watermark to be kept, got: %v", repo.Watermark())
+	}
+
+	// A failed read keeps the current contents
+	source.err = errors.New("dummy error")
+	if _, err := repo.Resync(context.Background()); err == nil {
+		t.Fatalf("Expected error resyncing, got nil")
+	}
+	if !repo.Watermark().Equal(updatedAt) {
+		t.Errorf("Expected watermark to be kept, got: %v", repo.Watermark())
+	}
+}
+
+func TestListenResync(t *testing.T) {
+	repo := newTestRepository(t, testBlockchains, testApps, testLbs)
+	repo.SetChangeSource(&fakeChangeSource{
+		apps: []*Application{{ID: "app-2", Name: "app two"}},
+	})
+
+	notifications := make(chan *Notification)
+	done := make(chan struct{})
+	go func() {
+		repo.Listen(notifications)
+		close(done)
+	}()
+
+	notifications <- &Notification{Action: ActionResync}
+	close(notifications)
+	<-done
+
+	if _, err := repo.GetApplication("app-2"); err != nil {
+		t.Errorf("Expected application to be resynced from the change source, got: %v", err)
+	}
+}
+
+func TestNewRepositoryFromSource(t *testing.T) {
+	source := &fakeChangeSource{
+		apps: []*Application{{ID: "app-1", Name: "app one"}},
+		lbs:  []*LoadBalancer{{ID: "lb-1", Name: "lb one", ApplicationIDs: []string{"app-1"}}},
+		blockchains: []*Blockchain{
+			{ID: "0021", Blockchain: "eth-mainnet", BlockchainAliases: []string{"eth-mainnet"}},
+		},
+	}
+
+	repo, err := NewRepositoryFromSource(context.Background(), source, logger.New())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !source.since.IsZero() {
+		t.Errorf("Expected all entities to be read, got changes since: %v", source.since)
+	}
+	if err := repo.Ready(); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if lb, err := repo.GetLoadBalancer("lb-1"); err != nil || lb.Applications["app-1"] == nil {
+		t.Errorf("Expected load balancer with its applications, got: %v, error: %v", lb, err)
+	}
+	if _, err := repo.GetPayPlan(FreetierV0); err != nil {
+		t.Errorf("Expected default pay plans without a catalogue in the source, got: %v", err)
+	}
+
+	// Reloads read all the entities again, dropping the deleted ones
+	source.apps = nil
+	source.lbs = nil
+	if err := repo.Reload(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := repo.GetApplication("app-1"); err == nil {
+		t.Errorf("Expected deleted application to be dropped on reload")
+	}
+
+	source.err = errors.New("dummy error")
+	if _, err := NewRepositoryFromSource(context.Background(), source, logger.New()); err == nil {
+		t.Errorf("Expected error loading the repository, got nil")
+	}
+}
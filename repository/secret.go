@@ -0,0 +1,31 @@
+package repository
+
+import "encoding/json"
+
+// RedactedSecret replaces the values of secrets wherever they are formatted, marshaled or recorded, e.g. in the audit log
+const RedactedSecret = "[REDACTED]"
+
+// Secret holds a sensitive value, e.g. an application's private key.
+// It is redacted whenever it is formatted or marshaled, so it can not leak through logs or API responses:
+// the underlying value is only accessible through Reveal.
+type Secret string
+
+// Reveal returns the plain text value of the secret
+func (s Secret) Reveal() string {
+	return string(s)
+}
+
+func (s Secret) String() string {
+	if s == "" {
+		return ""
+	}
+	return RedactedSecret
+}
+
+func (s Secret) GoString() string {
+	return s.String()
+}
+
+func (s Secret) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.String())
+}
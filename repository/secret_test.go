@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	logger "github.com/sirupsen/logrus"
+)
+
+func TestSecretIsRedacted(t *testing.T) {
+	const privateKey = "6f8f4c1ab5d0b0c9d4d6e0a3f2b1c0d9"
+
+	app := &Application{
+		ID:              "app-1",
+		GatewayAAT:      GatewayAAT{PrivateKey: privateKey},
+		GatewaySettings: GatewaySettings{SecretKey: privateKey},
+	}
+
+	if app.GatewayAAT.PrivateKey.Reveal() != privateKey {
+		t.Fatalf("Expected revealed secret %q, got: %q", privateKey, app.GatewayAAT.PrivateKey.Reveal())
+	}
+
+	formatted := []string{
+		fmt.Sprint(app.GatewayAAT.PrivateKey),
+		fmt.Sprintf("%v", *app),
+		fmt.Sprintf("%+v", *app),
+		fmt.Sprintf("%#v", *app),
+		fmt.Sprintf("%s", app.GatewaySettings.SecretKey),
+	}
+
+	marshaled, err := json.Marshal(app)
+	if err != nil {
+		t.Fatalf("Unexpected error marshaling application: %v", err)
+	}
+	formatted = append(formatted, string(marshaled))
+
+	for _, formatter := range []logger.Formatter{&logger.TextFormatter{}, &logger.JSONFormatter{}} {
+		var out bytes.Buffer
+		log := logger.New()
+		log.SetOutput(&out)
+		log.SetFormatter(formatter)
+
+		log.WithFields(logger.Fields{"application": *app, "gatewayAAT": app.GatewayAAT}).Info("relay")
+		formatted = append(formatted, out.String())
+	}
+
+	for _, f := range formatted {
+		if strings.Contains(f, privateKey) {
+			t.Errorf("Secret leaked in: %s", f)
+		}
+		if !strings.Contains(f, RedactedSecret) {
+			t.Errorf("Expected redacted secret in: %s", f)
+		}
+	}
+
+	if Secret("").String() != "" {
+		t.Errorf("Expected empty secret to be formatted as empty string")
+	}
+}
+
+func TestSecretUnmarshal(t *testing.T) {
+	var aat GatewayAAT
+	if err := json.Unmarshal([]byte(`{"privateKey": "abc"}`), &aat); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if aat.PrivateKey.Reveal() != "abc" {
+		t.Errorf("Expected secret %q, got: %q", "abc", aat.PrivateKey.Reveal())
+	}
+}
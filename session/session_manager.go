@@ -1,6 +1,10 @@
 package session
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
 	"time"
 
 	"github.com/pokt-foundation/pocket-go/provider"
@@ -10,15 +14,34 @@ type SessionManager interface {
 	GetSession(Key) (*provider.Session, error)
 }
 
-func NewSessionManager(dispatchUrls []string) SessionManager {
+// Settings configures the dispatch of sessions and how long they are cached
+type Settings struct {
+	TTL     time.Duration `yaml:"ttl"`
+	Timeout time.Duration `yaml:"timeout"`
+	Retries int           `yaml:"retries"`
+}
+
+func DefaultSettings() Settings {
+	return Settings{
+		TTL:     60 * time.Second,
+		Timeout: 20 * time.Second,
+	}
+}
+
+func NewSessionManager(dispatchUrls []string, settings Settings) SessionManager {
 	return &sessionManager{
 		dispatchUrls: dispatchUrls,
+		settings:     settings,
+		sessions:     make(map[Key]cacheEntry),
 	}
 }
 
-var defaultTTL = 60 * time.Second
+// dispatcherPingTimeout bounds the requests checking whether dispatchers are reachable
+const dispatcherPingTimeout = 5 * time.Second
+
+var ErrDispatchersUnreachable = errors.New("no dispatcher is reachable")
 
-// TODO: add options to session manager: retryAttempts, rejectSelfSignedCertificates, timeout
+// TODO: add options to session manager: rejectSelfSignedCertificates
 
 type cacheEntry struct {
 	*provider.Session
@@ -27,7 +50,10 @@ type cacheEntry struct {
 
 type sessionManager struct {
 	dispatchUrls []string
-	sessions     map[Key]cacheEntry
+	settings     Settings
+
+	mu       sync.Mutex
+	sessions map[Key]cacheEntry
 }
 
 type Key struct {
@@ -35,9 +61,13 @@ type Key struct {
 	BlockchainID string
 }
 
+// GetSession returns the cached session of the key, dispatching a new one once the cached one expires
 func (s *sessionManager) GetSession(k Key) (*provider.Session, error) {
+	s.mu.Lock()
 	cached, ok := s.sessions[k]
-	if !ok || cached.TTL.After(time.Now()) {
+	s.mu.Unlock()
+
+	if !ok || cached.TTL.Before(time.Now()) {
 		return s.newSession(k)
 	}
 	return cached.Session, nil
@@ -45,14 +75,49 @@ func (s *sessionManager) GetSession(k Key) (*provider.Session, error) {
 
 func (s *sessionManager) newSession(k Key) (*provider.Session, error) {
 	rpcProvider := provider.NewProvider(s.dispatchUrls[0], s.dispatchUrls)
-	rpcProvider.UpdateRequestConfig(0, time.Duration(20)*time.Second)
+	rpcProvider.UpdateRequestConfig(s.settings.Retries, s.settings.Timeout)
 	r, err := rpcProvider.Dispatch(k.PublicKey, k.BlockchainID, nil)
 	if err != nil {
 		return &provider.Session{}, err
 	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	s.sessions[k] = cacheEntry{
 		Session: r.Session,
-		TTL:     time.Now().Add(defaultTTL),
+		TTL:     time.Now().Add(s.settings.TTL),
 	}
 	return r.Session, nil
 }
+
+// PingDispatchers returns nil once any of the dispatchers responds with its block height,
+// or ErrDispatchersUnreachable with the last error if none of them does.
+func PingDispatchers(ctx context.Context, dispatchUrls []string) error {
+	if len(dispatchUrls) == 0 {
+		return ErrDispatchersUnreachable
+	}
+
+	errs := make(chan error, len(dispatchUrls))
+	for _, url := range dispatchUrls {
+		go func(url string) {
+			rpcProvider := provider.NewProvider(url, dispatchUrls)
+			rpcProvider.UpdateRequestConfig(0, dispatcherPingTimeout)
+			_, err := rpcProvider.GetBlockHeight()
+			errs <- err
+		}(url)
+	}
+
+	var lastErr error
+	for range dispatchUrls {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%w: %v", ErrDispatchersUnreachable, ctx.Err())
+		case err := <-errs:
+			if err == nil {
+				return nil
+			}
+			lastErr = err
+		}
+	}
+	return fmt.Errorf("%w: %v", ErrDispatchersUnreachable, lastErr)
+}
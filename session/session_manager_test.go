@@ -0,0 +1,95 @@
+package session
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeDispatcher serves dispatch requests with a new session key each time, counting them
+type fakeDispatcher struct {
+	mu         sync.Mutex
+	dispatches int
+}
+
+func (d *fakeDispatcher) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	d.mu.Lock()
+	d.dispatches++
+	dispatches := d.dispatches
+	d.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	fmt.Fprintf(w, `{"block_height": 1, "session": {"key": "session-%d"}}`, dispatches)
+}
+
+func (d *fakeDispatcher) count() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.dispatches
+}
+
+func newTestSessionManager(t *testing.T, ttl time.Duration) (SessionManager, *fakeDispatcher) {
+	dispatcher := &fakeDispatcher{}
+	server := httptest.NewServer(dispatcher)
+	t.Cleanup(server.Close)
+
+	return NewSessionManager([]string{server.URL}, Settings{TTL: ttl, Timeout: time.Second}), dispatcher
+}
+
+func TestGetSession(t *testing.T) {
+	manager, dispatcher := newTestSessionManager(t, time.Minute)
+	key := Key{PublicKey: "public-key", BlockchainID: "0021"}
+
+	// Concurrent relays of a new manager dispatch and cache sessions
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := manager.GetSession(key); err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	s, err := manager.GetSession(key)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if s.Key == "" {
+		t.Errorf("Expected dispatched session, got: %v", s)
+	}
+	if dispatcher.count() == 0 {
+		t.Errorf("Expected session to be dispatched")
+	}
+}
+
+func TestGetSessionTTL(t *testing.T) {
+	manager, dispatcher := newTestSessionManager(t, 50*time.Millisecond)
+	key := Key{PublicKey: "public-key", BlockchainID: "0021"}
+
+	first, err := manager.GetSession(key)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	cached, err := manager.GetSession(key)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if cached.Key != first.Key || dispatcher.count() != 1 {
+		t.Errorf("Expected cached session %s, got: %s after %d dispatches", first.Key, cached.Key, dispatcher.count())
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	renewed, err := manager.GetSession(key)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if renewed.Key == first.Key || dispatcher.count() != 2 {
+		t.Errorf("Expected expired session %s to be dispatched again, got: %s after %d dispatches", first.Key, renewed.Key, dispatcher.count())
+	}
+}
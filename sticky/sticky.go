@@ -2,6 +2,7 @@ package sticky
 
 import (
 	"fmt"
+	"sync"
 	"time"
 
 	logger "github.com/sirupsen/logrus"
@@ -47,24 +48,28 @@ func (c StickyClient) IsEmpty() bool {
 }
 
 type StickyNodeSettings struct {
-	Duration                 time.Duration
+	// Duration is the TTL of the relay and error counts of sticky clients
+	Duration time.Duration
+	// RelayLimit and MaxErrors are the relays and errors after which a sticky client is dropped
 	RelayLimit               int
 	MaxErrors                int
 	DefaultStickinessOptions repository.StickyOptions
 	DefaultStickyClient      StickyClient
 }
 
-func NewStickyNodes(settings StickyNodeSettings) StickyClientService {
+func NewStickyNodes(settings StickyNodeSettings, log *logger.Logger) StickyClientService {
 	return &stickyNodes{
 		settings: settings,
 		items:    make(map[Key]StickyClient),
+		log:      log,
 	}
 }
 
-// TODO: Add a RW mutex
 type stickyNodes struct {
 	settings StickyNodeSettings
-	items    map[Key]StickyClient
+	// mu guards items, which are read and updated by concurrent relays
+	mu    sync.Mutex
+	items map[Key]StickyClient
 
 	log *logger.Logger
 }
@@ -77,6 +82,9 @@ func (s *stickyNodes) Get(k Key) StickyClient {
 // TTL of ErrorCount
 // TTL of RelayLimit
 func (s *stickyNodes) validate(k Key) StickyClient {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
 	sc, ok := s.items[k]
 	if !ok {
 		return StickyClient{}
@@ -153,6 +161,9 @@ func (s *stickyNodes) Success(d *StickyDetails) error {
 		return nil
 	}
 
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
 	// TODO: check if Origin checking is required (it is already checked elsewhere)
 	// We trust the passed StickyClient, to avoid additional cache look-ups
 	sc, ok := s.items[d.Key]
@@ -234,6 +245,9 @@ func (s *stickyNodes) Failure(d *StickyDetails) error {
 		return nil
 	}
 
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
 	sc, ok := s.items[d.Key]
 	if !ok {
 		newItem := &d.StickyClient
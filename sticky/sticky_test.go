@@ -1,6 +1,9 @@
 package sticky
 
 import (
+	"fmt"
+	"io"
+	"sync"
 	"testing"
 	"time"
 
@@ -334,3 +337,30 @@ func TestSuccess(t *testing.T) {
 		})
 	}
 }
+
+// TestConcurrentRelays updates the sticky clients from concurrent relays, run with -race to detect unguarded access
+func TestConcurrentRelays(t *testing.T) {
+	log := logger.New()
+	log.SetOutput(io.Discard)
+	s := NewStickyNodes(StickyNodeSettings{Duration: duration, RelayLimit: maxRelays, MaxErrors: maxErrors}, log).(*stickyNodes)
+	options := repository.StickyOptions{Stickiness: true, StickyOrigins: []string{"origin-1"}}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			k := Key{ApplicationID: "app-1", BlockchainID: "block-1", IP: fmt.Sprintf("10.0.0.%d", i%5)}
+			for j := 0; j < 50; j++ {
+				s.Get(k)
+				if err := s.Success(&StickyDetails{Key: k, StickyOptions: options}); err != nil {
+					t.Errorf("Unexpected error: %v", err)
+				}
+				if err := s.Failure(&StickyDetails{Key: k, StickyOptions: options}); err != nil {
+					t.Errorf("Unexpected error: %v", err)
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+}
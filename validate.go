@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	logger "github.com/sirupsen/logrus"
+
+	"github.com/pokt-foundation/portal-api-go/repository"
+)
+
+const validateConfigCommand = "validate-config"
+
+// ErrInvalidLoadBalancers error when load balancers include applications missing from the repository
+var ErrInvalidLoadBalancers = errors.New("load balancers include invalid application IDs")
+
+// validateConfig validates the settings, loads the repository and reports the application IDs of load
+// balancers that are missing from it:
+//
+//	validate-config [flags]
+func validateConfig(args []string, out io.Writer, log *logger.Logger) error {
+	settings, err := gatherSettings(args, settings.validateConfig)
+	if err != nil {
+		return err
+	}
+	log.SetLevel(settings.LogLevel)
+
+	repo, driver, err := openRepository(context.Background(), settings, log)
+	if err != nil {
+		return err
+	}
+	if driver != nil {
+		defer driver.CloseListener()
+	}
+
+	if err := repo.Ready(); err != nil {
+		return err
+	}
+
+	return reportInvalidApplicationIDs(out, repo)
+}
+
+// reportInvalidApplicationIDs prints the invalid application IDs of each load balancer, sorted by load balancer ID,
+// and returns ErrInvalidLoadBalancers if there are any
+func reportInvalidApplicationIDs(out io.Writer, repo repository.ReloadableRepository) error {
+	invalid := repo.InvalidApplicationIDs()
+	if len(invalid) == 0 {
+		fmt.Fprintln(out, "Configuration is valid")
+		return nil
+	}
+
+	lbIDs := make([]string, 0, len(invalid))
+	for id := range invalid {
+		lbIDs = append(lbIDs, id)
+	}
+	sort.Strings(lbIDs)
+
+	for _, id := range lbIDs {
+		fmt.Fprintf(out, "Load balancer %s: invalid application IDs: %s\n", id, strings.Join(invalid[id], ", "))
+	}
+	return fmt.Errorf("%w: %d load balancers", ErrInvalidLoadBalancers, len(invalid))
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path"
+	"testing"
+
+	logger "github.com/sirupsen/logrus"
+)
+
+func TestValidateConfig(t *testing.T) {
+	testCases := []struct {
+		name        string
+		lbs         string
+		expected    string
+		expectedErr error
+	}{
+		{
+			name:     "Valid configuration",
+			lbs:      `[{"id": "lb-1", "applicationIDs": ["app-1"]}]`,
+			expected: "Configuration is valid\n",
+		},
+		{
+			name: "Invalid application IDs are reported",
+			lbs:  `[{"id": "lb-2", "applicationIDs": ["app-1", "app-3"]}, {"id": "lb-1", "applicationIDs": ["app-2"]}]`,
+			expected: "Load balancer lb-1: invalid application IDs: app-2\n" +
+				"Load balancer lb-2: invalid application IDs: app-3\n",
+			expectedErr: ErrInvalidLoadBalancers,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			files := map[string]string{
+				"Blockchains.json":   `[{"id": "0021", "blockchain": "eth-mainnet"}]`,
+				"Applications.json":  `[{"id": "app-1"}]`,
+				"LoadBalancers.json": tc.lbs,
+			}
+			for name, contents := range files {
+				if err := os.WriteFile(path.Join(dir, name), []byte(contents), 0600); err != nil {
+					t.Fatalf("Error writing %s: %v", name, err)
+				}
+			}
+
+			var out bytes.Buffer
+			// The private key is not required to validate the configuration
+			args := []string{"-rpcUrls", "https://url1", "-repositoryPath", dir}
+			err := validateConfig(args, &out, logger.New())
+			if !errors.Is(err, tc.expectedErr) {
+				t.Errorf("Expected error: %v, got: %v", tc.expectedErr, err)
+			}
+			if out.String() != tc.expected {
+				t.Errorf("Expected output: %q, got: %q", tc.expected, out.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,151 @@
+package web
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// Forwarding headers the trusted proxies may report the client IP with
+const (
+	HeaderForwarded     = "Forwarded"
+	HeaderXForwardedFor = "X-Forwarded-For"
+	HeaderXRealIP       = "X-Real-IP"
+)
+
+var (
+	ErrInvalidTrustedProxy     = errors.New("invalid trusted proxy")
+	ErrInvalidForwardingHeader = errors.New("invalid forwarding header")
+)
+
+// ClientIPResolver returns the IP of the client of a request. Forwarding headers are only
+// considered when set by trusted proxies, e.g. an application load balancer, as any client can set them.
+// A nil resolver trusts no proxies.
+type ClientIPResolver struct {
+	trustedProxies []*net.IPNet
+	// header is the only forwarding header read: the one the trusted proxies write, as they pass others
+	// through as sent by the client
+	header string
+}
+
+// NewClientIPResolver returns a resolver trusting the proxies, given as CIDRs or single IPs, to report the
+// client IP in the forwarding header: one of HeaderForwarded, HeaderXForwardedFor or HeaderXRealIP
+func NewClientIPResolver(trustedProxies []string, header string) (*ClientIPResolver, error) {
+	r := &ClientIPResolver{header: http.CanonicalHeaderKey(header)}
+	switch r.header {
+	case HeaderForwarded, HeaderXForwardedFor, http.CanonicalHeaderKey(HeaderXRealIP):
+	default:
+		return nil, fmt.Errorf("%w: %q, accepted values are %s, %s and %s",
+			ErrInvalidForwardingHeader, header, HeaderForwarded, HeaderXForwardedFor, HeaderXRealIP)
+	}
+
+	for _, proxy := range trustedProxies {
+		if !strings.Contains(proxy, "/") {
+			ip := net.ParseIP(proxy)
+			if ip == nil {
+				return nil, fmt.Errorf("%w: %q", ErrInvalidTrustedProxy, proxy)
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+			r.trustedProxies = append(r.trustedProxies, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+
+		_, network, err := net.ParseCIDR(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidTrustedProxy, proxy)
+		}
+		r.trustedProxies = append(r.trustedProxies, network)
+	}
+	return r, nil
+}
+
+// ClientIP returns the normalised IP of the request's client: starting from the remote address,
+// the forwarding chain of the resolver's header is followed from the nearest hop while the hop is a trusted proxy.
+func (r *ClientIPResolver) ClientIP(req *http.Request) string {
+	ip := parseIP(req.RemoteAddr)
+	if ip == nil {
+		return req.RemoteAddr
+	}
+	if r == nil {
+		return ip.String()
+	}
+
+	chain := forwardedChain(req.Header, r.header)
+	for i := len(chain) - 1; i >= 0 && r.trusted(ip); i-- {
+		hop := parseIP(chain[i])
+		if hop == nil {
+			// Hops past an unknown or obfuscated one can not be verified
+			break
+		}
+		ip = hop
+	}
+
+	return ip.String()
+}
+
+func (r *ClientIPResolver) trusted(ip net.IP) bool {
+	if r == nil {
+		return false
+	}
+	for _, network := range r.trustedProxies {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// forwardedChain returns the addresses of the hops reported in the forwarding header, nearest last
+func forwardedChain(header http.Header, name string) []string {
+	var chain []string
+
+	switch name {
+	case HeaderForwarded:
+		for _, value := range header.Values(HeaderForwarded) {
+			for _, element := range strings.Split(value, ",") {
+				for _, pair := range strings.Split(element, ";") {
+					key, value, found := strings.Cut(strings.TrimSpace(pair), "=")
+					if found && strings.EqualFold(key, "for") {
+						chain = append(chain, value)
+					}
+				}
+			}
+		}
+	case HeaderXForwardedFor:
+		for _, value := range header.Values(HeaderXForwardedFor) {
+			chain = append(chain, strings.Split(value, ",")...)
+		}
+	default:
+		if realIP := header.Get(name); realIP != "" {
+			chain = []string{realIP}
+		}
+	}
+	return chain
+}
+
+// parseIP returns the IP of an address with an optional port, IPv6 addresses may be bracketed
+// and quoted as in the Forwarded header. IPv4-mapped IPv6 addresses are returned as IPv4.
+func parseIP(address string) net.IP {
+	address = strings.Trim(strings.TrimSpace(address), `"`)
+
+	if host, _, err := net.SplitHostPort(address); err == nil {
+		address = host
+	}
+	address = strings.TrimSuffix(strings.TrimPrefix(address, "["), "]")
+
+	// Zones are only meaningful on the host they were set on
+	if i := strings.Index(address, "%"); i >= 0 {
+		address = address[:i]
+	}
+
+	ip := net.ParseIP(address)
+	if ip4 := ip.To4(); ip4 != nil {
+		return ip4
+	}
+	return ip
+}
@@ -0,0 +1,141 @@
+package web
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClientIP(t *testing.T) {
+	trustedProxies := []string{"10.0.0.0/8", "2001:db8::/32", "192.168.1.1"}
+
+	testCases := []struct {
+		name       string
+		header     string
+		noResolver bool
+		remoteAddr string
+		headers    map[string][]string
+		expected   string
+	}{
+		{
+			name:       "Port is stripped from the remote address",
+			remoteAddr: "203.0.113.7:54321",
+			expected:   "203.0.113.7",
+		},
+		{
+			name:       "Headers from untrusted remote addresses are ignored",
+			remoteAddr: "203.0.113.7:54321",
+			headers:    map[string][]string{"X-Forwarded-For": {"198.51.100.1"}},
+			expected:   "203.0.113.7",
+		},
+		{
+			name:       "Nil resolver trusts no proxies",
+			noResolver: true,
+			remoteAddr: "10.0.0.1:80",
+			headers:    map[string][]string{"X-Forwarded-For": {"198.51.100.1"}},
+			expected:   "10.0.0.1",
+		},
+		{
+			name:       "X-Forwarded-For is followed through trusted proxies",
+			remoteAddr: "10.0.0.1:80",
+			headers:    map[string][]string{"X-Forwarded-For": {"198.51.100.9, 198.51.100.1", "192.168.1.1"}},
+			expected:   "198.51.100.1",
+		},
+		{
+			name:       "Forwarded header forged by the client is ignored behind proxies writing X-Forwarded-For",
+			remoteAddr: "10.0.0.1:80",
+			headers: map[string][]string{
+				"Forwarded":       {"for=1.2.3.4"},
+				"X-Forwarded-For": {"198.51.100.1"},
+			},
+			expected: "198.51.100.1",
+		},
+		{
+			name:       "Forwarded header forged by the client does not replace a missing X-Forwarded-For",
+			remoteAddr: "10.0.0.1:80",
+			headers:    map[string][]string{"Forwarded": {"for=1.2.3.4"}, "X-Real-Ip": {"1.2.3.5"}},
+			expected:   "10.0.0.1",
+		},
+		{
+			name:       "Forwarded header is parsed, with quoted IPv6 and ports",
+			header:     HeaderForwarded,
+			remoteAddr: "[2001:db8::1]:443",
+			headers: map[string][]string{
+				"Forwarded":       {`for="[2001:DB8:cafe::17]:4711";proto=https, For=10.1.2.3`},
+				"X-Forwarded-For": {"198.51.100.1"},
+			},
+			expected: "2001:db8:cafe::17",
+		},
+		{
+			name:       "X-Real-IP is used when configured",
+			header:     "x-real-ip",
+			remoteAddr: "10.0.0.1:80",
+			headers:    map[string][]string{"X-Real-Ip": {"198.51.100.2"}, "X-Forwarded-For": {"1.2.3.4"}},
+			expected:   "198.51.100.2",
+		},
+		{
+			name:       "IPv4-mapped IPv6 addresses are normalised",
+			remoteAddr: "[::ffff:10.0.0.1]:80",
+			headers:    map[string][]string{"X-Forwarded-For": {"::ffff:198.51.100.3"}},
+			expected:   "198.51.100.3",
+		},
+		{
+			name:       "Chain stops at unknown hops",
+			header:     HeaderForwarded,
+			remoteAddr: "10.0.0.1:80",
+			headers:    map[string][]string{"Forwarded": {"for=198.51.100.1, for=unknown"}},
+			expected:   "10.0.0.1",
+		},
+		{
+			name:       "Leftmost hop is returned when all hops are trusted",
+			remoteAddr: "10.0.0.1:80",
+			headers:    map[string][]string{"X-Forwarded-For": {"10.0.0.3, 10.0.0.2"}},
+			expected:   "10.0.0.3",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var resolver *ClientIPResolver
+			if !tc.noResolver {
+				header := tc.header
+				if header == "" {
+					header = HeaderXForwardedFor
+				}
+				var err error
+				if resolver, err = NewClientIPResolver(trustedProxies, header); err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+			}
+
+			req := httptest.NewRequest(http.MethodPost, "/", nil)
+			req.RemoteAddr = tc.remoteAddr
+			for key, values := range tc.headers {
+				for _, value := range values {
+					req.Header.Add(key, value)
+				}
+			}
+
+			if ip := resolver.ClientIP(req); ip != tc.expected {
+				t.Errorf("Expected client IP: %s, got: %s", tc.expected, ip)
+			}
+		})
+	}
+}
+
+func TestNewClientIPResolverInvalidProxy(t *testing.T) {
+	for _, proxy := range []string{"", "10.0.0.0/33", "not-an-ip"} {
+		if _, err := NewClientIPResolver([]string{proxy}, HeaderXForwardedFor); !errors.Is(err, ErrInvalidTrustedProxy) {
+			t.Errorf("Expected error: %v for proxy %q, got: %v", ErrInvalidTrustedProxy, proxy, err)
+		}
+	}
+}
+
+func TestNewClientIPResolverInvalidHeader(t *testing.T) {
+	for _, header := range []string{"", "X-Client-IP"} {
+		if _, err := NewClientIPResolver(nil, header); !errors.Is(err, ErrInvalidForwardingHeader) {
+			t.Errorf("Expected error: %v for header %q, got: %v", ErrInvalidForwardingHeader, header, err)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// readinessTimeout bounds the readiness checks run for each probe
+const readinessTimeout = 5 * time.Second
+
+// ErrDraining is reported by the readiness probe once the server is shutting down
+var ErrDraining = errors.New("server is shutting down")
+
+// ReadinessCheck returns an error if a dependency needed to serve relays is not ready, e.g. the repository
+type ReadinessCheck func(ctx context.Context) error
+
+// Health serves the liveness and readiness probes of the gateway
+type Health struct {
+	checks   map[string]ReadinessCheck
+	draining int32
+}
+
+// NewHealth returns the probes of the gateway, which is ready once all the named checks pass
+func NewHealth(checks map[string]ReadinessCheck) *Health {
+	return &Health{checks: checks}
+}
+
+// Drain reports the gateway as not ready, so load balancers stop routing requests to it before it shuts down
+func (h *Health) Drain() {
+	atomic.StoreInt32(&h.draining, 1)
+}
+
+type healthResponse struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks,omitempty"`
+}
+
+// Liveness serves /healthz: the gateway is alive as long as it responds
+func (h *Health) Liveness(w http.ResponseWriter, req *http.Request) {
+	writeHealth(w, http.StatusOK, healthResponse{Status: "ok"})
+}
+
+// Readiness serves /readyz, running all the checks concurrently: it responds with 503 and the
+// failed checks if any of them fails, or the gateway is draining
+func (h *Health) Readiness(w http.ResponseWriter, req *http.Request) {
+	if atomic.LoadInt32(&h.draining) == 1 {
+		writeHealth(w, http.StatusServiceUnavailable, healthResponse{Status: ErrDraining.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(req.Context(), readinessTimeout)
+	defer cancel()
+
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		results = make(map[string]string, len(h.checks))
+		ready   = true
+	)
+	for name, check := range h.checks {
+		wg.Add(1)
+		go func(name string, check ReadinessCheck) {
+			defer wg.Done()
+
+			result := "ok"
+			err := check(ctx)
+			if err != nil {
+				result = err.Error()
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			results[name] = result
+			ready = ready && err == nil
+		}(name, check)
+	}
+	wg.Wait()
+
+	if !ready {
+		writeHealth(w, http.StatusServiceUnavailable, healthResponse{Status: "not ready", Checks: results})
+		return
+	}
+	writeHealth(w, http.StatusOK, healthResponse{Status: "ready", Checks: results})
+}
+
+func writeHealth(w http.ResponseWriter, status int, response healthResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,78 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestHealth(t *testing.T) {
+	errDispatchers := errors.New("dispatchers unreachable")
+	ok := func(context.Context) error { return nil }
+
+	testCases := []struct {
+		name             string
+		checks           map[string]ReadinessCheck
+		drain            bool
+		expectedStatus   int
+		expectedResponse healthResponse
+	}{
+		{
+			name:             "Ready when all checks pass",
+			checks:           map[string]ReadinessCheck{"repository": ok, "dispatchers": ok},
+			expectedStatus:   http.StatusOK,
+			expectedResponse: healthResponse{Status: "ready", Checks: map[string]string{"repository": "ok", "dispatchers": "ok"}},
+		},
+		{
+			name: "Not ready when a check fails",
+			checks: map[string]ReadinessCheck{
+				"repository":  ok,
+				"dispatchers": func(context.Context) error { return errDispatchers },
+			},
+			expectedStatus:   http.StatusServiceUnavailable,
+			expectedResponse: healthResponse{Status: "not ready", Checks: map[string]string{"repository": "ok", "dispatchers": errDispatchers.Error()}},
+		},
+		{
+			name:             "Not ready while draining",
+			checks:           map[string]ReadinessCheck{"repository": ok},
+			drain:            true,
+			expectedStatus:   http.StatusServiceUnavailable,
+			expectedResponse: healthResponse{Status: ErrDraining.Error()},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			health := NewHealth(tc.checks)
+			if tc.drain {
+				health.Drain()
+			}
+
+			w := httptest.NewRecorder()
+			health.Readiness(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+			if w.Code != tc.expectedStatus {
+				t.Errorf("Expected status code: %d, got: %d", tc.expectedStatus, w.Code)
+			}
+			var response healthResponse
+			if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+				t.Fatalf("Unexpected error decoding response: %v", err)
+			}
+			if diff := cmp.Diff(tc.expectedResponse, response); diff != "" {
+				t.Errorf("unexpected response (-want +got):\n%s", diff)
+			}
+
+			// Liveness does not depend on the checks
+			w = httptest.NewRecorder()
+			health.Liveness(w, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+			if w.Code != http.StatusOK {
+				t.Errorf("Expected liveness status code: %d, got: %d", http.StatusOK, w.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/pokt-foundation/portal-api-go/repository"
+)
+
+// jsonRPCInternalError is the code of the JSON-RPC errors returned for relay errors without a code
+const jsonRPCInternalError = -32603
+
+type jsonRPCError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type jsonRPCErrorResponse struct {
+	JSONRPC string `json:"jsonrpc"`
+	// ID is the id of the failed request, null if unknown
+	ID    json.RawMessage `json:"id"`
+	Error jsonRPCError    `json:"error"`
+}
+
+type jsonRPCResultResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  any             `json:"result"`
+}
+
+// jsonRPCNotification is a notification of a subscription, e.g. of eth_subscribe
+type jsonRPCNotification struct {
+	JSONRPC string                    `json:"jsonrpc"`
+	Method  string                    `json:"method"`
+	Params  jsonRPCNotificationParams `json:"params"`
+}
+
+type jsonRPCNotificationParams struct {
+	Subscription string          `json:"subscription"`
+	Result       json.RawMessage `json:"result"`
+}
+
+// newJSONRPCErrorResponse returns the JSON-RPC error of the relay error for the request with the id,
+// with the code of coded errors
+func newJSONRPCErrorResponse(id json.RawMessage, err error) jsonRPCErrorResponse {
+	code := jsonRPCInternalError
+	var codedErr *repository.CodedError
+	if errors.As(err, &codedErr) {
+		code = codedErr.Code
+	}
+
+	return jsonRPCErrorResponse{
+		JSONRPC: "2.0",
+		ID:      id,
+		Error:   jsonRPCError{Code: code, Message: err.Error()},
+	}
+}
+
+// writeJSONRPCError responds with the status and the JSON-RPC error of the relay error for the request with the id
+func writeJSONRPCError(w http.ResponseWriter, status int, id json.RawMessage, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(newJSONRPCErrorResponse(id, err))
+}
+
+// writeRateLimited responds with 429, the time to wait before retrying and the JSON-RPC error
+func writeRateLimited(w http.ResponseWriter, id json.RawMessage, wait time.Duration, err error) {
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+	writeJSONRPCError(w, http.StatusTooManyRequests, id, err)
+}
+
+// requestID returns the id of the JSON-RPC request, nil if it has none or can not be parsed
+func requestID(rawData string) json.RawMessage {
+	var request struct {
+		ID json.RawMessage `json:"id"`
+	}
+	if err := json.Unmarshal([]byte(rawData), &request); err != nil {
+		return nil
+	}
+	return request.ID
+}
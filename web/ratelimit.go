@@ -0,0 +1,189 @@
+package web
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"sync"
+	"time"
+
+	logger "github.com/sirupsen/logrus"
+
+	"github.com/pokt-foundation/portal-api-go/relay"
+	"github.com/pokt-foundation/portal-api-go/repository"
+)
+
+// bucketsSweepInterval is how often the buckets refilled to their burst are dropped
+const bucketsSweepInterval = time.Minute
+
+// Rate is the number of requests per second a token bucket allows, with bursts of up to Burst requests.
+// A zero rate allows unlimited requests.
+type Rate struct {
+	PerSecond float64 `yaml:"perSecond"`
+	// Burst defaults to the rate rounded up
+	Burst int `yaml:"burst"`
+}
+
+func (r Rate) burst() float64 {
+	if r.Burst > 0 {
+		return float64(r.Burst)
+	}
+	return math.Max(1, math.Ceil(r.PerSecond))
+}
+
+// RateLimitSettings configures the rate limits of relay requests, enforced for each client IP,
+// application and load balancer. Applications whose pay plan has a rate limit in the repository's pay plans
+// catalogue are limited by it instead of PerApplication.
+type RateLimitSettings struct {
+	PerIP           Rate `yaml:"perIP"`
+	PerApplication  Rate `yaml:"perApplication"`
+	PerLoadBalancer Rate `yaml:"perLoadBalancer"`
+}
+
+// applicationRate returns the rate of the application: the rate limit of its pay plan in the catalogue if set,
+// PerApplication otherwise
+func (s RateLimitSettings) applicationRate(r repository.Repository, appID string) Rate {
+	app, err := r.GetApplication(appID)
+	if err != nil || app.Limit.PayPlan.Type == "" {
+		return s.PerApplication
+	}
+	plan, err := r.GetPayPlan(app.Limit.PayPlan.Type)
+	if err != nil || plan.RateLimit <= 0 {
+		return s.PerApplication
+	}
+	return Rate{PerSecond: float64(plan.RateLimit)}
+}
+
+// RateLimit wraps the relay handler, responding with 429 and a JSON-RPC error to requests exceeding
+// the rate limits of their client IP, application or load balancer
+func RateLimit(next http.HandlerFunc, r repository.Repository, settings RateLimitSettings, ips *ClientIPResolver, l *logger.Logger) http.HandlerFunc {
+	limiter := newRateLimiter()
+
+	return func(w http.ResponseWriter, req *http.Request) {
+		keys := []limitedKey{{key: "ip:" + ips.ClientIP(req), rate: settings.PerIP}}
+		// Requests with invalid paths are only limited by IP, the handler rejects them
+		if appID, lbID, _, err := ids(req.URL.Path); err == nil {
+			if lbID != "" {
+				keys = append(keys, limitedKey{key: "lb:" + lbID, rate: settings.PerLoadBalancer})
+			} else {
+				keys = append(keys, limitedKey{key: "app:" + appID, rate: settings.applicationRate(r, appID)})
+			}
+		}
+
+		limit := func(now time.Time) (time.Duration, error) {
+			if key, wait, ok := limiter.allow(keys, now); !ok {
+				l.WithFields(logger.Fields{"key": key, "retryAfter": wait}).Warn("Rate limit exceeded")
+				return wait, fmt.Errorf("%w: %s", ErrRateLimited, key)
+			}
+			return 0, nil
+		}
+
+		if wait, err := limit(time.Now()); err != nil {
+			writeRateLimited(w, nil, wait, err)
+			return
+		}
+
+		next(w, req.WithContext(context.WithValue(req.Context(), limitKey{}, limit)))
+	}
+}
+
+type limitKey struct{}
+
+// limitMessage counts a message sent over the connection of the request, e.g. a WebSocket, against the
+// rate limits of the request, returning ErrRateLimited if exceeded. Requests not served by RateLimit are not limited.
+func limitMessage(req *http.Request) error {
+	limit, ok := req.Context().Value(limitKey{}).(func(time.Time) (time.Duration, error))
+	if !ok {
+		return nil
+	}
+	_, err := limit(time.Now())
+	return err
+}
+
+type limitedKey struct {
+	key  string
+	rate Rate
+}
+
+// ErrRateLimited is returned to requests exceeding the rate limits, with the same code as the relayer's rate limit errors
+var ErrRateLimited = &repository.CodedError{Code: relay.ErrRateLimitExceeded.Code, Message: "rate limit exceeded"}
+
+// tokenBucket holds the tokens left at the time of the last request
+type tokenBucket struct {
+	rate   Rate
+	tokens float64
+	last   time.Time
+}
+
+// full returns whether the bucket has been refilled to its burst at now
+func (b *tokenBucket) full(now time.Time) bool {
+	return b.tokens+now.Sub(b.last).Seconds()*b.rate.PerSecond >= b.rate.burst()
+}
+
+// rateLimiter keeps a token bucket for each key
+type rateLimiter struct {
+	mu        sync.Mutex
+	buckets   map[string]*tokenBucket
+	lastSweep time.Time
+}
+
+func newRateLimiter() *rateLimiter {
+	return &rateLimiter{buckets: make(map[string]*tokenBucket)}
+}
+
+// allow takes a token from the bucket of each key if none of them is empty. Otherwise no token is taken,
+// and the key of the first empty bucket is returned with the time until its next token is available.
+func (l *rateLimiter) allow(keys []limitedKey, now time.Time) (string, time.Duration, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.sweep(now)
+
+	buckets := make([]*tokenBucket, 0, len(keys))
+	for _, k := range keys {
+		if k.rate.PerSecond <= 0 {
+			continue
+		}
+		b := l.refill(k.key, k.rate, now)
+		if b.tokens < 1 {
+			return k.key, time.Duration((1 - b.tokens) / k.rate.PerSecond * float64(time.Second)), false
+		}
+		buckets = append(buckets, b)
+	}
+
+	for _, b := range buckets {
+		b.tokens--
+	}
+	return "", 0, true
+}
+
+// refill returns the bucket of the key with the tokens added since its last request
+func (l *rateLimiter) refill(key string, rate Rate, now time.Time) *tokenBucket {
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &tokenBucket{tokens: rate.burst(), last: now}
+		l.buckets[key] = b
+	}
+	b.rate = rate
+
+	elapsed := now.Sub(b.last).Seconds()
+	if elapsed > 0 {
+		b.tokens = math.Min(rate.burst(), b.tokens+elapsed*rate.PerSecond)
+		b.last = now
+	}
+	return b
+}
+
+// sweep drops the full buckets, which are created again as full on the next request of their key
+func (l *rateLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < bucketsSweepInterval {
+		return
+	}
+	for key, b := range l.buckets {
+		if b.full(now) {
+			delete(l.buckets, key)
+		}
+	}
+	l.lastSweep = now
+}
@@ -0,0 +1,210 @@
+package web
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	logger "github.com/sirupsen/logrus"
+
+	"github.com/pokt-foundation/portal-api-go/relay"
+	"github.com/pokt-foundation/portal-api-go/repository"
+)
+
+func TestRateLimiterAllow(t *testing.T) {
+	limiter := newRateLimiter()
+	key := []limitedKey{{key: "key", rate: Rate{PerSecond: 2, Burst: 3}}}
+	now := time.Date(2022, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	for i := 0; i < 3; i++ {
+		if _, _, ok := limiter.allow(key, now); !ok {
+			t.Fatalf("Expected request %d of the burst to be allowed", i)
+		}
+	}
+	rejected, wait, ok := limiter.allow(key, now)
+	if ok {
+		t.Fatalf("Expected request over the burst to be rejected")
+	}
+	if rejected != "key" || wait != 500*time.Millisecond {
+		t.Errorf("Expected key to be rejected with a wait of 500ms, got: %s, %v", rejected, wait)
+	}
+	if _, _, ok := limiter.allow([]limitedKey{{key: "other", rate: key[0].rate}}, now); !ok {
+		t.Errorf("Expected keys to be limited separately")
+	}
+
+	// Tokens are refilled at the rate
+	if _, _, ok := limiter.allow(key, now.Add(500*time.Millisecond)); !ok {
+		t.Errorf("Expected request to be allowed after refill")
+	}
+	if _, _, ok := limiter.allow(key, now.Add(500*time.Millisecond)); ok {
+		t.Errorf("Expected request to be rejected before the next refill")
+	}
+
+	if _, _, ok := limiter.allow([]limitedKey{{key: "unlimited"}}, now); !ok {
+		t.Errorf("Expected zero rate to allow all requests")
+	}
+
+	// Full buckets are dropped
+	limiter.allow(key, now.Add(bucketsSweepInterval))
+	if len(limiter.buckets) != 1 {
+		t.Errorf("Expected full buckets to be swept, got: %d buckets", len(limiter.buckets))
+	}
+}
+
+func TestRateLimiterAllowKeys(t *testing.T) {
+	limiter := newRateLimiter()
+	ip := limitedKey{key: "ip:10.0.0.1", rate: Rate{PerSecond: 1, Burst: 2}}
+	app1 := limitedKey{key: "app:app-1", rate: Rate{PerSecond: 1, Burst: 1}}
+	app2 := limitedKey{key: "app:app-2", rate: Rate{PerSecond: 1, Burst: 1}}
+	now := time.Date(2022, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	if _, _, ok := limiter.allow([]limitedKey{ip, app1}, now); !ok {
+		t.Fatalf("Expected first request to be allowed")
+	}
+	if rejected, _, ok := limiter.allow([]limitedKey{ip, app1}, now); ok || rejected != app1.key {
+		t.Fatalf("Expected request to be rejected by %s, got: %s", app1.key, rejected)
+	}
+	// The request rejected by the application's bucket took no token from the IP's bucket
+	if _, _, ok := limiter.allow([]limitedKey{ip, app2}, now); !ok {
+		t.Errorf("Expected request of another application from the IP to be allowed")
+	}
+	if rejected, _, ok := limiter.allow([]limitedKey{ip, app2}, now); ok || rejected != ip.key {
+		t.Errorf("Expected request to be rejected by %s, got: %s", ip.key, rejected)
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	settings := RateLimitSettings{
+		PerIP:           Rate{PerSecond: 100},
+		PerApplication:  Rate{PerSecond: 2},
+		PerLoadBalancer: Rate{PerSecond: 1},
+	}
+	repo := fakeRepository{
+		apps: map[string]repository.Application{
+			"app-12345678901234567890": {Limit: repository.AppLimit{PayPlan: repository.PayPlan{Type: repository.Enterprise}}},
+			"app-09876543210987654321": {Limit: repository.AppLimit{PayPlan: repository.PayPlan{Type: repository.FreetierV0}}},
+		},
+		payPlans: repository.PayPlans{
+			repository.Enterprise: {Type: repository.Enterprise, RateLimit: 3},
+			repository.FreetierV0: {Type: repository.FreetierV0},
+		},
+	}
+
+	testCases := []struct {
+		name             string
+		path             string
+		settings         RateLimitSettings
+		expectedAccepted int
+	}{
+		{
+			name:             "Application is limited by the rate limit of its pay plan in the catalogue",
+			path:             "eth-mainnet.pokt.network/v1/app-12345678901234567890",
+			expectedAccepted: 3,
+		},
+		{
+			name:             "Application without a plan rate is limited by the application rate",
+			path:             "eth-mainnet.pokt.network/v1/app-09876543210987654321",
+			expectedAccepted: 2,
+		},
+		{
+			name:             "Load balancer is limited by the load balancer rate",
+			path:             "eth-mainnet.pokt.network/v1/lb/lb-123456789012345678901",
+			expectedAccepted: 1,
+		},
+		{
+			name:             "Client IP is limited across applications",
+			path:             "eth-mainnet.pokt.network/v1/app-12345678901234567890",
+			settings:         RateLimitSettings{PerIP: Rate{PerSecond: 1}},
+			expectedAccepted: 1,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := settings
+			if tc.settings.PerIP.PerSecond != 0 {
+				s = tc.settings
+			}
+			served := 0
+			handler := RateLimit(func(w http.ResponseWriter, req *http.Request) { served++ }, repo, s, nil, logger.New())
+
+			for i := 0; i < 5; i++ {
+				req := httptest.NewRequest(http.MethodPost, "/", nil)
+				req.URL.Path = tc.path
+				req.RemoteAddr = fmt.Sprintf("10.0.0.1:%d", 1000+i)
+
+				w := httptest.NewRecorder()
+				handler(w, req)
+				resp := w.Result()
+
+				if i < tc.expectedAccepted {
+					if resp.StatusCode != http.StatusOK {
+						t.Fatalf("Expected request %d to be served, got status: %d", i, resp.StatusCode)
+					}
+					continue
+				}
+				if resp.StatusCode != http.StatusTooManyRequests {
+					t.Fatalf("Expected request %d to be rate limited, got status: %d", i, resp.StatusCode)
+				}
+				if retryAfter := resp.Header.Get("Retry-After"); retryAfter != "1" {
+					t.Errorf("Expected Retry-After: 1, got: %q", retryAfter)
+				}
+				body, _ := ioutil.ReadAll(resp.Body)
+				if !bytes.Contains(body, []byte(`"jsonrpc":"2.0","id":null,"error":{"code":-32062,"message":"rate limit exceeded`)) {
+					t.Errorf("Unexpected body: %s", body)
+				}
+			}
+			if served != tc.expectedAccepted {
+				t.Errorf("Expected %d requests served, got: %d", tc.expectedAccepted, served)
+			}
+		})
+	}
+}
+
+func TestGetHttpServerRelayerRateLimit(t *testing.T) {
+	f := fakeRelayer{err: fmt.Errorf("%w: application app-1", relay.ErrRateLimitExceeded)}
+	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader([]byte(`{"blockchainID": "0001"}`)))
+	req.URL.Path = "eth-mainnet.pokt.network/v1/app-12345678901234567890"
+
+	w := httptest.NewRecorder()
+	GetHTTPServer(&f, nil, logger.New())(w, req)
+
+	resp := w.Result()
+	if resp.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("Expected status code: %d, got: %d", http.StatusTooManyRequests, resp.StatusCode)
+	}
+	if retryAfter := resp.Header.Get("Retry-After"); retryAfter != "1" {
+		t.Errorf("Expected Retry-After: 1, got: %q", retryAfter)
+	}
+}
+
+type fakeRepository struct {
+	apps     map[string]repository.Application
+	payPlans repository.PayPlans
+}
+
+func (f fakeRepository) GetApplication(id string) (repository.Application, error) {
+	if app, ok := f.apps[id]; ok {
+		return app, nil
+	}
+	return repository.Application{}, fmt.Errorf("Application not found")
+}
+
+func (f fakeRepository) GetBlockchain(alias string) (repository.Blockchain, error) {
+	return repository.Blockchain{}, fmt.Errorf("Blockchain not found")
+}
+
+func (f fakeRepository) GetLoadBalancer(id string) (repository.LoadBalancer, error) {
+	return repository.LoadBalancer{}, fmt.Errorf("LoadBalancer not found")
+}
+
+func (f fakeRepository) GetPayPlan(planType repository.PayPlanType) (repository.PayPlan, error) {
+	if plan, ok := f.payPlans[planType]; ok {
+		return plan, nil
+	}
+	return repository.PayPlan{}, fmt.Errorf("%w: %s", repository.ErrInvalidPayPlanType, planType)
+}
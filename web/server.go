@@ -0,0 +1,83 @@
+package web
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ServerSettings configures the limits of the http server serving relays
+type ServerSettings struct {
+	ReadTimeout  time.Duration `yaml:"readTimeout"`
+	WriteTimeout time.Duration `yaml:"writeTimeout"`
+	IdleTimeout  time.Duration `yaml:"idleTimeout"`
+	// MaxBodySize is the maximum size in bytes of request bodies: 0 means unlimited
+	MaxBodySize int64 `yaml:"maxBodySize"`
+	// SubscriptionPollInterval is how often the nodes of WebSocket connections are polled for the notifications
+	// of their subscriptions
+	SubscriptionPollInterval time.Duration `yaml:"subscriptionPollInterval"`
+}
+
+// DefaultServerSettings leave enough write time for relays to time out on the node side first
+func DefaultServerSettings() ServerSettings {
+	return ServerSettings{
+		ReadTimeout:  10 * time.Second,
+		WriteTimeout: 60 * time.Second,
+		IdleTimeout:  2 * time.Minute,
+		MaxBodySize:  10 << 20,
+
+		SubscriptionPollInterval: defaultSubscriptionPollInterval,
+	}
+}
+
+// NewServer returns the http server of the handler listening on addr, with the timeouts of the settings.
+// The contexts of its requests signal the server shutting down through serverShutdown.
+func NewServer(addr string, handler http.Handler, settings ServerSettings) *http.Server {
+	shutdown := make(chan struct{})
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           handler,
+		ReadTimeout:       settings.ReadTimeout,
+		ReadHeaderTimeout: settings.ReadTimeout,
+		WriteTimeout:      settings.WriteTimeout,
+		IdleTimeout:       settings.IdleTimeout,
+		BaseContext: func(net.Listener) context.Context {
+			return context.WithValue(context.Background(), shutdownKey{}, (<-chan struct{})(shutdown))
+		},
+	}
+
+	var once sync.Once
+	server.RegisterOnShutdown(func() {
+		once.Do(func() { close(shutdown) })
+	})
+
+	return server
+}
+
+type shutdownKey struct{}
+
+// serverShutdown returns a channel closed once the server of the request context starts shutting down.
+// Shutdown does not wait for hijacked connections, e.g. WebSockets, which need to be closed by their handlers.
+func serverShutdown(ctx context.Context) <-chan struct{} {
+	shutdown, _ := ctx.Value(shutdownKey{}).(<-chan struct{})
+	return shutdown
+}
+
+// MaxBodySize wraps the handler, responding with 413 to requests declaring a body larger than
+// the limit. Bodies without a declared length fail to be read past the limit.
+func MaxBodySize(next http.Handler, limit int64) http.Handler {
+	if limit <= 0 {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.ContentLength > limit {
+			http.Error(w, "request body too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		req.Body = http.MaxBytesReader(w, req.Body, limit)
+		next.ServeHTTP(w, req)
+	})
+}
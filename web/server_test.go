@@ -0,0 +1,75 @@
+package web
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMaxBodySize(t *testing.T) {
+	testCases := []struct {
+		name           string
+		body           string
+		unknownLength  bool
+		limit          int64
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "Body within the limit is served",
+			body:           "0123456789",
+			limit:          10,
+			expectedStatus: http.StatusOK,
+			expectedBody:   "0123456789",
+		},
+		{
+			name:           "Declared body over the limit is rejected",
+			body:           "0123456789",
+			limit:          5,
+			expectedStatus: http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:           "Body without declared length fails to be read over the limit",
+			body:           "0123456789",
+			unknownLength:  true,
+			limit:          5,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "01234",
+		},
+		{
+			name:           "Zero limit allows any body",
+			body:           "0123456789",
+			expectedStatus: http.StatusOK,
+			expectedBody:   "0123456789",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var read string
+			handler := MaxBodySize(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				body, err := ioutil.ReadAll(req.Body)
+				read = string(body)
+				if err != nil {
+					w.WriteHeader(http.StatusBadRequest)
+				}
+			}), tc.limit)
+
+			req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tc.body))
+			if tc.unknownLength {
+				req.ContentLength = -1
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			if w.Code != tc.expectedStatus {
+				t.Errorf("Expected status code: %d, got: %d", tc.expectedStatus, w.Code)
+			}
+			if read != tc.expectedBody {
+				t.Errorf("Expected body read: %q, got: %q", tc.expectedBody, read)
+			}
+		})
+	}
+}
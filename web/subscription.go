@@ -0,0 +1,321 @@
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	logger "github.com/sirupsen/logrus"
+
+	"github.com/pokt-foundation/portal-api-go/relay"
+	"github.com/pokt-foundation/portal-api-go/repository"
+)
+
+const (
+	// defaultSubscriptionPollInterval is used if the server settings set no poll interval
+	defaultSubscriptionPollInterval = 2 * time.Second
+	// maxSubscriptions bounds the subscriptions of a connection, each of them polls the pinned node
+	maxSubscriptions = 10
+)
+
+const (
+	subscriptionNewHeads = "newHeads"
+	subscriptionLogs     = "logs"
+)
+
+var (
+	// ErrSubscriptionsNotSupported is returned to subscriptions that can not be served by polling the pinned node,
+	// e.g. newPendingTransactions, or accountSubscribe of Solana
+	ErrSubscriptionsNotSupported = &repository.CodedError{Code: -32601, Message: "subscription not supported"}
+	ErrInvalidSubscriptionParams = &repository.CodedError{Code: -32602, Message: "invalid subscription params"}
+	ErrTooManySubscriptions      = &repository.CodedError{Code: -32005, Message: "too many subscriptions"}
+)
+
+// subscription is an eth_subscribe subscription of a WebSocket connection. Relays to nodes are request-response,
+// so nodes can not push the notifications of subscriptions: the pinned node is polled for the blocks after
+// lastBlock instead, and the new heads or logs of the blocks are written to the connection as notifications.
+type subscription struct {
+	id   string
+	kind string
+	// filter holds the address and topics of logs subscriptions
+	filter    map[string]json.RawMessage
+	lastBlock uint64
+}
+
+// subscriptionRequest is an eth_subscribe or eth_unsubscribe request, or any other subscription request,
+// sent on a WebSocket connection
+type subscriptionRequest struct {
+	BlockchainID string
+	ID           json.RawMessage
+	Method       string
+	Params       []json.RawMessage
+}
+
+// parseSubscriptionRequest returns the subscription request of the message, and whether the message is one.
+// Params are accepted as a JSON array, or as the string of one like the other fields of rawData.
+func parseSubscriptionRequest(message []byte) (subscriptionRequest, bool) {
+	var m struct {
+		BlockchainID string `json:"blockchainID"`
+		RawData      struct {
+			ID     json.RawMessage `json:"id"`
+			Method string          `json:"method"`
+			Params json.RawMessage `json:"params"`
+		} `json:"rawData"`
+	}
+	if err := json.Unmarshal(message, &m); err != nil {
+		return subscriptionRequest{}, false
+	}
+	if !strings.HasSuffix(strings.ToLower(m.RawData.Method), "subscribe") {
+		return subscriptionRequest{}, false
+	}
+
+	params := m.RawData.Params
+	var encoded string
+	if err := json.Unmarshal(params, &encoded); err == nil {
+		params = json.RawMessage(encoded)
+	}
+	var list []json.RawMessage
+	// Invalid params are rejected by each method
+	json.Unmarshal(params, &list)
+
+	return subscriptionRequest{
+		BlockchainID: m.BlockchainID,
+		ID:           m.RawData.ID,
+		Method:       m.RawData.Method,
+		Params:       list,
+	}, true
+}
+
+// newSubscription returns the subscription of the eth_subscribe params, e.g. ["logs", {"address": "0x..."}]
+func newSubscription(params []json.RawMessage) (*subscription, error) {
+	if len(params) == 0 {
+		return nil, ErrInvalidSubscriptionParams
+	}
+	var kind string
+	if err := json.Unmarshal(params[0], &kind); err != nil {
+		return nil, ErrInvalidSubscriptionParams
+	}
+
+	s := &subscription{
+		id:   "0x" + strings.ReplaceAll(uuid.New().String(), "-", ""),
+		kind: kind,
+	}
+	switch kind {
+	case subscriptionNewHeads:
+	case subscriptionLogs:
+		s.filter = make(map[string]json.RawMessage)
+		if len(params) > 1 {
+			var filter struct {
+				Address json.RawMessage `json:"address"`
+				Topics  json.RawMessage `json:"topics"`
+			}
+			if err := json.Unmarshal(params[1], &filter); err != nil {
+				return nil, ErrInvalidSubscriptionParams
+			}
+			if filter.Address != nil {
+				s.filter["address"] = filter.Address
+			}
+			if filter.Topics != nil {
+				s.filter["topics"] = filter.Topics
+			}
+		}
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrSubscriptionsNotSupported, kind)
+	}
+	return s, nil
+}
+
+// handleSubscription serves eth_subscribe and eth_unsubscribe requests, other subscription requests are rejected
+// with ErrSubscriptionsNotSupported
+func (s *wsSession) handleSubscription(request subscriptionRequest) error {
+	switch request.Method {
+	case "eth_subscribe":
+		if len(s.subscriptions) >= maxSubscriptions {
+			return s.conn.writeError(request.ID, ErrTooManySubscriptions)
+		}
+		sub, err := newSubscription(request.Params)
+		if err != nil {
+			return s.conn.writeError(request.ID, err)
+		}
+
+		// Notifications start after the latest block of the pinned node, the connection is pinned if not yet
+		latest, err := s.blockNumber(request.BlockchainID)
+		if errors.Is(err, relay.ErrNodeUnavailable) {
+			return err
+		}
+		if err != nil {
+			s.log.WithFields(logger.Fields{"error": err}).Warn("Error subscribing")
+			return s.conn.writeError(request.ID, err)
+		}
+		sub.lastBlock = latest
+		s.subscriptions[sub.id] = sub
+
+		s.log.WithFields(logger.Fields{"subscription": sub.id, "kind": sub.kind}).Info("WebSocket subscription added")
+		return s.conn.writeResult(request.ID, sub.id)
+	case "eth_unsubscribe":
+		var id string
+		if len(request.Params) != 1 || json.Unmarshal(request.Params[0], &id) != nil {
+			return s.conn.writeError(request.ID, ErrInvalidSubscriptionParams)
+		}
+		_, ok := s.subscriptions[id]
+		delete(s.subscriptions, id)
+		return s.conn.writeResult(request.ID, ok)
+	default:
+		return s.conn.writeError(request.ID, fmt.Errorf("%w: %s", ErrSubscriptionsNotSupported, request.Method))
+	}
+}
+
+// poll writes the notifications of the blocks added to the pinned node since the last poll. Each relay to the node
+// is counted against the rate limits of the connection: polls exceeding them are skipped until the next interval.
+// Subscriptions whose poll fails are polled again on the next interval, from the same block.
+func (s *wsSession) poll() error {
+	if len(s.subscriptions) == 0 {
+		return nil
+	}
+	// Connections with subscriptions are not idle, even if the client sends no messages
+	if err := s.conn.extendIdleTimeout(); err != nil {
+		return err
+	}
+
+	if err := limitMessage(s.req); err != nil {
+		s.log.WithFields(logger.Fields{"error": err}).Debug("Rate limit exceeded, skipping subscriptions poll")
+		return nil
+	}
+	latest, err := s.blockNumber("")
+	if err != nil {
+		return s.pollError(err)
+	}
+
+	// Subscriptions are polled in a stable order, for their notifications to be written in the same order
+	ids := make([]string, 0, len(s.subscriptions))
+	for id := range s.subscriptions {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		sub := s.subscriptions[id]
+		if latest <= sub.lastBlock {
+			continue
+		}
+
+		if err := limitMessage(s.req); err != nil {
+			s.log.WithFields(logger.Fields{"error": err}).Debug("Rate limit exceeded, skipping subscriptions poll")
+			return nil
+		}
+		results, err := s.pollSubscription(sub, latest)
+		if err != nil {
+			if err := s.pollError(err); err != nil {
+				return err
+			}
+			continue
+		}
+
+		for _, result := range results {
+			if err := s.conn.writeNotification(sub.id, result); err != nil {
+				return err
+			}
+		}
+		sub.lastBlock = latest
+	}
+	return nil
+}
+
+// pollError returns relay.ErrNodeUnavailable, for the connection to be closed, and logs other errors
+func (s *wsSession) pollError(err error) error {
+	if errors.Is(err, relay.ErrNodeUnavailable) {
+		return err
+	}
+	s.log.WithFields(logger.Fields{"error": err}).Warn("Error polling websocket subscriptions")
+	return nil
+}
+
+// pollSubscription returns the notifications of the subscription for the blocks up to latest
+func (s *wsSession) pollSubscription(sub *subscription, latest uint64) ([]json.RawMessage, error) {
+	switch sub.kind {
+	case subscriptionNewHeads:
+		// Only the latest head is notified, heads skipped between polls are not
+		result, err := s.nodeCall("eth_getBlockByNumber", hexNumber(latest), false)
+		if err != nil || string(result) == "null" {
+			return nil, err
+		}
+		return []json.RawMessage{result}, nil
+	case subscriptionLogs:
+		filter := make(map[string]any, len(sub.filter)+2)
+		for k, v := range sub.filter {
+			filter[k] = v
+		}
+		filter["fromBlock"] = hexNumber(sub.lastBlock + 1)
+		filter["toBlock"] = hexNumber(latest)
+
+		result, err := s.nodeCall("eth_getLogs", filter)
+		if err != nil {
+			return nil, err
+		}
+		var logs []json.RawMessage
+		if err := json.Unmarshal(result, &logs); err != nil {
+			return nil, fmt.Errorf("Error parsing logs: %w", err)
+		}
+		return logs, nil
+	}
+	return nil, nil
+}
+
+// blockNumber returns the latest block number of the pinned node, or of the node serving the request on the
+// blockchain if the connection is not pinned yet
+func (s *wsSession) blockNumber(blockchainID string) (uint64, error) {
+	result, err := s.nodeCallOn(blockchainID, "eth_blockNumber")
+	if err != nil {
+		return 0, err
+	}
+	var number string
+	if err := json.Unmarshal(result, &number); err != nil {
+		return 0, fmt.Errorf("Error parsing block number: %w", err)
+	}
+	n, err := strconv.ParseUint(strings.TrimPrefix(number, "0x"), 16, 64)
+	if err != nil {
+		return 0, fmt.Errorf("Error parsing block number %q: %w", number, err)
+	}
+	return n, nil
+}
+
+// nodeCall relays the JSON-RPC request to the pinned node and returns its result
+func (s *wsSession) nodeCall(method string, params ...any) (json.RawMessage, error) {
+	return s.nodeCallOn("", method, params...)
+}
+
+func (s *wsSession) nodeCallOn(blockchainID, method string, params ...any) (json.RawMessage, error) {
+	if params == nil {
+		params = []any{}
+	}
+	request, err := json.Marshal(map[string]any{"jsonrpc": "2.0", "id": 1, "method": method, "params": params})
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := s.relay(blockchainID, string(request))
+	if err != nil {
+		return nil, err
+	}
+
+	var r struct {
+		Result json.RawMessage `json:"result"`
+		Error  *jsonRPCError   `json:"error"`
+	}
+	if err := json.Unmarshal([]byte(response.Data), &r); err != nil {
+		return nil, fmt.Errorf("Error parsing %s response: %w", method, err)
+	}
+	if r.Error != nil {
+		return nil, &repository.CodedError{Code: r.Error.Code, Message: r.Error.Message}
+	}
+	return r.Result, nil
+}
+
+func hexNumber(n uint64) string {
+	return "0x" + strconv.FormatUint(n, 16)
+}
@@ -2,26 +2,34 @@ package web
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"net/http"
 	"regexp"
 	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	logger "github.com/sirupsen/logrus"
 
 	"github.com/pokt-foundation/portal-api-go/relay"
+	"github.com/pokt-foundation/portal-api-go/repository"
 )
 
 const idLength = 24
 
+// The ID of the paths may be followed by the relay path, either encoded with '~', e.g. /v1/{id}~relay~path,
+// or native, e.g. /v1/{id}/relay/path. Paths are matched from their first /v1/ segment.
 var (
-	appsPath = regexp.MustCompile(`[[:alnum:]]+/v1/([[:alnum:]]+[[:alnum:]-~]*[[:alnum:]]+)$`)
-	lbsPath  = regexp.MustCompile(`[[:alnum:]]+/v1/[l|L][b|B]/([[:alnum:]]+[[:alnum:]-~]*[[:alnum:]]+)$`)
-	idExp    = regexp.MustCompile(`^[[:alnum:]-]{24}~`)
+	appsPath   = regexp.MustCompile(`^/v1/([[:alnum:]][[:alnum:]-]*)([~/].*)?$`)
+	lbsPath    = regexp.MustCompile(`^/v1/[lL][bB]/([[:alnum:]][[:alnum:]-]*)([~/].*)?$`)
+	pathPrefix = regexp.MustCompile(`[[:alnum:]]$`)
 )
 
+// relayMethods are the HTTP methods accepted for relays: methods other than POST are meant for REST chains
+var relayMethods = []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete}
+
 type HTTPRequestError error
 
 var (
@@ -29,39 +37,67 @@ var (
 )
 
 func ids(path string) (string, string, string, error) {
-	match := func(r *regexp.Regexp, p string) string {
+	match := func(r *regexp.Regexp, p string) (string, string) {
 		matches := r.FindStringSubmatch(p)
-		if len(matches) != 2 {
-			return ""
+		if len(matches) != 3 {
+			return "", ""
+		}
+		id := matches[1]
+		if len(id) > idLength {
+			id = id[:idLength]
 		}
-		return matches[1]
+		return id, getRelayPath(matches[2])
 	}
 
-	if appID := match(appsPath, path); appID != "" {
-		if len(appID) > idLength {
-			return appID[:idLength], "", getRelayPath(appID), nil
-		}
-		return appID, "", "", nil
+	// Later /v1/ segments are part of the relay path, e.g. of REST chains
+	i := strings.Index(path, "/v1/")
+	if i < 0 || !pathPrefix.MatchString(path[:i]) {
+		return "", "", "", ErrInvalidPath
 	}
+	path = path[i:]
 
-	if lbID := match(lbsPath, path); lbID != "" {
-		if len(lbID) > idLength {
-			return "", lbID[:idLength], getRelayPath(lbID), nil
-		}
-		return "", lbID, "", nil
+	// Load balancer paths are matched first, as they would otherwise match as an application ID of "lb"
+	if lbID, relayPath := match(lbsPath, path); lbID != "" {
+		return "", lbID, relayPath, nil
+	}
+
+	if appID, relayPath := match(appsPath, path); appID != "" {
+		return appID, "", relayPath, nil
 	}
 	return "", "", "", ErrInvalidPath
 }
 
-func getRelayPath(id string) string {
-	if !idExp.MatchString(id) {
-		return ""
+// getRelayPath decodes the relay path following the ID of a request path, where '~' stands for '/'
+func getRelayPath(suffix string) string {
+	return strings.ReplaceAll(suffix, "~", "/")
+}
+
+func buildRelayOptions(req *http.Request, ips *ClientIPResolver) (relay.RelayOptions, error) {
+	relayOptions, err := pathRelayOptions(req, ips)
+	if err != nil {
+		return relay.RelayOptions{}, err
 	}
 
-	return strings.ReplaceAll(id[idLength:], "~", "/")
+	defer req.Body.Close()
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		return relay.RelayOptions{}, fmt.Errorf("Error reading request body: %w", err)
+	}
+
+	// Requests without a body, e.g. GET requests to REST chains, relay to the blockchain of the host's alias
+	if len(body) == 0 {
+		relayOptions.BlockchainID = relayOptions.Host
+		return relayOptions, nil
+	}
+
+	if err := setRelayBody(&relayOptions, body); err != nil {
+		return relay.RelayOptions{}, err
+	}
+	return relayOptions, nil
 }
 
-func buildRelayOptions(req *http.Request) (relay.RelayOptions, error) {
+// pathRelayOptions returns the relay options of the request's path and headers
+func pathRelayOptions(req *http.Request, ips *ClientIPResolver) (relay.RelayOptions, error) {
 	appID, lbID, relayPath, err := ids(req.URL.Path)
 	if err != nil {
 		return relay.RelayOptions{}, err
@@ -76,10 +112,11 @@ func buildRelayOptions(req *http.Request) (relay.RelayOptions, error) {
 		ApplicationID:  appID,
 		LoadBalancerID: lbID,
 		Path:           relayPath,
-		Method:         "POST",
+		Query:          req.URL.RawQuery,
+		Method:         req.Method,
 		RequestID:      uuid.New(),
 		Host:           pathParts[0],
-		IP:             req.RemoteAddr,
+		IP:             ips.ClientIP(req),
 	}
 
 	origins, ok := req.Header["Origin"]
@@ -87,12 +124,11 @@ func buildRelayOptions(req *http.Request) (relay.RelayOptions, error) {
 		relayOptions.Origin = origins[0]
 	}
 
-	defer req.Body.Close()
-	body, err := ioutil.ReadAll(req.Body)
-	if err != nil {
-		return relay.RelayOptions{}, fmt.Errorf("Error reading request body: %w", err)
-	}
+	return relayOptions, nil
+}
 
+// setRelayBody sets the blockchain and data of the relay from the body of a request, or a WebSocket message
+func setRelayBody(relayOptions *relay.RelayOptions, body []byte) error {
 	type requestBody struct {
 		BlockchainID string            `json:"blockchainID"`
 		RawData      map[string]string `json:"rawData"`
@@ -100,31 +136,41 @@ func buildRelayOptions(req *http.Request) (relay.RelayOptions, error) {
 
 	var reqBody requestBody
 	if err := json.Unmarshal(body, &reqBody); err != nil {
-		return relay.RelayOptions{}, fmt.Errorf("Error unmarshalling request body: %w", err)
+		return fmt.Errorf("Error unmarshalling request body: %w", err)
 	}
 	data, err := parseRawData(reqBody.RawData)
 	if err != nil {
-		return relay.RelayOptions{}, fmt.Errorf("Error marshalling raw data: %w", err)
+		return fmt.Errorf("Error marshalling raw data: %w", err)
 	}
 
 	relayOptions.BlockchainID = reqBody.BlockchainID
 	relayOptions.RawData = data
 
-	return relayOptions, nil
+	return nil
+}
+
+// sendRelay relays through the load balancer of the options if set, otherwise through the application
+func sendRelay(r relay.Relayer, relayOptions relay.RelayOptions) (relay.RelayResponse, error) {
+	if relayOptions.LoadBalancerID != "" {
+		return r.RelayWithLb(relayOptions)
+	}
+	return r.RelayWithApp(relayOptions)
 }
 
-// serves: /v1/{id}, /v1/lb/{id}
-func GetHTTPServer(r relay.Relayer, l *logger.Logger) func(w http.ResponseWriter, req *http.Request) {
+// serves: /v1/{id}, /v1/lb/{id}, optionally followed by the relay path, e.g. /v1/{id}/relay/path or /v1/{id}~relay~path
+// The client IP of relays is resolved by ips, which may be nil if there are no trusted proxies.
+func GetHTTPServer(r relay.Relayer, ips *ClientIPResolver, l *logger.Logger) func(w http.ResponseWriter, req *http.Request) {
 	return func(w http.ResponseWriter, req *http.Request) {
 		log := l.WithFields(logger.Fields{"Request": *req})
-		if req.Method != http.MethodPost {
-			log.Warn("Incorrect request method, expected: " + http.MethodPost)
-			// fmt.Fprintf(w, "Incorrect request method, expected: %s, got %s", http.MethodPost, req.Method)
-			http.Error(w, fmt.Sprintf("Incorrect request method, expected: %s, got: %s", http.MethodPost, req.Method), http.StatusBadRequest)
+		if !isRelayMethod(req.Method) {
+			allowed := strings.Join(relayMethods, ", ")
+			log.Warn("Incorrect request method, expected one of: " + allowed)
+			w.Header().Set("Allow", allowed)
+			http.Error(w, fmt.Sprintf("Incorrect request method, expected one of: %s, got: %s", allowed, req.Method), http.StatusMethodNotAllowed)
 			return
 		}
 
-		relayOptions, err := buildRelayOptions(req)
+		relayOptions, err := buildRelayOptions(req, ips)
 		if err != nil {
 			log.WithFields(logger.Fields{"error": err}).Warn("Failed to build relay request from http request")
 			fmt.Fprintf(w, "invalid request")
@@ -133,10 +179,18 @@ func GetHTTPServer(r relay.Relayer, l *logger.Logger) func(w http.ResponseWriter
 		log = log.WithFields(logger.Fields{"relayOptions": relayOptions})
 		log.Info("Build relay request from http request")
 
-		if relayOptions.LoadBalancerID != "" {
-			err = r.RelayWithLb(relayOptions)
-		} else {
-			err = r.RelayWithApp(relayOptions)
+		response, err := sendRelay(r, relayOptions)
+		if errors.Is(err, relay.ErrRateLimitExceeded) {
+			log.WithFields(logger.Fields{"error": err}).Warn("Pay plan rate limit exceeded")
+			writeRateLimited(w, requestID(relayOptions.RawData), time.Second, err)
+			return
+		}
+		// Coded errors are returned as JSON-RPC errors, like those of WebSocket relays
+		var codedErr *repository.CodedError
+		if errors.As(err, &codedErr) {
+			log.WithFields(logger.Fields{"error": err}).Warn("Error relaying")
+			writeJSONRPCError(w, http.StatusBadRequest, requestID(relayOptions.RawData), err)
+			return
 		}
 		if err != nil {
 			log.WithFields(logger.Fields{"error": err}).Warn("Error relaying")
@@ -144,9 +198,25 @@ func GetHTTPServer(r relay.Relayer, l *logger.Logger) func(w http.ResponseWriter
 			http.Error(w, err.Error(), http.StatusBadRequest)
 			return
 		}
+		if response.Warning != "" {
+			w.Header().Set("Warning", fmt.Sprintf("199 - %q", response.Warning))
+		}
 		log.Info("Relay sent")
-		fmt.Fprintf(w, "Relaysent")
+		// Responses of REST chains may not be JSON: leave those to content sniffing
+		if json.Valid([]byte(response.Data)) {
+			w.Header().Set("Content-Type", "application/json")
+		}
+		fmt.Fprint(w, response.Data)
+	}
+}
+
+func isRelayMethod(method string) bool {
+	for _, m := range relayMethods {
+		if method == m {
+			return true
+		}
 	}
+	return false
 }
 
 // TODO: Verify this data type can handle all possible raw data input
@@ -2,7 +2,9 @@ package web
 
 import (
 	"bytes"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
@@ -61,6 +63,30 @@ func TestGetIDs(t *testing.T) {
 			expectedApp:  "app-12345678901234567890",
 			expectedPath: "/relayPath/001",
 		},
+		{
+			name:         "Native relay path is extracted from App ID",
+			path:         "/v1/app-12345678901234567890/relayPath/001/",
+			expectedApp:  "app-12345678901234567890",
+			expectedPath: "/relayPath/001/",
+		},
+		{
+			name:         "Only the first /v1/ segment is matched",
+			path:         "/v1/app-12345678901234567890/foo/v1/lb/bar",
+			expectedApp:  "app-12345678901234567890",
+			expectedPath: "/foo/v1/lb/bar",
+		},
+		{
+			name:         "Relay path of a load balancer may contain /v1/",
+			path:         "/v1/lb/lb-123456789012345678901/v1/app-1",
+			expectedLb:   "lb-123456789012345678901",
+			expectedPath: "/v1/app-1",
+		},
+		{
+			name:         "Native relay path is extracted from Lb ID",
+			path:         "/v1/LB/lb-123456789012345678901/relayPath~001",
+			expectedLb:   "lb-123456789012345678901",
+			expectedPath: "/relayPath/001",
+		},
 	}
 
 	for _, tc := range testCases {
@@ -98,6 +124,7 @@ func TestBuildRelayOptions(t *testing.T) {
 		{
 			name: "valid http request on loadbalancer endpoint",
 			req: &http.Request{
+				Method: http.MethodPost,
 				URL: &url.URL{
 					Path: "eth-mainnet.pokt.network/v1/lb/lb-123456789012345678901~relay~path~12",
 				},
@@ -118,6 +145,25 @@ func TestBuildRelayOptions(t *testing.T) {
 				RawData:        string(`{"method":"post","rpcID":"rpcID002"}`),
 			},
 		},
+		{
+			name: "GET request without body relays to the blockchain of the host",
+			req: &http.Request{
+				Method: http.MethodGet,
+				URL: &url.URL{
+					Path:     "eth-mainnet.pokt.network/v1/app-12345678901234567890/v1/blocks/latest",
+					RawQuery: "height=10",
+				},
+				Body: ioutil.NopCloser(bytes.NewReader(nil)),
+			},
+			expected: relay.RelayOptions{
+				Path:          "/v1/blocks/latest",
+				Query:         "height=10",
+				Method:        "GET",
+				Host:          "eth-mainnet",
+				ApplicationID: "app-12345678901234567890",
+				BlockchainID:  "eth-mainnet",
+			},
+		},
 		{
 			name: "Invalid request path",
 			req: &http.Request{
@@ -132,7 +178,7 @@ func TestBuildRelayOptions(t *testing.T) {
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			actual, err := buildRelayOptions(tc.req)
+			actual, err := buildRelayOptions(tc.req, nil)
 			if tc.expectedErr != nil {
 				if err == nil {
 					t.Fatalf("Expected error: %v, got nil", tc.expectedErr)
@@ -161,11 +207,13 @@ func TestGetHttpServer(t *testing.T) {
 	}
 
 	testCases := []struct {
-		name          string
-		path          string
-		expectedAppID string
-		expectedLbID  string
-		expectedErr   error
+		name            string
+		path            string
+		response        relay.RelayResponse
+		expectedAppID   string
+		expectedLbID    string
+		expectedWarning string
+		expectedErr     error
 	}{
 		{
 			name:         "Relay with Load Balancer is sent to correct relayer handler",
@@ -177,12 +225,19 @@ func TestGetHttpServer(t *testing.T) {
 			path:          "eth-mainnet.pokt.network/v1/app-12345678901234567890~relay~path~12",
 			expectedAppID: "app-12345678901234567890",
 		},
+		{
+			name:            "Relay warning is returned in the Warning header",
+			path:            "eth-mainnet.pokt.network/v1/app-12345678901234567890~relay~path~12",
+			response:        relay.RelayResponse{Warning: "application status is AWAITING_GRACE_PERIOD"},
+			expectedAppID:   "app-12345678901234567890",
+			expectedWarning: `199 - "application status is AWAITING_GRACE_PERIOD"`,
+		},
 	}
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			f := fakeRelayer{}
-			httpServer := GetHTTPServer(&f, logger.New())
+			f := fakeRelayer{response: tc.response}
+			httpServer := GetHTTPServer(&f, nil, logger.New())
 			//TODO: use httptest.NewRequest
 			req := &http.Request{
 				Method: http.MethodPost,
@@ -201,6 +256,9 @@ func TestGetHttpServer(t *testing.T) {
 			if resp.StatusCode != 200 {
 				t.Fatalf("Expected status code: 200, got: %d", resp.StatusCode)
 			}
+			if warning := resp.Header.Get("Warning"); warning != tc.expectedWarning {
+				t.Errorf("Expected Warning header: %q, got: %q", tc.expectedWarning, warning)
+			}
 
 			expected := expectedRelay
 			var actual relay.RelayOptions
@@ -220,17 +278,151 @@ func TestGetHttpServer(t *testing.T) {
 	}
 }
 
+func TestGetHttpServerMethods(t *testing.T) {
+	testCases := []struct {
+		name           string
+		method         string
+		expectedStatus int
+		expectedMethod string
+	}{
+		{
+			name:           "GET relays are sent with their method",
+			method:         http.MethodGet,
+			expectedStatus: http.StatusOK,
+			expectedMethod: http.MethodGet,
+		},
+		{
+			name:           "DELETE relays are sent with their method",
+			method:         http.MethodDelete,
+			expectedStatus: http.StatusOK,
+			expectedMethod: http.MethodDelete,
+		},
+		{
+			name:           "Unsupported methods are rejected",
+			method:         http.MethodOptions,
+			expectedStatus: http.StatusMethodNotAllowed,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			f := fakeRelayer{response: relay.RelayResponse{Data: `{"status":"ok"}`}}
+			httpServer := GetHTTPServer(&f, nil, logger.New())
+			req := httptest.NewRequest(tc.method, "/v1/app-12345678901234567890/status?verbose=true", nil)
+			req.URL.Path = "eth-mainnet.pokt.network" + req.URL.Path
+
+			w := httptest.NewRecorder()
+			httpServer(w, req)
+			resp := w.Result()
+			if resp.StatusCode != tc.expectedStatus {
+				t.Fatalf("Expected status code: %d, got: %d", tc.expectedStatus, resp.StatusCode)
+			}
+			if tc.expectedStatus != http.StatusOK {
+				if allow := resp.Header.Get("Allow"); allow == "" {
+					t.Errorf("Expected Allow header")
+				}
+				return
+			}
+			body, err := ioutil.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatalf("Error reading response body: %v", err)
+			}
+			if string(body) != `{"status":"ok"}` || resp.Header.Get("Content-Type") != "application/json" {
+				t.Errorf("Expected relay response, got: %s %q", resp.Header.Get("Content-Type"), body)
+			}
+
+			expected := relay.RelayOptions{
+				Method:        tc.expectedMethod,
+				Host:          "eth-mainnet",
+				Path:          "/status",
+				Query:         "verbose=true",
+				BlockchainID:  "eth-mainnet",
+				ApplicationID: "app-12345678901234567890",
+				IP:            "192.0.2.1",
+				RequestID:     f.appRelay.RequestID,
+			}
+			if diff := cmp.Diff(expected, f.appRelay); diff != "" {
+				t.Errorf("unexpected relay value (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestGetHttpServerCodedErrors(t *testing.T) {
+	testCases := []struct {
+		name           string
+		err            error
+		expectedStatus int
+	}{
+		{
+			name:           "Application status",
+			err:            relay.ErrApplicationStatus,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Inactive blockchain",
+			err:            fmt.Errorf("%w: 0021", relay.ErrBlockchainInactive),
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Blockchain not allowed by the pay plan",
+			err:            relay.ErrChainNotAllowed,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Daily limit exceeded",
+			err:            relay.ErrDailyLimitExceeded,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Rate limit exceeded",
+			err:            relay.ErrRateLimitExceeded,
+			expectedStatus: http.StatusTooManyRequests,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			f := fakeRelayer{err: tc.err}
+			req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader([]byte(`{"blockchainID": "0021", "rawData": {"id": "7", "method": "eth_blockNumber"}}`)))
+			req.URL.Path = "eth-mainnet.pokt.network/v1/app-12345678901234567890"
+
+			w := httptest.NewRecorder()
+			GetHTTPServer(&f, nil, logger.New())(w, req)
+
+			resp := w.Result()
+			if resp.StatusCode != tc.expectedStatus {
+				t.Fatalf("Expected status code: %d, got: %d", tc.expectedStatus, resp.StatusCode)
+			}
+			if contentType := resp.Header.Get("Content-Type"); contentType != "application/json" {
+				t.Errorf("Expected Content-Type: application/json, got: %q", contentType)
+			}
+			var response jsonRPCErrorResponse
+			if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+				t.Fatalf("Error decoding response: %v", err)
+			}
+			// The same error is written to WebSocket clients
+			expected := newJSONRPCErrorResponse(json.RawMessage(`"7"`), tc.err)
+			if diff := cmp.Diff(expected, response); diff != "" {
+				t.Errorf("unexpected value (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
 type fakeRelayer struct {
 	appRelay relay.RelayOptions
 	lbRelay  relay.RelayOptions
+	response relay.RelayResponse
+	err      error
 }
 
-func (f *fakeRelayer) RelayWithApp(r relay.RelayOptions) error {
+func (f *fakeRelayer) RelayWithApp(r relay.RelayOptions) (relay.RelayResponse, error) {
 	f.appRelay = r
-	return nil
+	return f.response, f.err
 }
 
-func (f *fakeRelayer) RelayWithLb(r relay.RelayOptions) error {
+func (f *fakeRelayer) RelayWithLb(r relay.RelayOptions) (relay.RelayResponse, error) {
 	f.lbRelay = r
-	return nil
+	return f.response, f.err
 }
@@ -0,0 +1,352 @@
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+	logger "github.com/sirupsen/logrus"
+
+	"github.com/pokt-foundation/portal-api-go/relay"
+	"github.com/pokt-foundation/portal-api-go/repository"
+)
+
+// defaultMaxMessageSize bounds the messages of WebSocket connections if the server settings set no body size limit
+const defaultMaxMessageSize = 10 << 20
+
+var ErrBlockchainPinned = errors.New("connection is pinned to another blockchain")
+
+// upgrader accepts connections from any origin, as the relay paths do for browser dApps
+var upgrader = websocket.Upgrader{
+	CheckOrigin: func(*http.Request) bool { return true },
+}
+
+// WebSocket wraps the relay handler, serving WebSocket upgrade requests on the same paths.
+// The application or load balancer is resolved on the handshake, then each message of the connection is relayed
+// like the body of a POST request, and counted against the rate limits of the request and the application's pay plan.
+// The connection is pinned to the blockchain, application and node that served its first relay, and is closed
+// once the node rotates out of the session, for the client to reconnect.
+// Subscriptions to new heads and logs are served by polling the pinned node, see subscription.
+func WebSocket(next http.HandlerFunc, r relay.Relayer, repo repository.Repository, ips *ClientIPResolver, settings ServerSettings, l *logger.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		if !websocket.IsWebSocketUpgrade(req) {
+			next(w, req)
+			return
+		}
+
+		log := l.WithFields(logger.Fields{"path": req.URL.Path, "ip": ips.ClientIP(req)})
+
+		options, err := pathRelayOptions(req, ips)
+		if err != nil {
+			log.WithFields(logger.Fields{"error": err}).Warn("Failed to build relay request from websocket handshake")
+			http.Error(w, "invalid request", http.StatusBadRequest)
+			return
+		}
+		// Messages are relayed as JSON-RPC requests, regardless of the GET method of the handshake
+		options.Method = http.MethodPost
+		if err := resolveRelayTarget(repo, options); err != nil {
+			log.WithFields(logger.Fields{"error": err}).Warn("Error resolving websocket relay target")
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		// The upgrader responds to invalid handshakes
+		ws, err := upgrader.Upgrade(w, req, nil)
+		if err != nil {
+			log.WithFields(logger.Fields{"error": err}).Warn("Error upgrading to websocket")
+			return
+		}
+		conn := newWSConn(ws, settings)
+
+		log.Info("WebSocket connection opened")
+		serveWebSocket(req, conn, r, options, settings, log)
+		log.Info("WebSocket connection closed")
+	}
+}
+
+// resolveRelayTarget returns an error if the application or load balancer of the relay options does not exist,
+// or is invalid
+func resolveRelayTarget(repo repository.Repository, options relay.RelayOptions) error {
+	if options.LoadBalancerID != "" {
+		_, err := repo.GetLoadBalancer(options.LoadBalancerID)
+		return err
+	}
+	_, err := repo.GetApplication(options.ApplicationID)
+	return err
+}
+
+// serveWebSocket relays the messages of the connection, and polls the pinned node for the notifications of its
+// subscriptions, until the connection is closed by either side, or the server shuts down
+func serveWebSocket(req *http.Request, conn *wsConn, r relay.Relayer, base relay.RelayOptions, settings ServerSettings, log *logger.Entry) {
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-serverShutdown(req.Context()):
+			conn.close(websocket.CloseGoingAway, "server shutting down")
+		case <-done:
+		}
+	}()
+
+	// Messages are read on their own goroutine, for the notifications of subscriptions to be written in between
+	messages := make(chan []byte)
+	readErr := make(chan error, 1)
+	go func() {
+		for {
+			message, err := conn.readMessage()
+			if err != nil {
+				readErr <- err
+				return
+			}
+			select {
+			case messages <- message:
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	pollInterval := settings.SubscriptionPollInterval
+	if pollInterval <= 0 {
+		pollInterval = defaultSubscriptionPollInterval
+	}
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	s := &wsSession{
+		req:           req,
+		conn:          conn,
+		relayer:       r,
+		base:          base,
+		subscriptions: make(map[string]*subscription),
+		log:           log,
+	}
+	for {
+		var err error
+		select {
+		case err = <-readErr:
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				conn.close(websocket.CloseGoingAway, "idle timeout")
+			} else {
+				// Close frames of the client and protocol errors are answered by the connection itself
+				conn.Close()
+			}
+			return
+		case message := <-messages:
+			err = s.handleMessage(message)
+		case <-ticker.C:
+			err = s.poll()
+		}
+
+		if errors.Is(err, relay.ErrNodeUnavailable) {
+			s.log.WithFields(logger.Fields{"error": err}).Info("Pinned node rotated out of the session")
+			conn.close(websocket.CloseServiceRestart, "session rotated, reconnect")
+			return
+		}
+		if err != nil {
+			s.log.WithFields(logger.Fields{"error": err}).Warn("Error writing websocket message")
+			conn.Close()
+			return
+		}
+	}
+}
+
+// wsSession holds the state of a WebSocket connection: the node it is pinned to, and its subscriptions
+type wsSession struct {
+	req     *http.Request
+	conn    *wsConn
+	relayer relay.Relayer
+	base    relay.RelayOptions
+	pinned  relay.RelayOptions
+	// subscriptions are keyed by their ID
+	subscriptions map[string]*subscription
+
+	log *logger.Entry
+}
+
+// handleMessage relays the message, or serves it if it is a subscription request, and writes the response.
+// It returns relay.ErrNodeUnavailable once the pinned node rotates out of the session, and the errors of writing
+// to the connection: other errors are written to the client.
+func (s *wsSession) handleMessage(message []byte) error {
+	// Errors are sent with the id of the request, for clients to match them on the shared connection
+	id := messageID(message)
+
+	if err := limitMessage(s.req); err != nil {
+		s.log.WithFields(logger.Fields{"error": err}).Warn("Rate limit exceeded")
+		return s.conn.writeError(id, err)
+	}
+
+	if request, ok := parseSubscriptionRequest(message); ok {
+		return s.handleSubscription(request)
+	}
+
+	var options relay.RelayOptions
+	if err := setRelayBody(&options, message); err != nil {
+		return s.conn.writeError(id, err)
+	}
+
+	response, err := s.relay(options.BlockchainID, options.RawData)
+	if errors.Is(err, relay.ErrNodeUnavailable) {
+		return err
+	}
+	if err != nil {
+		s.log.WithFields(logger.Fields{"error": err}).Warn("Error relaying websocket message")
+		return s.conn.writeError(id, err)
+	}
+
+	return s.conn.writeMessage([]byte(response.Data))
+}
+
+// relay sends the JSON-RPC request to the pinned node, pinning the connection to the node that serves it if
+// not pinned yet. Requests without a blockchain are sent to the pinned blockchain.
+func (s *wsSession) relay(blockchainID, rawData string) (relay.RelayResponse, error) {
+	options := s.base
+	options.RequestID = uuid.New()
+	options.BlockchainID = blockchainID
+	options.RawData = rawData
+
+	if options.BlockchainID == "" {
+		options.BlockchainID = s.pinned.BlockchainID
+	}
+	if s.pinned.BlockchainID != "" && options.BlockchainID != s.pinned.BlockchainID {
+		return relay.RelayResponse{}, fmt.Errorf("%w: %s", ErrBlockchainPinned, s.pinned.BlockchainID)
+	}
+	options.NodeAddress = s.pinned.NodeAddress
+	if options.LoadBalancerID != "" {
+		options.ApplicationID = s.pinned.ApplicationID
+	}
+
+	response, err := sendRelay(s.relayer, options)
+	if err != nil {
+		return relay.RelayResponse{}, err
+	}
+
+	if s.pinned.NodeAddress == "" {
+		s.pinned = relay.RelayOptions{
+			BlockchainID:  options.BlockchainID,
+			ApplicationID: response.ApplicationID,
+			NodeAddress:   response.NodeAddress,
+		}
+		s.log = s.log.WithFields(logger.Fields{"pinned": s.pinned})
+		s.log.Info("WebSocket connection pinned to node")
+	}
+	return response, nil
+}
+
+// messageID returns the JSON-RPC id of the request of the message, nil if it has none or can not be parsed
+func messageID(message []byte) json.RawMessage {
+	var m struct {
+		RawData struct {
+			ID json.RawMessage `json:"id"`
+		} `json:"rawData"`
+	}
+	if err := json.Unmarshal(message, &m); err != nil {
+		return nil
+	}
+	return m.RawData.ID
+}
+
+// wsConn is a WebSocket connection applying the idle and write timeouts of the server
+type wsConn struct {
+	*websocket.Conn
+	idleTimeout  time.Duration
+	writeTimeout time.Duration
+	closeOnce    sync.Once
+}
+
+func newWSConn(ws *websocket.Conn, settings ServerSettings) *wsConn {
+	maxMessageSize := settings.MaxBodySize
+	if maxMessageSize <= 0 {
+		maxMessageSize = defaultMaxMessageSize
+	}
+	ws.SetReadLimit(maxMessageSize)
+
+	return &wsConn{
+		Conn:         ws,
+		idleTimeout:  settings.IdleTimeout,
+		writeTimeout: settings.WriteTimeout,
+	}
+}
+
+// readMessage returns the next data message, pings are answered while waiting for it
+func (c *wsConn) readMessage() ([]byte, error) {
+	var deadline time.Time
+	if c.idleTimeout > 0 {
+		deadline = time.Now().Add(c.idleTimeout)
+	}
+	if err := c.SetReadDeadline(deadline); err != nil {
+		return nil, err
+	}
+
+	_, message, err := c.ReadMessage()
+	return message, err
+}
+
+// extendIdleTimeout restarts the idle timeout of the connection, e.g. once a notification is written to it
+func (c *wsConn) extendIdleTimeout() error {
+	if c.idleTimeout <= 0 {
+		return nil
+	}
+	return c.SetReadDeadline(time.Now().Add(c.idleTimeout))
+}
+
+func (c *wsConn) writeMessage(data []byte) error {
+	if err := c.SetWriteDeadline(c.writeDeadline()); err != nil {
+		return err
+	}
+	return c.WriteMessage(websocket.TextMessage, data)
+}
+
+func (c *wsConn) writeDeadline() time.Time {
+	if c.writeTimeout <= 0 {
+		return time.Time{}
+	}
+	return time.Now().Add(c.writeTimeout)
+}
+
+// writeError writes the JSON-RPC error of the relay error for the request with the id
+func (c *wsConn) writeError(id json.RawMessage, err error) error {
+	data, marshalErr := json.Marshal(newJSONRPCErrorResponse(id, err))
+	if marshalErr != nil {
+		return marshalErr
+	}
+	return c.writeMessage(data)
+}
+
+// writeResult writes the JSON-RPC response of the request with the id
+func (c *wsConn) writeResult(id json.RawMessage, result any) error {
+	data, err := json.Marshal(jsonRPCResultResponse{JSONRPC: "2.0", ID: id, Result: result})
+	if err != nil {
+		return err
+	}
+	return c.writeMessage(data)
+}
+
+// writeNotification writes the eth_subscription notification of the subscription with the result
+func (c *wsConn) writeNotification(subscriptionID string, result json.RawMessage) error {
+	data, err := json.Marshal(jsonRPCNotification{
+		JSONRPC: "2.0",
+		Method:  "eth_subscription",
+		Params:  jsonRPCNotificationParams{Subscription: subscriptionID, Result: result},
+	})
+	if err != nil {
+		return err
+	}
+	return c.writeMessage(data)
+}
+
+// close sends a close frame with the status code and reason, then closes the connection.
+// It may be called concurrently with reads and writes, e.g. on server shutdown.
+func (c *wsConn) close(code int, reason string) {
+	c.closeOnce.Do(func() {
+		c.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, reason), c.writeDeadline())
+		c.Conn.Close()
+	})
+}
@@ -0,0 +1,415 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/gorilla/websocket"
+	logger "github.com/sirupsen/logrus"
+
+	"github.com/pokt-foundation/portal-api-go/relay"
+	"github.com/pokt-foundation/portal-api-go/repository"
+)
+
+const wsTestPath = "/mainnet/v1/app-12345678901234567890"
+
+func TestWebSocket(t *testing.T) {
+	f := &fakeWSRelayer{response: relay.RelayResponse{Data: `{"result":"0x1"}`, ApplicationID: "app-12345678901234567890", NodeAddress: "node-1"}}
+	repo := fakeRepository{apps: map[string]repository.Application{"app-12345678901234567890": {}}}
+	// The handshake and the first two messages use up the burst of the application
+	settings := RateLimitSettings{PerApplication: Rate{PerSecond: 0.001, Burst: 3}}
+	handler := RateLimit(WebSocket(failingHandler(t), f, repo, nil, DefaultServerSettings(), logger.New()), repo, settings, nil, logger.New())
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	conn := dialWebSocket(t, server, wsTestPath)
+	defer conn.Close()
+
+	writeMessage(t, conn, `{"blockchainID": "0021", "rawData": {"method": "eth_blockNumber"}}`)
+	if data := readMessage(t, conn); data != `{"result":"0x1"}` {
+		t.Fatalf("Expected relay response, got: %s", data)
+	}
+	if first := f.last(); first.NodeAddress != "" || first.BlockchainID != "0021" || first.ApplicationID != "app-12345678901234567890" {
+		t.Errorf("Unexpected first relay: %+v", first)
+	}
+
+	pong := make(chan string, 1)
+	conn.SetPongHandler(func(data string) error {
+		pong <- data
+		return nil
+	})
+	if err := conn.WriteControl(websocket.PingMessage, []byte("ping"), time.Now().Add(time.Second)); err != nil {
+		t.Fatalf("Error writing ping: %v", err)
+	}
+
+	// Following messages are pinned to the blockchain and node of the first relay
+	writeMessage(t, conn, `{"rawData": {"method": "eth_chainId"}}`)
+	readMessage(t, conn)
+	if data := <-pong; data != "ping" {
+		t.Errorf("Expected pong, got: %s", data)
+	}
+	if pinned := f.last(); pinned.NodeAddress != "node-1" || pinned.BlockchainID != "0021" || pinned.RawData != `{"method":"eth_chainId"}` {
+		t.Errorf("Expected relay pinned to the node, got: %+v", pinned)
+	}
+
+	writeMessage(t, conn, `{"rawData": {"method": "eth_chainId"}}`)
+	if rateLimited := readError(t, conn); rateLimited.Error.Code != ErrRateLimited.Code {
+		t.Errorf("Expected rate limit error, got: %+v", rateLimited)
+	}
+	if relays := f.count(); relays != 2 {
+		t.Errorf("Expected 2 relays, got: %d", relays)
+	}
+
+	if err := conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, "")); err != nil {
+		t.Fatalf("Error writing close frame: %v", err)
+	}
+	if _, _, err := conn.ReadMessage(); !websocket.IsCloseError(err, websocket.CloseNormalClosure) {
+		t.Errorf("Expected close frame, got: %v", err)
+	}
+}
+
+func TestWebSocketErrors(t *testing.T) {
+	f := &fakeWSRelayer{response: relay.RelayResponse{ApplicationID: "app-12345678901234567890", NodeAddress: "node-1"}}
+	repo := fakeRepository{apps: map[string]repository.Application{"app-12345678901234567890": {}}}
+	server := httptest.NewServer(WebSocket(failingHandler(t), f, repo, nil, DefaultServerSettings(), logger.New()))
+	defer server.Close()
+
+	conn := dialWebSocket(t, server, wsTestPath)
+	defer conn.Close()
+
+	writeMessage(t, conn, `{"blockchainID": "0021"}`)
+	readMessage(t, conn)
+
+	writeMessage(t, conn, `{"blockchainID": "0040", "rawData": {"id": "a1", "method": "eth_chainId"}}`)
+	if response := readError(t, conn); !strings.Contains(response.Error.Message, ErrBlockchainPinned.Error()) || string(response.ID) != `"a1"` {
+		t.Errorf("Expected pinned blockchain error of request a1, got: %+v", response)
+	}
+
+	writeMessage(t, conn, `{"rawData": {"id": 2, "method": "eth_subscribe"}}`)
+	if response := readError(t, conn); response.Error.Code != ErrInvalidSubscriptionParams.Code || string(response.ID) != "2" {
+		t.Errorf("Expected invalid params error of request 2, got: %+v", response)
+	}
+
+	writeMessage(t, conn, `{"rawData": {"id": "3", "method": "eth_subscribe", "params": ["newPendingTransactions"]}}`)
+	if response := readError(t, conn); response.Error.Code != ErrSubscriptionsNotSupported.Code || string(response.ID) != `"3"` {
+		t.Errorf("Expected subscriptions error of request 3, got: %+v", response)
+	}
+
+	writeMessage(t, conn, `not json`)
+	if response := readError(t, conn); string(response.ID) != "null" {
+		t.Errorf("Expected error without id, got: %+v", response)
+	}
+	if relays := f.count(); relays != 1 {
+		t.Errorf("Expected invalid subscriptions not to be relayed, got: %d relays", relays)
+	}
+
+	f.setError(fmt.Errorf("%w: node-1", relay.ErrNodeUnavailable))
+	writeMessage(t, conn, `{"blockchainID": "0021"}`)
+	if _, _, err := conn.ReadMessage(); !websocket.IsCloseError(err, websocket.CloseServiceRestart) {
+		t.Errorf("Expected close frame once the node rotates out of the session, got: %v", err)
+	}
+}
+
+func TestWebSocketSubscriptions(t *testing.T) {
+	f := &fakeNodeRelayer{block: 0x10}
+	repo := fakeRepository{apps: map[string]repository.Application{"app-12345678901234567890": {}}}
+	settings := DefaultServerSettings()
+	settings.SubscriptionPollInterval = 10 * time.Millisecond
+	server := httptest.NewServer(WebSocket(failingHandler(t), f, repo, nil, settings, logger.New()))
+	defer server.Close()
+
+	conn := dialWebSocket(t, server, wsTestPath)
+	defer conn.Close()
+
+	writeMessage(t, conn, `{"blockchainID": "0021", "rawData": {"id": 1, "method": "eth_subscribe", "params": ["newHeads"]}}`)
+	heads := readResult(t, conn)
+	// Params are also accepted as strings, like the other fields of rawData
+	writeMessage(t, conn, `{"rawData": {"id": 2, "method": "eth_subscribe", "params": "[\"logs\", {\"address\": \"0xabc\"}]"}}`)
+	logs := readResult(t, conn)
+	if heads.ID != "1" || logs.ID != "2" || heads.Result == logs.Result {
+		t.Fatalf("Expected subscription IDs, got: %+v, %+v", heads, logs)
+	}
+
+	// Notifications are sent once the pinned node adds a block
+	f.setBlock(0x11)
+	notified := make(map[string][]string)
+	for i := 0; i < 3; i++ {
+		var n jsonRPCNotification
+		if err := json.Unmarshal([]byte(readMessage(t, conn)), &n); err != nil || n.Method != "eth_subscription" {
+			t.Fatalf("Expected notification, got: %+v, error: %v", n, err)
+		}
+		notified[n.Params.Subscription] = append(notified[n.Params.Subscription], string(n.Params.Result))
+	}
+	expected := map[string][]string{
+		heads.Result: {`{"number":"0x11"}`},
+		logs.Result:  {`{"logIndex":"0x0"}`, `{"logIndex":"0x1"}`},
+	}
+	if diff := cmp.Diff(expected, notified); diff != "" {
+		t.Errorf("unexpected notifications (-want +got):\n%s", diff)
+	}
+
+	filter := f.lastRequest("eth_getLogs")
+	if filter.NodeAddress != "node-1" || filter.BlockchainID != "0021" ||
+		filter.RawData != `{"id":1,"jsonrpc":"2.0","method":"eth_getLogs","params":[{"address":"0xabc","fromBlock":"0x11","toBlock":"0x11"}]}` {
+		t.Errorf("Expected logs of the new block from the pinned node, got: %+v", filter)
+	}
+
+	writeMessage(t, conn, fmt.Sprintf(`{"rawData": {"id": 3, "method": "eth_unsubscribe", "params": [%q]}}`, heads.Result))
+	if response := readMessage(t, conn); response != `{"jsonrpc":"2.0","id":3,"result":true}` {
+		t.Errorf("Expected subscription to be removed, got: %s", response)
+	}
+	writeMessage(t, conn, fmt.Sprintf(`{"rawData": {"id": 4, "method": "eth_unsubscribe", "params": [%q]}}`, heads.Result))
+	if response := readMessage(t, conn); response != `{"jsonrpc":"2.0","id":4,"result":false}` {
+		t.Errorf("Expected unknown subscription, got: %s", response)
+	}
+
+	writeMessage(t, conn, `{"rawData": {"id": 5, "method": "eth_unsubscribe"}}`)
+	if response := readError(t, conn); response.Error.Code != ErrInvalidSubscriptionParams.Code {
+		t.Errorf("Expected invalid params error, got: %+v", response)
+	}
+}
+
+func TestWebSocketShutdown(t *testing.T) {
+	repo := fakeRepository{apps: map[string]repository.Application{"app-12345678901234567890": {}}}
+	server := httptest.NewUnstartedServer(nil)
+	server.Config = NewServer("", WebSocket(failingHandler(t), &fakeWSRelayer{}, repo, nil, DefaultServerSettings(), logger.New()), DefaultServerSettings())
+	server.Start()
+	defer server.Close()
+
+	conn := dialWebSocket(t, server, wsTestPath)
+	defer conn.Close()
+
+	if err := server.Config.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, _, err := conn.ReadMessage(); !websocket.IsCloseError(err, websocket.CloseGoingAway) {
+		t.Errorf("Expected close frame on shutdown, got: %v", err)
+	}
+}
+
+func TestWebSocketHandshake(t *testing.T) {
+	repo := fakeRepository{apps: map[string]repository.Application{"app-12345678901234567890": {}}}
+	handler := WebSocket(func(w http.ResponseWriter, req *http.Request) {
+		fmt.Fprintf(w, "not a websocket")
+	}, &fakeWSRelayer{}, repo, nil, DefaultServerSettings(), logger.New())
+
+	testCases := []struct {
+		name           string
+		path           string
+		headers        map[string]string
+		expectedStatus int
+	}{
+		{
+			name:           "Requests without upgrade are served by the relay handler",
+			path:           wsTestPath,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Unknown application is rejected",
+			path:           "/mainnet/v1/app-00000000000000000000",
+			headers:        map[string]string{"Connection": "Upgrade", "Upgrade": "websocket", "Sec-WebSocket-Version": "13"},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Unsupported version is rejected",
+			path:           wsTestPath,
+			headers:        map[string]string{"Connection": "keep-alive, Upgrade", "Upgrade": "websocket", "Sec-WebSocket-Version": "8"},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Missing key is rejected",
+			path:           wsTestPath,
+			headers:        map[string]string{"Connection": "Upgrade", "Upgrade": "websocket", "Sec-WebSocket-Version": "13"},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+			for name, value := range tc.headers {
+				req.Header.Set(name, value)
+			}
+
+			w := httptest.NewRecorder()
+			handler(w, req)
+			if status := w.Result().StatusCode; status != tc.expectedStatus {
+				t.Errorf("Expected status code: %d, got: %d", tc.expectedStatus, status)
+			}
+		})
+	}
+}
+
+func failingHandler(t *testing.T) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		t.Errorf("Unexpected request to the relay handler: %s", req.URL.Path)
+	}
+}
+
+type fakeWSRelayer struct {
+	mu       sync.Mutex
+	relays   []relay.RelayOptions
+	response relay.RelayResponse
+	err      error
+}
+
+func (f *fakeWSRelayer) RelayWithApp(r relay.RelayOptions) (relay.RelayResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.relays = append(f.relays, r)
+	return f.response, f.err
+}
+
+func (f *fakeWSRelayer) RelayWithLb(r relay.RelayOptions) (relay.RelayResponse, error) {
+	return f.RelayWithApp(r)
+}
+
+func (f *fakeWSRelayer) last() relay.RelayOptions {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.relays[len(f.relays)-1]
+}
+
+func (f *fakeWSRelayer) count() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.relays)
+}
+
+func (f *fakeWSRelayer) setError(err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.err = err
+}
+
+// fakeNodeRelayer answers the JSON-RPC requests of subscriptions like a node at the block
+type fakeNodeRelayer struct {
+	mu     sync.Mutex
+	block  uint64
+	relays []relay.RelayOptions
+}
+
+func (f *fakeNodeRelayer) RelayWithApp(r relay.RelayOptions) (relay.RelayResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.relays = append(f.relays, r)
+
+	var request struct {
+		Method string            `json:"method"`
+		Params []json.RawMessage `json:"params"`
+	}
+	if err := json.Unmarshal([]byte(r.RawData), &request); err != nil {
+		return relay.RelayResponse{}, err
+	}
+
+	var result string
+	switch request.Method {
+	case "eth_blockNumber":
+		result = fmt.Sprintf(`"0x%x"`, f.block)
+	case "eth_getBlockByNumber":
+		result = fmt.Sprintf(`{"number":%s}`, request.Params[0])
+	case "eth_getLogs":
+		result = `[{"logIndex":"0x0"},{"logIndex":"0x1"}]`
+	default:
+		return relay.RelayResponse{}, fmt.Errorf("unexpected method %s", request.Method)
+	}
+	return relay.RelayResponse{
+		Data:          `{"jsonrpc":"2.0","id":1,"result":` + result + `}`,
+		ApplicationID: "app-12345678901234567890",
+		NodeAddress:   "node-1",
+	}, nil
+}
+
+func (f *fakeNodeRelayer) RelayWithLb(r relay.RelayOptions) (relay.RelayResponse, error) {
+	return f.RelayWithApp(r)
+}
+
+func (f *fakeNodeRelayer) setBlock(block uint64) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.block = block
+}
+
+func (f *fakeNodeRelayer) lastRequest(method string) relay.RelayOptions {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for i := len(f.relays) - 1; i >= 0; i-- {
+		if strings.Contains(f.relays[i].RawData, `"method":"`+method+`"`) {
+			return f.relays[i]
+		}
+	}
+	return relay.RelayOptions{}
+}
+
+func dialWebSocket(t *testing.T, server *httptest.Server, path string) *websocket.Conn {
+	t.Helper()
+
+	conn, resp, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+path, nil)
+	if err != nil {
+		t.Fatalf("Error dialing: %v", err)
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("Expected status code: %d, got: %d", http.StatusSwitchingProtocols, resp.StatusCode)
+	}
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	return conn
+}
+
+func writeMessage(t *testing.T, conn *websocket.Conn, message string) {
+	t.Helper()
+
+	if err := conn.WriteMessage(websocket.TextMessage, []byte(message)); err != nil {
+		t.Fatalf("Error writing message: %v", err)
+	}
+}
+
+func readMessage(t *testing.T, conn *websocket.Conn) string {
+	t.Helper()
+
+	op, data, err := conn.ReadMessage()
+	if err != nil {
+		t.Fatalf("Error reading message: %v", err)
+	}
+	if op != websocket.TextMessage {
+		t.Fatalf("Expected text message, got: %d", op)
+	}
+	return string(data)
+}
+
+func readError(t *testing.T, conn *websocket.Conn) jsonRPCErrorResponse {
+	t.Helper()
+
+	data := readMessage(t, conn)
+	var response jsonRPCErrorResponse
+	if err := json.Unmarshal([]byte(data), &response); err != nil {
+		t.Fatalf("Expected JSON-RPC error, got: %s, error: %v", data, err)
+	}
+	return response
+}
+
+type jsonRPCTestResult struct {
+	ID     string
+	Result string
+}
+
+func readResult(t *testing.T, conn *websocket.Conn) jsonRPCTestResult {
+	t.Helper()
+
+	data := readMessage(t, conn)
+	var response struct {
+		ID     json.RawMessage `json:"id"`
+		Result string          `json:"result"`
+	}
+	if err := json.Unmarshal([]byte(data), &response); err != nil {
+		t.Fatalf("Expected JSON-RPC result, got: %s, error: %v", data, err)
+	}
+	return jsonRPCTestResult{ID: string(response.ID), Result: response.Result}
+}